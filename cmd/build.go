@@ -16,19 +16,30 @@ var buildCmd = &cobra.Command{
 	The results are made available in <DestDir>/SRPMS/<package> and <DestDir>/RPMS/<package>.
 	The manifest might specify only a single package(SRPM) per repo in the general case.
 	In situations where multiple packages need to be built in dependency order, the manifest might specify multple packages. The [ -p <package> ] can also be used to just build a specific package.
+	Packages with a build matrix can be built for a variant with --variant <variant>, or for all variants with --all-variants.
+	The results of a variant are made available under <DestDir>/variants/<variant>.
 	`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		repo, _ := cmd.Flags().GetString("repo")
 		pkg, _ := cmd.Flags().GetString("package")
 		doBuildPrep, _ := cmd.Flags().GetBool("do-build-prep")
 		noCheck, _ := cmd.Flags().GetBool("nocheck")
+		ignoreLock, _ := cmd.Flags().GetBool("ignore-lock")
+		variant, _ := cmd.Flags().GetString("variant")
+		allVariants, _ := cmd.Flags().GetBool("all-variants")
 		extraCreateSrpmArgs := impl.CreateSrpmExtraCmdlineArgs{
 			DoBuildPrep: doBuildPrep,
 		}
 		extraMockArgs := impl.MockExtraCmdlineArgs{
-			NoCheck: noCheck,
+			NoCheck:    noCheck,
+			IgnoreLock: ignoreLock,
 		}
-		return impl.Build(repo, pkg, defaultArch, extraCreateSrpmArgs, extraMockArgs, selectExecutor())
+		extraBuildArgs := impl.BuildExtraCmdlineArgs{
+			Variant:     variant,
+			AllVariants: allVariants,
+		}
+		return impl.Build(repo, pkg, defaultArch, extraCreateSrpmArgs, extraMockArgs, extraBuildArgs,
+			selectExecutor())
 	},
 }
 
@@ -39,5 +50,9 @@ func init() {
 	buildCmd.Flags().MarkHidden("skip-build-prep")
 	buildCmd.Flags().Bool("do-build-prep", false, "Runs build-prep on the created SRPM to make sure patches apply cleanly (OPTIONAL)")
 	buildCmd.Flags().Bool("nocheck", false, "Pass --nocheck to rpmbuild (OPTIONAL)")
+	buildCmd.Flags().String("variant", "", "Build variant from the package build matrix (OPTIONAL)")
+	buildCmd.Flags().Bool("all-variants", false, "Build all variants from the package build matrix (OPTIONAL)")
+	buildCmd.MarkFlagsMutuallyExclusive("variant", "all-variants")
+	buildCmd.Flags().Bool("ignore-lock", false, "Don't pin repo-bundles to eext.lock even if present (OPTIONAL)")
 	rootCmd.AddCommand(buildCmd)
 }
@@ -0,0 +1,37 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"code.arista.io/eos/tools/eext/impl"
+)
+
+// convertCmd represents the convert command
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert a package to another type",
+	Long: `Converts the unmodified-srpm package specified with -p <package> to an srpm package, so that it can be modified.
+The upstream SRPM is unpacked, its spec file is put in the spec dir of the package, with the Release patched
+with %{eext_release} like unmodified-srpm builds do. The patches and other sources of the upstream SRPM,
+except the tarballs, are put in the sources dir. The type of the package is updated in eext.yaml.
+The sources are expected to be in <SrcDir>/<repo> if --repo <repo> is specified,
+otherwise in current working directory.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, _ := cmd.Flags().GetString("repo")
+		pkg, _ := cmd.Flags().GetString("package")
+		to, _ := cmd.Flags().GetString("to")
+		return impl.Convert(repo, pkg, to, selectExecutor())
+	},
+}
+
+func init() {
+	convertCmd.Flags().StringP("repo", "r", "", "Repository name (OPTIONAL)")
+	convertCmd.Flags().StringP("package", "p", "", "package name")
+	convertCmd.Flags().String("to", "srpm", "Type to convert to, only srpm is supported (OPTIONAL)")
+	convertCmd.MarkFlagRequired("package")
+	rootCmd.AddCommand(convertCmd)
+}
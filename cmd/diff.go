@@ -0,0 +1,45 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"code.arista.io/eos/tools/eext/impl"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the changes to a package relative to upstream",
+	Long: `Compares the upstream SRPM or tarballs of the package specified with -p <package>
+with the SRPM built by eext create-srpm, which is expected in <SrpmsDir>/<package>.
+The upstream is fetched and verified like create-srpm does.
+Shows the unified diff of the spec files, the patches and sources added(A), removed(D) and changed(M),
+and the unified diff of the source trees after %prep, which is run with rpmbuild, unless --skip-prep is specified.
+The sources are expected to be in <SrcDir>/<repo> if --repo <repo> is specified,
+otherwise in current working directory.
+The output is text by default, or JSON with --format json.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, _ := cmd.Flags().GetString("repo")
+		pkg, _ := cmd.Flags().GetString("package")
+		format, _ := cmd.Flags().GetString("format")
+		skipPrep, _ := cmd.Flags().GetBool("skip-prep")
+		extraArgs := impl.DiffExtraCmdlineArgs{
+			Format:   format,
+			SkipPrep: skipPrep,
+		}
+		return impl.Diff(repo, pkg, extraArgs, selectExecutor())
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringP("repo", "r", "", "Repository name (OPTIONAL)")
+	diffCmd.Flags().StringP("package", "p", "", "package name")
+	diffCmd.Flags().String("format", "text", "Output format, text or json (OPTIONAL)")
+	diffCmd.Flags().Bool("skip-prep", false, "Skip the diff of the source trees after %prep (OPTIONAL)")
+	diffCmd.MarkFlagRequired("package")
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"code.arista.io/eos/tools/eext/impl"
+)
+
+// fetchProxyForwardCmd runs in the mock chroot, as the rpmbuild wrapper
+// of builds with a network policy.
+var fetchProxyForwardCmd = &cobra.Command{
+	Use:                "fetch-proxy-forward SOCKET LISTEN-ADDR COMMAND [ARGS...]",
+	Short:              "Run a command with LISTEN-ADDR forwarded to the fetch proxy socket",
+	Hidden:             true,
+	DisableFlagParsing: true,
+	Args:               cobra.MinimumNArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		exitCode, err := impl.FetchProxyForward(args[0], args[1], args[2:])
+		if err != nil {
+			return err
+		}
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fetchProxyForwardCmd)
+}
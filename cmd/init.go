@@ -0,0 +1,51 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"code.arista.io/eos/tools/eext/impl"
+)
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init <upstream-url>",
+	Short: "Set up a repo for a new package",
+	Long: `Sets up the repo for a new package built from the upstream source at <upstream-url>,
+which is an SRPM(*.src.rpm), a tarball or a git repo(*.git, or any URL with --revision).
+The repo is created in <SrcDir>/<repo> if --repo <repo> is specified, otherwise in the current working directory.
+eext.yaml is written with the sha256 of the upstream source, and the spec and sources dirs are created.
+The spec file and patches of an upstream SRPM are imported into the repo.
+For tarballs and git repos, a starter spec file using %{eext_release} is written, fill in its TODOs.
+The upstream source isn't verified by a signature until the manifest is updated with one.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, _ := cmd.Flags().GetString("repo")
+		name, _ := cmd.Flags().GetString("name")
+		revision, _ := cmd.Flags().GetString("revision")
+		subdir, _ := cmd.Flags().GetBool("subdir")
+		distro, _ := cmd.Flags().GetString("distro")
+		repoBundle, _ := cmd.Flags().GetStringSlice("repo-bundle")
+		extraArgs := impl.InitExtraCmdlineArgs{
+			Name:       name,
+			Revision:   revision,
+			Subdir:     subdir,
+			Distro:     distro,
+			RepoBundle: repoBundle,
+		}
+		return impl.Init(repo, args[0], extraArgs)
+	},
+}
+
+func init() {
+	initCmd.Flags().StringP("repo", "r", "", "Repository name (OPTIONAL)")
+	initCmd.Flags().StringP("name", "n", "", "Package name, guessed from the upstream source by default (OPTIONAL)")
+	initCmd.Flags().String("revision", "", "Commit/tag of a git upstream source")
+	initCmd.Flags().Bool("subdir", false, "Put the package in a subdir of the repo (OPTIONAL)")
+	initCmd.Flags().String("distro", "", "Distro to build for, el9 by default (OPTIONAL)")
+	initCmd.Flags().StringSlice("repo-bundle", nil, "Repo-bundles to build with, the one named after the distro by default (OPTIONAL)")
+	rootCmd.AddCommand(initCmd)
+}
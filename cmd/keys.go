@@ -0,0 +1,49 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"code.arista.io/eos/tools/eext/impl"
+)
+
+// keysCmd represents the keys command
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Audit the keys in the PKI dir",
+	Long: `Audit the rpm keys and trusted detached signers in the PKI dir,
+along with the trust policy in trust-policy.yaml which restricts the keys allowed to sign upstream SRPMs.
+`,
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the keys in the PKI dir with their expiry and revocation status",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return impl.KeysList()
+	},
+}
+
+var keysCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check for expired/revoked keys and keys in the trust policy missing from the PKI dir",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		if err = impl.KeysCheck(); err == nil {
+			log.Println("SUCCESS: keys check")
+		}
+		return err
+	},
+}
+
+func init() {
+	keysCmd.AddCommand(keysListCmd)
+	keysCmd.AddCommand(keysCheckCmd)
+	rootCmd.AddCommand(keysCmd)
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"code.arista.io/eos/tools/eext/impl"
+)
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Pin repo-bundles in the manifest to concrete versions",
+	Long: `Resolves every repo-bundle in the manifest to its concrete version and the baseurl of
+each of its repos for every target arch, and writes the results to eext.lock next to the manifest.
+mock pins the repo-bundles to eext.lock if present, so that moving version labels like 'default'
+in the dnf configuration doesn't silently change the build.
+If eext.lock is already present and out of date, the changes are listed and an error is returned.
+Use --update to apply the changes.
+`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, _ := cmd.Flags().GetString("repo")
+		update, _ := cmd.Flags().GetBool("update")
+		return impl.Lock(repo, update)
+	},
+}
+
+func init() {
+	lockCmd.Flags().StringP("repo", "r", "", "Repository name (OPTIONAL)")
+	lockCmd.Flags().Bool("update", false, "Show changes and update an out of date eext.lock (OPTIONAL)")
+	rootCmd.AddCommand(lockCmd)
+}
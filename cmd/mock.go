@@ -23,9 +23,11 @@ var mockCmd = &cobra.Command{
 		target, _ := cmd.Flags().GetString("target")
 		onlyCreateCfg, _ := cmd.Flags().GetBool("only-create-cfg")
 		noCheck, _ := cmd.Flags().GetBool("nocheck")
+		ignoreLock, _ := cmd.Flags().GetBool("ignore-lock")
 		extraArgs := impl.MockExtraCmdlineArgs{
 			NoCheck:       noCheck,
 			OnlyCreateCfg: onlyCreateCfg,
+			IgnoreLock:    ignoreLock,
 		}
 		return impl.Mock(repo, pkg, target, extraArgs, selectExecutor())
 	},
@@ -37,5 +39,6 @@ func init() {
 	mockCmd.Flags().StringP("target", "t", defaultArch, "target architecture for the rpmbuild (OPTIONAL)")
 	mockCmd.Flags().Bool("only-create-cfg", false, "Just create mock configuration, don't run mock (OPTIONAL)")
 	mockCmd.Flags().Bool("nocheck", false, "Pass --nocheck to rpmbuild (OPTIONAL)")
+	mockCmd.Flags().Bool("ignore-lock", false, "Don't pin repo-bundles to eext.lock even if present (OPTIONAL)")
 	rootCmd.AddCommand(mockCmd)
 }
@@ -0,0 +1,38 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"code.arista.io/eos/tools/eext/impl"
+)
+
+// multilibCmd represents the multilib command
+var multilibCmd = &cobra.Command{
+	Use:   "multilib",
+	Short: "Compose multilib RPM set for a package",
+	Long: `Combines the RPMs built for the native arch and the other arch(i686 for x86_64 and vice versa)
+into a multilib RPM set, as specified by the eextgen multilib spec for the native arch in the manifest.
+The RPMs are expected to be already built in <DestDir>/RPMS/<arch>/<package>.
+The native-arch/other-arch patterns are applied, file conflicts between the arches are checked, and the
+results are made available in <DestDir>/multilib/<native-arch>/<package> along with a manifest
+of which RPMs were kept and why.
+`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, _ := cmd.Flags().GetString("repo")
+		pkg, _ := cmd.Flags().GetString("package")
+		nativeArch, _ := cmd.Flags().GetString("native")
+		return impl.Multilib(repo, pkg, nativeArch, selectExecutor())
+	},
+}
+
+func init() {
+	multilibCmd.Flags().StringP("repo", "r", "", "Repository name (OPTIONAL)")
+	multilibCmd.Flags().StringP("package", "p", "", "package name (REQUIRED)")
+	multilibCmd.MarkFlagRequired("package")
+	multilibCmd.Flags().String("native", defaultArch, "native architecture of the multilib image (OPTIONAL)")
+	rootCmd.AddCommand(multilibCmd)
+}
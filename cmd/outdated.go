@@ -0,0 +1,37 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"code.arista.io/eos/tools/eext/impl"
+)
+
+// outdatedCmd represents the outdated command
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List packages with newer upstream versions",
+	Long: `Checks the upstream sources of the packages for newer versions, and prints
+the current and latest version of each upstream source.
+All the repos in <SrcDir> are checked, unless --repo <repo> is specified.
+If SrcDir isn't set, the repo in the current working directory is checked.
+Tarballs and SRPMs are looked up in the directory index of their source-bundle or full-url.
+SRPMs are also looked up in the SRPM repos of the repo-bundles they are built with,
+which are the repos with 'source' as the arch in their baseurl.
+git-upstream sources are looked up in the tags of the git repo, with git ls-remote --tags.
+Sources in the repo(file://) are skipped.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, _ := cmd.Flags().GetString("repo")
+		pkg, _ := cmd.Flags().GetString("package")
+		return impl.Outdated(repo, pkg)
+	},
+}
+
+func init() {
+	outdatedCmd.Flags().StringP("repo", "r", "", "Repository name (OPTIONAL)")
+	outdatedCmd.Flags().StringP("package", "p", "", "package name (OPTIONAL)")
+	rootCmd.AddCommand(outdatedCmd)
+}
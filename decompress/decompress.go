@@ -0,0 +1,107 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+// Package decompress provides streaming decompression for the
+// compression formats used by RPM payloads and upstream tarballs.
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// Supported compression formats
+const (
+	Gzip  = "gzip"
+	Bzip2 = "bzip2"
+	Xz    = "xz"
+	Lzma  = "lzma"
+	Zstd  = "zstd"
+)
+
+// Formats lists the supported compression formats
+var Formats = []string{Gzip, Bzip2, Xz, Lzma, Zstd}
+
+var magics = []struct {
+	format string
+	magic  []byte
+}{
+	{Gzip, []byte{0x1f, 0x8b}},
+	{Bzip2, []byte("BZh")},
+	{Xz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{Zstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (rc *readCloser) Close() error {
+	if rc.close == nil {
+		return nil
+	}
+	return rc.close()
+}
+
+// NewReader returns a reader which decompresses r, which is compressed
+// with format. The reader must be closed to release its resources.
+func NewReader(r io.Reader, format string) (io.ReadCloser, error) {
+	switch format {
+	case Gzip:
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return gzipReader, nil
+	case Bzip2:
+		return &readCloser{Reader: bzip2.NewReader(r)}, nil
+	case Xz:
+		xzReader, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return &readCloser{Reader: xzReader}, nil
+	case Lzma:
+		lzmaReader, err := lzma.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return &readCloser{Reader: lzmaReader}, nil
+	case Zstd:
+		zstdReader, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return &readCloser{Reader: zstdReader,
+			close: func() error {
+				zstdReader.Close()
+				return nil
+			}}, nil
+	}
+	return nil, fmt.Errorf("unsupported compression format '%s'", format)
+}
+
+// Detect returns the compression format of the data in r from its magic,
+// without consuming it. An empty format is returned for data which isn't
+// compressed with one of the formats with a magic, lzma doesn't have one.
+func Detect(r *bufio.Reader) (string, error) {
+	header, err := r.Peek(6)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	for _, m := range magics {
+		if bytes.HasPrefix(header, m.magic) {
+			return m.format, nil
+		}
+	}
+	return "", nil
+}
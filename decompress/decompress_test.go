@@ -0,0 +1,93 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+func compress(t *testing.T, format string, data []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch format {
+	case Gzip:
+		w = gzip.NewWriter(&buf)
+	case Xz:
+		w, err = xz.NewWriter(&buf)
+	case Lzma:
+		w, err = lzma.NewWriter(&buf)
+	case Zstd:
+		w, err = zstd.NewWriter(&buf)
+	}
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	data := bytes.Repeat([]byte("eext decompress test\n"), 1000)
+	for _, format := range []string{Gzip, Xz, Lzma, Zstd} {
+		t.Logf("Testing %s", format)
+		compressed := compress(t, format, data)
+
+		reader := bufio.NewReader(bytes.NewReader(compressed))
+		detected, err := Detect(reader)
+		require.NoError(t, err)
+		if format == Lzma {
+			require.Empty(t, detected)
+		} else {
+			require.Equal(t, format, detected)
+		}
+
+		decompressor, err := NewReader(reader, format)
+		require.NoError(t, err)
+		decompressed, err := io.ReadAll(decompressor)
+		require.NoError(t, err)
+		require.NoError(t, decompressor.Close())
+		require.Equal(t, data, decompressed)
+
+		t.Logf("Testing truncated %s", format)
+		decompressor, err = NewReader(bytes.NewReader(compressed[:len(compressed)/2]), format)
+		if err == nil {
+			_, err = io.ReadAll(decompressor)
+			decompressor.Close()
+		}
+		require.Error(t, err)
+	}
+
+	t.Log("Testing bzip2")
+	// bzip2 of "hello\n", the standard library has no bzip2 compressor
+	bzip2Data := []byte{0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59,
+		0xc1, 0xc0, 0x80, 0xe2, 0x00, 0x00, 0x01, 0x41, 0x00, 0x00, 0x10, 0x02,
+		0x44, 0xa0, 0x00, 0x30, 0xcd, 0x00, 0xc3, 0x46, 0x29, 0x97, 0x17, 0x72,
+		0x45, 0x38, 0x50, 0x90, 0xc1, 0xc0, 0x80, 0xe2}
+	reader := bufio.NewReader(bytes.NewReader(bzip2Data))
+	detected, err := Detect(reader)
+	require.NoError(t, err)
+	require.Equal(t, Bzip2, detected)
+	decompressor, err := NewReader(reader, Bzip2)
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(decompressor)
+	require.NoError(t, err)
+	require.Equal(t, "hello\n", string(decompressed))
+
+	t.Log("Testing uncompressed and unsupported")
+	detected, err = Detect(bufio.NewReader(bytes.NewReader([]byte("plain"))))
+	require.NoError(t, err)
+	require.Empty(t, detected)
+	_, err = NewReader(bytes.NewReader(nil), "7z")
+	require.ErrorContains(t, err, "unsupported compression format '7z'")
+}
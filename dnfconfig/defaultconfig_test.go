@@ -221,5 +221,15 @@ func TestDefaultDnfRepoConfig(t *testing.T) {
 	require.Equal(t, len(expectedRepoBundles), len(dnfConfig.DnfRepoBundleConfig))
 	t.Log("No unexpected repo-bundles in defaults")
 
+	t.Log("Testing distro profiles in defaults")
+	for _, distro := range []string{"el9", "el10", "fc40"} {
+		require.Contains(t, dnfConfig.Distro, distro)
+	}
+	for bundleName, bundleConfig := range dnfConfig.DnfRepoBundleConfig {
+		require.NotEmpty(t, bundleConfig.Distro, bundleName)
+	}
+	require.Equal(t, "el9", dnfConfig.DnfRepoBundleConfig["el9-unsafe"].Distro)
+	require.Equal(t, "fc40", dnfConfig.DnfRepoBundleConfig["fc40-unsafe"].Distro)
+
 	t.Log("TestDefaultDnfRepoConfig test passed")
 }
@@ -7,9 +7,11 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"strings"
 	"text/template"
 
 	"github.com/spf13/viper"
+	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v2"
 
 	"code.arista.io/eos/tools/eext/util"
@@ -19,27 +21,110 @@ const (
 	RepoHighPriority int = 1
 )
 
+// targetArchs are the archs that can be specified in arch-overrides
+var targetArchs = []string{"i686", "x86_64", "aarch64"}
+
+// DnfRepoArchOverride spec
+// Overrides the parameters of a repo when building for a particular target arch.
+// Enabled: enables/disables the repo, the repo is left as is if unset.
+// BaseURLFormat: baseurl template to use instead of the bundle's baseurl.
+// Arch: arch substituted for {{.Arch}} in the baseurl template.
+// Exclude: packages to exclude from the repo.
+type DnfRepoArchOverride struct {
+	Enabled       *bool  `yaml:"enabled"`
+	BaseURLFormat string `yaml:"baseurl"`
+	Arch          string `yaml:"arch"`
+	Exclude       string `yaml:"exclude"`
+}
+
 // DnfRepoConfig holds baseURL format template(/string)
+// ArchOverrides are indexed by target arch and take precedence over
+// the arch-overrides of the bundle.
+// Options are extra dnf.conf options for the repo, which take precedence
+// over the options of the bundle.
 type DnfRepoConfig struct {
-	Enabled bool   `yaml:"enabled"`
-	Exclude string `yaml:"exclude"`
+	Enabled       bool                            `yaml:"enabled"`
+	Exclude       string                          `yaml:"exclude"`
+	ArchOverrides map[string]*DnfRepoArchOverride `yaml:"arch-overrides"`
+	Options       map[string]string               `yaml:"options"`
 }
 
 // DnfRepoBundleConfig is a collection of DnfRepoConfig indexed by name
+// Extends names another bundle from which all fields not explicitly set
+// in this bundle are inherited. Repos are merged by name, with repos
+// specified in this bundle taking precedence.
+// Distro names the distro profile the bundle belongs to, a bundle
+// without one can be used with any distro.
+// ArchOverrides are indexed by target arch and apply to all repos in the bundle.
+// Options are extra dnf.conf options applied to all repos in the bundle.
+// Modules are module streams to enable/disable when using the bundle.
 type DnfRepoBundleConfig struct {
-	BaseURLFormat         string                    `yaml:"baseurl"`
-	GpgCheck              bool                      `yaml:"gpgcheck"`
-	GpgKey                string                    `yaml:"gpgkey"`
-	UseBaseArch           bool                      `yaml:"use-base-arch"`
-	DnfRepoConfig         map[string]*DnfRepoConfig `yaml:"repo"`
-	VersionLabels         map[string]string         `yaml:"version-labels"`
-	Priority              int                       `yaml:"priority"`
+	Extends               string                          `yaml:"extends"`
+	Distro                string                          `yaml:"distro"`
+	BaseURLFormat         string                          `yaml:"baseurl"`
+	GpgCheck              bool                            `yaml:"gpgcheck"`
+	GpgKey                string                          `yaml:"gpgkey"`
+	UseBaseArch           bool                            `yaml:"use-base-arch"`
+	DnfRepoConfig         map[string]*DnfRepoConfig       `yaml:"repo"`
+	VersionLabels         map[string]string               `yaml:"version-labels"`
+	Priority              int                             `yaml:"priority"`
+	ArchOverrides         map[string]*DnfRepoArchOverride `yaml:"arch-overrides"`
+	Options               map[string]string               `yaml:"options"`
+	Modules               DnfModules                      `yaml:"modules"`
 	baseURLFormatTemplate *template.Template
 }
 
+// DistroConfig is a distro profile, which specifies how to
+// setup the mock chroot for the distro.
+// Releasever and PlatformID are the dnf releasever and module_platform_id.
+// ChrootSetupPkgs are the packages installed in the chroot to setup the buildroot.
+// Macros are the default rpm macros for the distro.
+type DistroConfig struct {
+	Releasever      string            `yaml:"releasever"`
+	PlatformID      string            `yaml:"platform-id"`
+	ChrootSetupPkgs []string          `yaml:"chroot-setup-pkgs"`
+	Macros          map[string]string `yaml:"macros"`
+}
+
 // DnfConfig is a collection of DnfRepoBundleConfig indexed by name.
+// Distro is a collection of DistroConfig indexed by name.
 type DnfConfig struct {
 	DnfRepoBundleConfig map[string]*DnfRepoBundleConfig `yaml:"repo-bundle"`
+	Distro              map[string]*DistroConfig        `yaml:"distro"`
+}
+
+// legacyDistroName is the name of legacyDistro
+const legacyDistroName = "el9"
+
+// legacyDistro is the distro profile of dnf configs written before
+// distro profiles, which have no distro section. It has the mock chroot
+// settings eext used before distro profiles.
+var legacyDistro = DistroConfig{
+	Releasever: "9",
+	PlatformID: "platform:el9",
+	ChrootSetupPkgs: []string{
+		"bash", "bzip2", "coreutils", "cpio", "diffutils", "findutils", "gawk",
+		"glibc-minimal-langpack", "grep", "gzip", "info", "patch", "redhat-release",
+		"redhat-rpm-config", "rpm-build", "sed", "shadow-utils", "tar", "unzip",
+		"util-linux", "which", "xz",
+	},
+}
+
+// GetDistro returns the distro profile named distro.
+// A dnf config without a distro section only has the el9 profile,
+// with the settings used before distro profiles.
+func (config *DnfConfig) GetDistro(distro string) (*DistroConfig, bool) {
+	if len(config.Distro) == 0 && distro == legacyDistroName {
+		legacy := legacyDistro
+		return &legacy, true
+	}
+	distroConfig, found := config.Distro[distro]
+	return distroConfig, found
+}
+
+// InDistro checks if the bundle can be used with distro.
+func (b *DnfRepoBundleConfig) InDistro(distro string) bool {
+	return b.Distro == "" || b.Distro == distro
 }
 
 // DnfRepoURLData is used to execute baseURLFormatTemplate
@@ -52,10 +137,14 @@ type DnfRepoURLData struct {
 
 // RepoParamsOverride spec
 // this is used to override default parameters for repos in the bundle.
+// ArchOverrides take precedence over the arch-overrides in the dnf config file.
+// Options are merged with the options in the dnf config file.
 type DnfRepoParamsOverride struct {
-	Enabled  bool   `yaml:"enabled"`
-	Exclude  string `yaml:"exclude"`
-	Priority int    `yaml:"priority"`
+	Enabled       bool                            `yaml:"enabled"`
+	Exclude       string                          `yaml:"exclude"`
+	Priority      int                             `yaml:"priority"`
+	ArchOverrides map[string]*DnfRepoArchOverride `yaml:"arch-overrides"`
+	Options       map[string]string               `yaml:"options"`
 }
 
 // RepoData holds dnf repo name and baseurl for mock.cfg generation
@@ -68,6 +157,7 @@ type DnfRepoParams struct {
 	GpgKey   string
 	Exclude  string
 	Priority int
+	Options  map[string]string
 }
 
 func baseArch(arch string) string {
@@ -77,48 +167,114 @@ func baseArch(arch string) string {
 	return arch
 }
 
+// mergeArchOverrides combines the overrides for the target arch,
+// with the later archOverrides taking precedence.
+func mergeArchOverrides(arch string,
+	archOverrides ...map[string]*DnfRepoArchOverride) DnfRepoArchOverride {
+	var merged DnfRepoArchOverride
+	for _, overrides := range archOverrides {
+		override, found := overrides[arch]
+		if !found || override == nil {
+			continue
+		}
+		if override.Enabled != nil {
+			merged.Enabled = override.Enabled
+		}
+		if override.BaseURLFormat != "" {
+			merged.BaseURLFormat = override.BaseURLFormat
+		}
+		if override.Arch != "" {
+			merged.Arch = override.Arch
+		}
+		if override.Exclude != "" {
+			merged.Exclude = override.Exclude
+		}
+	}
+	return merged
+}
+
+// validateArchOverrides checks that arch-overrides are only specified for
+// valid target archs and that the baseurl overrides are valid templates.
+func validateArchOverrides(archOverrides map[string]*DnfRepoArchOverride) error {
+	for arch, override := range archOverrides {
+		if !slices.Contains(targetArchs, arch) {
+			return fmt.Errorf("arch-overrides specified for invalid arch %s, must be one of %s",
+				arch, strings.Join(targetArchs, ", "))
+		}
+		if override == nil || override.BaseURLFormat == "" {
+			continue
+		}
+		if _, parseErr := template.New("archOverride").Parse(override.BaseURLFormat); parseErr != nil {
+			return fmt.Errorf("Error parsing baseurl %s in arch-overrides for %s: %s",
+				override.BaseURLFormat, arch, parseErr)
+		}
+	}
+	return nil
+}
+
+// ResolveVersion returns the concrete version of the bundle,
+// given the version specified in the manifest.
+// If no version is specified, the 'default' label is used.
+// Version labels are translated to actual version numbers.
+func (b *DnfRepoBundleConfig) ResolveVersion(versionOverride string) string {
+	var version string
+	if versionOverride == "" {
+		version = "default"
+	} else {
+		version = versionOverride
+	}
+
+	translatedVersion, isVersionLabel := b.VersionLabels[version]
+	if !isVersionLabel {
+		translatedVersion = version
+	}
+	return translatedVersion
+}
+
 // getBaseURL generates baseURL for a particular repo
 // looking at the template in the dnfrepo config file,
 // and arch and version supplied as arguments.
+// The baseurl template and the arch are taken from archOverride, if set there.
 func (b *DnfRepoBundleConfig) getBaseURL(
 	repoName string,
 	arch string,
 	versionOverride string,
+	archOverride *DnfRepoArchOverride,
 	errPrefix util.ErrPrefix) (
 	string, error) {
 
 	var repoArch string
-	if b.UseBaseArch {
+	if archOverride.Arch != "" {
+		repoArch = archOverride.Arch
+	} else if b.UseBaseArch {
 		repoArch = baseArch(arch)
 	} else {
 		repoArch = arch
 	}
 
-	var version string
-	if versionOverride == "" {
-		version = "default"
-	} else {
-		version = versionOverride
-	}
-
-	// See if version specified in manifest is a label
-	// If it is, translate it into actual version number before deriving URL.
-	translatedVersion, isVersionLabel := b.VersionLabels[version]
-	if !isVersionLabel {
-		translatedVersion = version
+	baseURLFormat := b.BaseURLFormat
+	baseURLFormatTemplate := b.baseURLFormatTemplate
+	if archOverride.BaseURLFormat != "" {
+		baseURLFormat = archOverride.BaseURLFormat
+		var parseErr error
+		baseURLFormatTemplate, parseErr = template.New("archOverride").Parse(baseURLFormat)
+		if parseErr != nil {
+			return "", fmt.Errorf("%sError parsing baseurl %s in arch-overrides for %s",
+				errPrefix, baseURLFormat, arch)
+		}
 	}
 
 	urlData := DnfRepoURLData{
 		RepoName: repoName,
 		Host:     viper.GetString("DnfRepoHost"),
 		Arch:     repoArch,
-		Version:  translatedVersion,
+		Version:  b.ResolveVersion(versionOverride),
 	}
 
 	var urlBuf bytes.Buffer
-	if err := b.baseURLFormatTemplate.Execute(&urlBuf, urlData); err != nil {
+	if err := baseURLFormatTemplate.Execute(&urlBuf, urlData); err != nil {
 		return "", fmt.Errorf("%sError executing template %s with data %v",
-			errPrefix, b.BaseURLFormat, urlData)
+			errPrefix, baseURLFormat, urlData)
 	}
 	return urlBuf.String(), nil
 }
@@ -126,6 +282,8 @@ func (b *DnfRepoBundleConfig) getBaseURL(
 // This returns a DnfRepoParams object for the repo named repoName
 // in the bundle aggregating the dnf config file and version/params override
 // coming in from the manifest.
+// The arch-overrides for the target arch are applied last, in the order
+// bundle, repo, manifest.
 func (b *DnfRepoBundleConfig) GetDnfRepoParams(
 	repoName string,
 	arch string,
@@ -140,10 +298,23 @@ func (b *DnfRepoBundleConfig) GetDnfRepoParams(
 			errPrefix, repoName)
 	}
 
+	repoOverride, hasOverrides := repoOverrides[repoName]
+	if err := validateArchOverrides(repoOverride.ArchOverrides); err != nil {
+		return nil, fmt.Errorf("%sBad override for repo %s: %s",
+			errPrefix, repoName, err)
+	}
+	if err := validateDnfRepoOptions(repoOverride.Options); err != nil {
+		return nil, fmt.Errorf("%sBad override for repo %s: %s",
+			errPrefix, repoName, err)
+	}
+	archOverride := mergeArchOverrides(arch,
+		b.ArchOverrides, repoConfig.ArchOverrides, repoOverride.ArchOverrides)
+
 	baseURL, err := b.getBaseURL(
 		repoName,
 		arch,
 		versionOverride,
+		&archOverride,
 		errPrefix)
 	if err != nil {
 		return nil, err
@@ -152,7 +323,6 @@ func (b *DnfRepoBundleConfig) GetDnfRepoParams(
 	enabled := repoConfig.Enabled
 	priority := b.Priority
 	var exclude string
-	repoOverride, hasOverrides := repoOverrides[repoName]
 	if hasOverrides {
 		enabled = repoOverride.Enabled
 		exclude = repoOverride.Exclude
@@ -166,6 +336,13 @@ func (b *DnfRepoBundleConfig) GetDnfRepoParams(
 		}
 	}
 
+	if archOverride.Enabled != nil {
+		enabled = *archOverride.Enabled
+	}
+	if archOverride.Exclude != "" {
+		exclude = archOverride.Exclude
+	}
+
 	return &DnfRepoParams{
 		Name:     repoName,
 		BaseURL:  baseURL,
@@ -174,9 +351,101 @@ func (b *DnfRepoBundleConfig) GetDnfRepoParams(
 		GpgCheck: b.GpgCheck,
 		GpgKey:   b.GpgKey,
 		Priority: priority,
+		Options:  mergeDnfRepoOptions(b.Options, repoConfig.Options, repoOverride.Options),
 	}, nil
 }
 
+// inheritFrom copies all fields of parent, which aren't
+// explicitly set in the bundle, over to the bundle.
+// setFields are the yaml keys explicitly set in the bundle.
+func (b *DnfRepoBundleConfig) inheritFrom(parent *DnfRepoBundleConfig,
+	setFields map[string]interface{}) {
+	isSet := func(field string) bool {
+		_, set := setFields[field]
+		return set
+	}
+
+	if !isSet("baseurl") {
+		b.BaseURLFormat = parent.BaseURLFormat
+	}
+	if !isSet("gpgcheck") {
+		b.GpgCheck = parent.GpgCheck
+	}
+	if !isSet("gpgkey") {
+		b.GpgKey = parent.GpgKey
+	}
+	if !isSet("use-base-arch") {
+		b.UseBaseArch = parent.UseBaseArch
+	}
+	if !isSet("version-labels") {
+		b.VersionLabels = parent.VersionLabels
+	}
+	if !isSet("priority") {
+		b.Priority = parent.Priority
+	}
+	if !isSet("arch-overrides") {
+		b.ArchOverrides = parent.ArchOverrides
+	}
+	if !isSet("options") {
+		b.Options = parent.Options
+	}
+	if !isSet("modules") {
+		b.Modules = parent.Modules
+	}
+	if !isSet("distro") {
+		b.Distro = parent.Distro
+	}
+
+	mergedRepoConfig := make(map[string]*DnfRepoConfig)
+	for repoName, repoConfig := range parent.DnfRepoConfig {
+		mergedRepoConfig[repoName] = repoConfig
+	}
+	for repoName, repoConfig := range b.DnfRepoConfig {
+		mergedRepoConfig[repoName] = repoConfig
+	}
+	b.DnfRepoConfig = mergedRepoConfig
+}
+
+// resolveExtends resolves the extends field of all bundles in the config
+// rawBundles holds the yaml keys explicitly set in each bundle.
+func (c *DnfConfig) resolveExtends(rawBundles map[string]map[string]interface{}) error {
+	resolved := make(map[string]bool)
+	var resolve func(bundleName string, chain []string) error
+	resolve = func(bundleName string, chain []string) error {
+		if resolved[bundleName] {
+			return nil
+		}
+		for _, visited := range chain {
+			if visited == bundleName {
+				return fmt.Errorf("dnfconfig.LoadDnfConfig: Cycle in repo-bundle extends: %s",
+					strings.Join(append(chain, bundleName), " -> "))
+			}
+		}
+
+		bundle := c.DnfRepoBundleConfig[bundleName]
+		if bundle.Extends != "" {
+			parent, found := c.DnfRepoBundleConfig[bundle.Extends]
+			if !found {
+				return fmt.Errorf("dnfconfig.LoadDnfConfig: dnf repo-bundle %s extends unknown repo-bundle %s",
+					bundleName, bundle.Extends)
+			}
+			if err := resolve(bundle.Extends, append(chain, bundleName)); err != nil {
+				return err
+			}
+			bundle.inheritFrom(parent, rawBundles[bundleName])
+		}
+		resolved[bundleName] = true
+		return nil
+	}
+
+	for bundleName := range c.DnfRepoBundleConfig {
+		if err := resolve(bundleName, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Enabled computes enabled flags for a particular repo
 // LoadDnfConfig loads the dnf repo config file, parses it and
 // returns the data structure
@@ -203,7 +472,35 @@ func LoadDnfConfig(cfgPath string) (*DnfConfig, error) {
 			cfgPath, parseErr)
 	}
 
+	// Parse again to find the fields explicitly set in each bundle,
+	// so that an explicit zero value isn't mistaken for an unset field.
+	var rawConfig struct {
+		RepoBundle map[string]map[string]interface{} `yaml:"repo-bundle"`
+	}
+	if parseErr := yaml.Unmarshal(yamlContents, &rawConfig); parseErr != nil {
+		return nil, fmt.Errorf("dnfconfig.LoadDnfConfig: Error parsing yaml file %s: %s",
+			cfgPath, parseErr)
+	}
+	if err := config.resolveExtends(rawConfig.RepoBundle); err != nil {
+		return nil, err
+	}
+
+	for distroName, distroConfig := range config.Distro {
+		if distroConfig == nil || distroConfig.Releasever == "" ||
+			distroConfig.PlatformID == "" || len(distroConfig.ChrootSetupPkgs) == 0 {
+			return nil, fmt.Errorf("dnfconfig.LoadDnfConfig: distro %s should specify "+
+				"releasever, platform-id and chroot-setup-pkgs", distroName)
+		}
+	}
+
 	for bundleName, repoBundleConfig := range config.DnfRepoBundleConfig {
+		if repoBundleConfig.Distro != "" {
+			if _, found := config.Distro[repoBundleConfig.Distro]; !found {
+				return nil, fmt.Errorf("dnfconfig.LoadDnfConfig: Unknown distro %s for dnf repo-bundle %s",
+					repoBundleConfig.Distro, bundleName)
+			}
+		}
+
 		templateName := "dnfRepoBundle_" + bundleName
 		t, parseErr := template.New(templateName).Parse(repoBundleConfig.BaseURLFormat)
 		if parseErr != nil {
@@ -213,15 +510,41 @@ func LoadDnfConfig(cfgPath string) (*DnfConfig, error) {
 		}
 		repoBundleConfig.baseURLFormatTemplate = t
 
+		if err := validateArchOverrides(repoBundleConfig.ArchOverrides); err != nil {
+			return nil, fmt.Errorf("dnfconfig.LoadDnfConfig: Bad dnf repo-bundle %s: %s",
+				bundleName, err)
+		}
+		if err := validateDnfRepoOptions(repoBundleConfig.Options); err != nil {
+			return nil, fmt.Errorf("dnfconfig.LoadDnfConfig: Bad dnf repo-bundle %s: %s",
+				bundleName, err)
+		}
+		if err := repoBundleConfig.Modules.validate(); err != nil {
+			return nil, fmt.Errorf("dnfconfig.LoadDnfConfig: Bad modules in dnf repo-bundle %s: %s",
+				bundleName, err)
+		}
+		for repoName, repoConfig := range repoBundleConfig.DnfRepoConfig {
+			if err := validateArchOverrides(repoConfig.ArchOverrides); err != nil {
+				return nil, fmt.Errorf("dnfconfig.LoadDnfConfig: Bad repo %s in dnf repo-bundle %s: %s",
+					repoName, bundleName, err)
+			}
+			if err := validateDnfRepoOptions(repoConfig.Options); err != nil {
+				return nil, fmt.Errorf("dnfconfig.LoadDnfConfig: Bad repo %s in dnf repo-bundle %s: %s",
+					repoName, bundleName, err)
+			}
+		}
+
 		priority := repoBundleConfig.Priority
 		if priority == 0 {
-			return nil, fmt.Errorf("dnfconfig.LoadDnfConfig: Priority not set. Please provide a valid priority > 1")
+			return nil, fmt.Errorf("dnfconfig.LoadDnfConfig: Priority not set for dnf repo-bundle %s. "+
+				"Please provide a valid priority > 1", bundleName)
 		} else if priority == 1 {
 			return nil, fmt.Errorf(
-				"dnfconfig.LoadDnfConfig: Priority 1 is reserved for local deps, please provide a priority > 1")
+				"dnfconfig.LoadDnfConfig: Priority 1 is reserved for local deps, "+
+					"please provide a priority > 1 for dnf repo-bundle %s", bundleName)
 		} else if priority < 0 {
 			return nil, fmt.Errorf(
-				"dnfconfig.LoadDnfConfig: Wrong priority %d provided Please provide a valid priority > 1", priority)
+				"dnfconfig.LoadDnfConfig: Wrong priority %d provided for dnf repo-bundle %s. "+
+					"Please provide a valid priority > 1", priority, bundleName)
 		}
 	}
 	return &config, nil
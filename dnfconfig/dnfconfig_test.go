@@ -123,3 +123,122 @@ func TestRepoConfig(t *testing.T) {
 
 	t.Log("BaseURL template test passed")
 }
+
+func TestRepoBundleExtends(t *testing.T) {
+	viper.Set("DnfRepoHost", "http://foo.org")
+	defer viper.Reset()
+
+	t.Log("Testing Load with extends")
+	dnfConfig, loadErr := LoadDnfConfig("testData/extends-dnfconfig.yaml")
+	require.NoError(t, loadErr)
+	require.NotNil(t, dnfConfig)
+
+	child := dnfConfig.DnfRepoBundleConfig["child"]
+	require.NotNil(t, child)
+	require.True(t, child.GpgCheck)
+	require.Equal(t, "file:///keyfile", child.GpgKey)
+	require.False(t, child.UseBaseArch)
+	require.Equal(t, 3, child.Priority)
+	require.Equal(t, map[string]string{"default": "1"}, child.VersionLabels)
+	require.Equal(t, map[string]*DnfRepoConfig{
+		"repo1": {Enabled: true},
+		"repo2": {Enabled: true, Exclude: "foo"},
+		"repo3": {Enabled: true},
+	}, child.DnfRepoConfig)
+
+	repoParams, err := child.GetDnfRepoParams("repo2", "i686", "", nil,
+		util.ErrPrefix("TestRepoBundleExtends-child"))
+	require.NoError(t, err)
+	require.Equal(t, "http://foo.org/base-1/repo2/i686/", repoParams.BaseURL)
+
+	grandchild := dnfConfig.DnfRepoBundleConfig["grandchild"]
+	require.NotNil(t, grandchild)
+	require.Equal(t, 3, grandchild.Priority)
+	require.Len(t, grandchild.DnfRepoConfig, 3)
+	repoParams, err = grandchild.GetDnfRepoParams("repo3", "x86_64", "", nil,
+		util.ErrPrefix("TestRepoBundleExtends-grandchild"))
+	require.NoError(t, err)
+	require.Equal(t, "http://foo.org/grandchild-default/repo3/x86_64/", repoParams.BaseURL)
+
+	base := dnfConfig.DnfRepoBundleConfig["base"]
+	require.Len(t, base.DnfRepoConfig, 2)
+	require.False(t, base.DnfRepoConfig["repo2"].Enabled)
+
+	t.Log("Testing bad extends")
+	for cfgFile, expectedErr := range map[string]string{
+		"testData/extends-cycle-dnfconfig.yaml":        "Cycle in repo-bundle extends",
+		"testData/extends-unknown-dnfconfig.yaml":      "extends unknown repo-bundle bundle0",
+		"testData/extends-bad-priority-dnfconfig.yaml": "Priority 1 is reserved for local deps",
+	} {
+		_, loadErr := LoadDnfConfig(cfgFile)
+		require.ErrorContains(t, loadErr, expectedErr, cfgFile)
+	}
+}
+
+func TestArchOverrides(t *testing.T) {
+	viper.Set("DnfRepoHost", "http://foo.org")
+	defer viper.Reset()
+
+	dnfConfig, loadErr := LoadDnfConfig("testData/arch-overrides-dnfconfig.yaml")
+	require.NoError(t, loadErr)
+	bundleConfig := dnfConfig.DnfRepoBundleConfig["bundle1"]
+	require.NotNil(t, bundleConfig)
+
+	enabled := true
+	manifestOverrides := map[string]DnfRepoParamsOverride{
+		"repo2": DnfRepoParamsOverride{
+			Enabled: true,
+			ArchOverrides: map[string]*DnfRepoArchOverride{
+				"aarch64": {Enabled: &enabled, Arch: "arm64"},
+			},
+		},
+	}
+
+	type expectedParams struct {
+		baseURL string
+		enabled bool
+		exclude string
+	}
+	testCases := []struct {
+		repo      string
+		arch      string
+		overrides map[string]DnfRepoParamsOverride
+		expected  expectedParams
+	}{
+		{"repo1", "x86_64", nil, expectedParams{"http://foo.org/bundle1-1/repo1/x86_64/", true, ""}},
+		{"repo1", "i686", nil, expectedParams{"http://foo.org/bundle1-1/repo1/x86_64/", true, "foo"}},
+		{"repo2", "i686", nil, expectedParams{"http://foo.org/repo2-vault-1/x86_64/", true, "foo-devel"}},
+		{"repo2", "aarch64", nil, expectedParams{"http://foo.org/bundle1-1/repo2/aarch64/", false, ""}},
+		{"repo2", "aarch64", manifestOverrides, expectedParams{"http://foo.org/bundle1-1/repo2/arm64/", true, ""}},
+	}
+	for _, tc := range testCases {
+		t.Logf("Testing case: repo %s arch %s with overrides %v", tc.repo, tc.arch, tc.overrides != nil)
+		repoParams, err := bundleConfig.GetDnfRepoParams(tc.repo, tc.arch, "", tc.overrides,
+			util.ErrPrefix("TestArchOverrides"))
+		require.NoError(t, err)
+		require.Equal(t, tc.expected.baseURL, repoParams.BaseURL)
+		require.Equal(t, tc.expected.enabled, repoParams.Enabled)
+		require.Equal(t, tc.expected.exclude, repoParams.Exclude)
+	}
+
+	t.Log("Testing bad arch-overrides")
+	_, err := bundleConfig.GetDnfRepoParams("repo1", "x86_64", "",
+		map[string]DnfRepoParamsOverride{
+			"repo1": DnfRepoParamsOverride{
+				ArchOverrides: map[string]*DnfRepoArchOverride{"ppc64le": {}},
+			},
+		},
+		util.ErrPrefix("TestArchOverrides"))
+	require.ErrorContains(t, err, "invalid arch ppc64le")
+
+	_, loadErr = LoadDnfConfig("testData/bad-arch-overrides-dnfconfig.yaml")
+	require.ErrorContains(t, loadErr, "invalid arch ppc64le")
+}
+
+func TestDistro(t *testing.T) {
+	_, loadErr := LoadDnfConfig("testData/bad-distro-dnfconfig.yaml")
+	require.ErrorContains(t, loadErr, "Unknown distro el8 for dnf repo-bundle bundle1")
+
+	_, loadErr = LoadDnfConfig("testData/incomplete-distro-dnfconfig.yaml")
+	require.ErrorContains(t, loadErr, "distro el9 should specify releasever, platform-id and chroot-setup-pkgs")
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package dnfconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+type dnfRepoOptionKind int
+
+const (
+	dnfRepoOptionString dnfRepoOptionKind = iota
+	dnfRepoOptionBool
+	dnfRepoOptionInt
+)
+
+// dnfRepoOptions are the extra dnf.conf repo options which can be
+// specified in the options map of repos, repo-bundles and overrides.
+var dnfRepoOptions = map[string]dnfRepoOptionKind{
+	"includepkgs":         dnfRepoOptionString,
+	"module_hotfixes":     dnfRepoOptionBool,
+	"cost":                dnfRepoOptionInt,
+	"skip_if_unavailable": dnfRepoOptionBool,
+	"sslverify":           dnfRepoOptionBool,
+}
+
+var dnfBoolValues = []string{"0", "1", "true", "false", "yes", "no", "on", "off"}
+
+// moduleSpecRegex matches module specs of the form name[:stream][/profile]
+var moduleSpecRegex = regexp.MustCompile(`^[A-Za-z0-9_.+-]+(:[A-Za-z0-9_.+-]+)?(/[A-Za-z0-9_.+-]+)?$`)
+
+// disableModuleSpecRegex matches module specs of the form name[:stream],
+// profiles are only installed by enabled modules.
+var disableModuleSpecRegex = regexp.MustCompile(`^[A-Za-z0-9_.+-]+(:[A-Za-z0-9_.+-]+)?$`)
+
+// validateDnfRepoOptions checks that only supported options with
+// sane values are specified.
+func validateDnfRepoOptions(options map[string]string) error {
+	for option, value := range options {
+		kind, supported := dnfRepoOptions[option]
+		if !supported {
+			supportedOptions := maps.Keys(dnfRepoOptions)
+			slices.Sort(supportedOptions)
+			return fmt.Errorf("unsupported dnf repo option %s, must be one of %s",
+				option, strings.Join(supportedOptions, ", "))
+		}
+		switch kind {
+		case dnfRepoOptionBool:
+			if !slices.Contains(dnfBoolValues, strings.ToLower(value)) {
+				return fmt.Errorf("dnf repo option %s expects a boolean, got '%s'",
+					option, value)
+			}
+		case dnfRepoOptionInt:
+			if _, err := strconv.Atoi(value); err != nil {
+				return fmt.Errorf("dnf repo option %s expects an integer, got '%s'",
+					option, value)
+			}
+		case dnfRepoOptionString:
+			if value == "" || strings.Contains(value, "\n") {
+				return fmt.Errorf("dnf repo option %s expects a single line non-empty value",
+					option)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeDnfRepoOptions combines the options with the later ones
+// taking precedence. nil is returned if there are no options.
+func mergeDnfRepoOptions(options ...map[string]string) map[string]string {
+	var merged map[string]string
+	for _, opts := range options {
+		for option, value := range opts {
+			if merged == nil {
+				merged = make(map[string]string)
+			}
+			merged[option] = value
+		}
+	}
+	return merged
+}
+
+// DnfModules spec
+// Module streams to enable/disable in the mock chroot.
+// Enable: module specs of the form name[:stream][/profile]
+// Disable: module specs of the form name[:stream]
+type DnfModules struct {
+	Enable  []string `yaml:"enable"`
+	Disable []string `yaml:"disable"`
+}
+
+// DnfModuleCmd is a module setup command run by mock
+// Action is one of enable/disable.
+type DnfModuleCmd struct {
+	Action string
+	Module string
+}
+
+func moduleName(moduleSpec string) string {
+	return strings.FieldsFunc(moduleSpec, func(r rune) bool {
+		return r == ':' || r == '/'
+	})[0]
+}
+
+func (m *DnfModules) validate() error {
+	enabledModules := make(map[string]bool)
+	for _, moduleSpec := range m.Enable {
+		if !moduleSpecRegex.MatchString(moduleSpec) {
+			return fmt.Errorf("bad module spec '%s' in enable, expected name[:stream][/profile]",
+				moduleSpec)
+		}
+		enabledModules[moduleName(moduleSpec)] = true
+	}
+	for _, moduleSpec := range m.Disable {
+		if !disableModuleSpecRegex.MatchString(moduleSpec) {
+			return fmt.Errorf("bad module spec '%s' in disable, expected name[:stream]",
+				moduleSpec)
+		}
+		if enabledModules[moduleName(moduleSpec)] {
+			return fmt.Errorf("module %s is both enabled and disabled",
+				moduleName(moduleSpec))
+		}
+	}
+	return nil
+}
+
+// MergeDnfModules combines the module specs, with the later ones taking
+// precedence for the same module name, into module setup commands for mock.
+// Disable commands are ordered before enable commands.
+func MergeDnfModules(modules ...DnfModules) ([]DnfModuleCmd, error) {
+	var names []string
+	cmds := make(map[string]DnfModuleCmd)
+	addCmd := func(action string, moduleSpec string) {
+		name := moduleName(moduleSpec)
+		if _, found := cmds[name]; !found {
+			names = append(names, name)
+		}
+		cmds[name] = DnfModuleCmd{Action: action, Module: moduleSpec}
+	}
+
+	for _, m := range modules {
+		if err := m.validate(); err != nil {
+			return nil, err
+		}
+		for _, moduleSpec := range m.Disable {
+			addCmd("disable", moduleSpec)
+		}
+		for _, moduleSpec := range m.Enable {
+			addCmd("enable", moduleSpec)
+		}
+	}
+
+	var moduleCmds []DnfModuleCmd
+	for _, action := range []string{"disable", "enable"} {
+		for _, name := range names {
+			if cmds[name].Action == action {
+				moduleCmds = append(moduleCmds, cmds[name])
+			}
+		}
+	}
+	return moduleCmds, nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package dnfconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDnfRepoOptions(t *testing.T) {
+	require.NoError(t, validateDnfRepoOptions(map[string]string{
+		"includepkgs":         "foo* bar",
+		"module_hotfixes":     "true",
+		"cost":                "500",
+		"skip_if_unavailable": "0",
+		"sslverify":           "False",
+	}))
+
+	for option, value := range map[string]string{
+		"gpgcheck":        "1",
+		"module_hotfixes": "maybe",
+		"cost":            "high",
+		"includepkgs":     "",
+	} {
+		t.Logf("Testing bad option %s=%s", option, value)
+		require.Error(t, validateDnfRepoOptions(map[string]string{option: value}))
+	}
+
+	_, loadErr := LoadDnfConfig("testData/bad-options-dnfconfig.yaml")
+	require.ErrorContains(t, loadErr, "unsupported dnf repo option gpgcheck")
+}
+
+func TestMergeDnfModules(t *testing.T) {
+	moduleCmds, err := MergeDnfModules(
+		DnfModules{
+			Enable:  []string{"postgresql:13"},
+			Disable: []string{"nodejs", "perl"},
+		},
+		DnfModules{
+			Enable: []string{"nodejs:18/common"},
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []DnfModuleCmd{
+		{Action: "disable", Module: "perl"},
+		{Action: "enable", Module: "nodejs:18/common"},
+		{Action: "enable", Module: "postgresql:13"},
+	}, moduleCmds)
+
+	moduleCmds, err = MergeDnfModules()
+	require.NoError(t, err)
+	require.Empty(t, moduleCmds)
+
+	t.Log("Testing bad modules")
+	_, err = MergeDnfModules(DnfModules{Enable: []string{"nodejs 18"}})
+	require.ErrorContains(t, err, "bad module spec")
+	_, err = MergeDnfModules(DnfModules{Disable: []string{"nodejs:18/common"}})
+	require.ErrorContains(t, err, "bad module spec 'nodejs:18/common' in disable, expected name[:stream]")
+	_, err = MergeDnfModules(DnfModules{
+		Enable:  []string{"nodejs:18"},
+		Disable: []string{"nodejs"},
+	})
+	require.ErrorContains(t, err, "module nodejs is both enabled and disabled")
+}
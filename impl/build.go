@@ -4,15 +4,117 @@
 package impl
 
 import (
+	"fmt"
 	"log"
 
+	"github.com/spf13/viper"
+
 	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/manifest"
 )
 
+// BuildExtraCmdlineArgs is a bundle of extra args for impl.Build
+// Variant selects a build variant from the package build matrix,
+// AllVariants selects all of them.
+type BuildExtraCmdlineArgs struct {
+	Variant     string
+	AllVariants bool
+}
+
+// withVariantDirs runs fn with WorkingDir and DestDir scoped to subdirs for the
+// variant and SrpmsDir pointing to the variant's SRPMs.
+func withVariantDirs(variant string, fn func() error) error {
+	workingDir := viper.GetString("WorkingDir")
+	destDir := viper.GetString("DestDir")
+	srpmsDir := viper.GetString("SrpmsDir")
+	defer func() {
+		viper.Set("WorkingDir", workingDir)
+		viper.Set("DestDir", destDir)
+		viper.Set("SrpmsDir", srpmsDir)
+	}()
+
+	viper.Set("WorkingDir", getVariantDir(workingDir, variant))
+	viper.Set("DestDir", getVariantDir(destDir, variant))
+	viper.Set("SrpmsDir", getAllSrpmsDestDir())
+	return fn()
+}
+
+// buildVariants builds the selected variants of the packages in the manifest.
+// Each variant's results are in <DestDir>/variants/<variant>.
+func buildVariants(repo string, pkg string, arch string,
+	extraCreateSrpmArgs CreateSrpmExtraCmdlineArgs,
+	extraMockArgs MockExtraCmdlineArgs,
+	extraArgs BuildExtraCmdlineArgs,
+	executor executor.Executor) error {
+	repoManifest, loadManifestErr := manifest.LoadManifest(repo)
+	if loadManifestErr != nil {
+		return loadManifestErr
+	}
+
+	found := false
+	for _, pkgSpec := range repoManifest.Package {
+		if pkg != "" && pkg != pkgSpec.Name {
+			continue
+		}
+		found = true
+
+		var variants []string
+		if extraArgs.AllVariants {
+			for _, variant := range pkgSpec.Matrix {
+				variants = append(variants, variant.Name)
+			}
+			if variants == nil {
+				return fmt.Errorf("impl.Build: No matrix specified for package %s",
+					pkgSpec.Name)
+			}
+		} else {
+			if pkgSpec.GetVariant(extraArgs.Variant) == nil {
+				return fmt.Errorf("impl.Build: Variant %s not found in matrix of package %s",
+					extraArgs.Variant, pkgSpec.Name)
+			}
+			variants = []string{extraArgs.Variant}
+		}
+
+		for _, variant := range variants {
+			log.Printf("impl.Build: Building variant %s of package %s", variant, pkgSpec.Name)
+			variantMockArgs := extraMockArgs
+			variantMockArgs.Variant = variant
+			if err := withVariantDirs(variant, func() error {
+				if err := CreateSrpm(repo, pkgSpec.Name, extraCreateSrpmArgs, executor); err != nil {
+					return err
+				}
+				return Mock(repo, pkgSpec.Name, arch, variantMockArgs, executor)
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("impl.Build: Invalid package name %s specified", pkg)
+	}
+	return nil
+}
+
 // Build calls CreateSrpm and Mock in sequence
+// If variants are selected, this is done for each variant of each package.
 func Build(repo string, pkg string, arch string,
 	extraCreateSrpmArgs CreateSrpmExtraCmdlineArgs,
-	extraMockArgs MockExtraCmdlineArgs, executor executor.Executor) error {
+	extraMockArgs MockExtraCmdlineArgs,
+	extraArgs BuildExtraCmdlineArgs,
+	executor executor.Executor) error {
+	if extraArgs.Variant != "" || extraArgs.AllVariants {
+		if extraArgs.Variant != "" && extraArgs.AllVariants {
+			return fmt.Errorf("impl.Build: Specify either a variant or all variants, not both")
+		}
+		if err := buildVariants(repo, pkg, arch, extraCreateSrpmArgs, extraMockArgs,
+			extraArgs, executor); err != nil {
+			return err
+		}
+		log.Println("SUCCESS: Build")
+		return nil
+	}
+
 	if err := CreateSrpm(repo, pkg, extraCreateSrpmArgs, executor); err != nil {
 		return err
 	}
@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/rpmfile"
+)
+
+// writeDummyRpm writes an RPM with just the headers to dir,
+// an SRPM if arch is "src".
+func writeDummyRpm(t *testing.T, dir string, name string, arch string,
+	setup func(hdr *rpmfile.Header)) string {
+	hdr := rpmfile.NewHeader()
+	hdr.SetString(rpmfile.TagName, name)
+	hdr.SetString(rpmfile.TagVersion, "1.0")
+	hdr.SetString(rpmfile.TagRelease, "1")
+	if arch == "src" {
+		hdr.SetString(rpmfile.TagArch, "x86_64")
+	} else {
+		hdr.SetString(rpmfile.TagArch, arch)
+		hdr.SetString(rpmfile.TagSourceRPM, name+"-1.0-1.src.rpm")
+	}
+	if setup != nil {
+		setup(hdr)
+	}
+	rpmPkg := rpmfile.NewPackage(hdr)
+
+	rpmPath := filepath.Join(dir, fmt.Sprintf("%s-1.0-1.%s.rpm", name, arch))
+	file, err := os.Create(rpmPath)
+	require.NoError(t, err)
+	defer file.Close()
+	require.NoError(t, rpmPkg.Write(file, nil))
+	return rpmPath
+}
+
+func TestCheckRpmFile(t *testing.T) {
+	dir := t.TempDir()
+	srpmPath := writeDummyRpm(t, dir, "foo", "src", nil)
+	rpmPath := writeDummyRpm(t, dir, "foo", "x86_64", nil)
+	noarchRpmPath := writeDummyRpm(t, dir, "foo-doc", "noarch", nil)
+
+	rpmPkg, err := checkRpmFile(srpmPath, "src", "")
+	require.NoError(t, err)
+	require.Equal(t, "foo-1.0-1.src", rpmPkg.NEVRA())
+	_, err = checkRpmFile(rpmPath, "x86_64", "")
+	require.NoError(t, err)
+
+	t.Log("Testing arch mismatch")
+	_, err = checkRpmFile(rpmPath, "src", "")
+	require.ErrorContains(t, err, "is a x86_64 rpm, expected src")
+	_, err = checkRpmFile(srpmPath, "x86_64", "")
+	require.ErrorContains(t, err, "is a src rpm, expected x86_64")
+
+	t.Log("Testing checkRpmFiles")
+	require.NoError(t, checkRpmFiles(filepath.Join(dir, "*.noarch.rpm"), "noarch", ""))
+	require.NoError(t, checkRpmFiles(filepath.Join(dir, "*.i686.rpm"), "i686", ""))
+	require.NoError(t, os.Rename(noarchRpmPath, filepath.Join(dir, "foo-doc-1.0-1.x86_64.rpm")))
+	require.Error(t, checkRpmFiles(filepath.Join(dir, "*.x86_64.rpm"), "x86_64", ""))
+
+	t.Log("Testing invalid rpm")
+	badRpmPath := filepath.Join(dir, "bad-1.0-1.x86_64.rpm")
+	require.NoError(t, os.WriteFile(badRpmPath, []byte("<html>Not Found</html>"), 0644))
+	_, err = checkRpmFile(badRpmPath, "x86_64", "")
+	require.ErrorContains(t, err, "Invalid rpm")
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// hashHexLen is the length of the hex encoded hash for each algorithm
+var hashHexLen = map[string]int{
+	"sha256":  64,
+	"sha512":  128,
+	"blake2b": 128,
+}
+
+// GNU coreutils format: <hash>  <file> or <hash> *<file>
+var gnuChecksumLineRegex = regexp.MustCompile(`^([0-9a-fA-F]+) [ *](.+)$`)
+
+// BSD(--tag) format: <ALGO> (<file>) = <hash>
+var bsdChecksumLineRegex = regexp.MustCompile(`^([A-Za-z0-9-]+) ?\((.+)\) ?= ?([0-9a-fA-F]+)$`)
+
+// bsdChecksumTags maps the algorithm tags in BSD format checksum files
+// to the corresponding algorithm.
+var bsdChecksumTags = map[string]string{
+	"sha256":      "sha256",
+	"sha512":      "sha512",
+	"blake2b":     "blake2b",
+	"blake2b-512": "blake2b",
+}
+
+// checkHash checks that the hash of the file generated with
+// the algorithm matches the expected hash.
+func checkHash(srcFilePath string, algorithm string, expectedHash string,
+	errPrefix util.ErrPrefix) error {
+	generatedHash, err := util.GenerateHash(srcFilePath, algorithm)
+	if err != nil {
+		return fmt.Errorf("%s %s generation failed with '%s' for file: %s",
+			errPrefix, strings.ToUpper(algorithm), err, srcFilePath)
+	}
+	if generatedHash != strings.ToLower(expectedHash) {
+		return fmt.Errorf("%s bad %s: '%s' expected: '%s' for file: %s",
+			errPrefix, strings.ToUpper(algorithm), generatedHash, expectedHash, srcFilePath)
+	}
+	return nil
+}
+
+// checkManifestHashes checks the source file against the hashes
+// specified for the upstream source in the manifest.
+func checkManifestHashes(upstreamSrcFromManifest manifest.UpstreamSrc, srcFilePath string,
+	errPrefix util.ErrPrefix) error {
+	hashesInManifest := map[string]string{
+		"sha256":  upstreamSrcFromManifest.Sha256,
+		"sha512":  upstreamSrcFromManifest.Sha512,
+		"blake2b": upstreamSrcFromManifest.Blake2b,
+	}
+	for _, algorithm := range util.HashAlgorithms {
+		if hashInManifest := hashesInManifest[algorithm]; hashInManifest != "" {
+			if err := checkHash(srcFilePath, algorithm, hashInManifest, errPrefix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checksumFileAlgorithm returns the algorithm specified for the checksum file,
+// deriving it from the checksum file name(Eg: SHA512SUMS, B2SUMS) if unspecified.
+func checksumFileAlgorithm(checksumFile manifest.ChecksumFile) (string, error) {
+	if checksumFile.Algorithm != "" {
+		return checksumFile.Algorithm, nil
+	}
+	fileName := strings.ToLower(path.Base(checksumFile.FullURL))
+	switch {
+	case strings.Contains(fileName, "sha256"):
+		return "sha256", nil
+	case strings.Contains(fileName, "sha512"):
+		return "sha512", nil
+	case strings.Contains(fileName, "b2sum"), strings.Contains(fileName, "blake2"):
+		return "blake2b", nil
+	}
+	return "", fmt.Errorf("unable to derive the algorithm from checksum file name %s, "+
+		"specify algorithm", path.Base(checksumFile.FullURL))
+}
+
+// parseChecksumFile parses the checksum file in GNU or BSD format
+// and returns the hashes with the algorithm, indexed by file name.
+// Lines which aren't checksum entries, like comments, are ignored,
+// as are BSD format entries for other algorithms.
+func parseChecksumFile(checksumFilePath string, algorithm string) (map[string]string, error) {
+	file, err := os.Open(checksumFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hashes := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		var fileName, hash string
+		if match := bsdChecksumLineRegex.FindStringSubmatch(line); match != nil {
+			if bsdChecksumTags[strings.ToLower(match[1])] != algorithm {
+				continue
+			}
+			fileName, hash = match[2], match[3]
+		} else if match := gnuChecksumLineRegex.FindStringSubmatch(line); match != nil {
+			hash, fileName = match[1], match[2]
+		} else {
+			continue
+		}
+		if len(hash) != hashHexLen[algorithm] {
+			return nil, fmt.Errorf("bad %s hash length for %s", algorithm, fileName)
+		}
+
+		fileName = path.Base(fileName)
+		hash = strings.ToLower(hash)
+		if prevHash, found := hashes[fileName]; found && prevHash != hash {
+			return nil, fmt.Errorf("conflicting hashes for %s", fileName)
+		}
+		hashes[fileName] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// checkChecksumFile downloads the checksum file specified for the
+// upstream source, verifies its detached signature and checks the
+// hash of the source file against the one listed in the checksum file.
+func (bldr *srpmBuilder) checkChecksumFile(checksumFile manifest.ChecksumFile,
+	srcFilePath string, downloadDir string) error {
+	repo := bldr.repo
+	pkg := bldr.pkgSpec.Name
+	isPkgSubdirInRepo := bldr.pkgSpec.Subdir
+
+	algorithm, algoErr := checksumFileAlgorithm(checksumFile)
+	if algoErr != nil {
+		return fmt.Errorf("%s%s", bldr.errPrefix, algoErr)
+	}
+
+	bldr.log("downloading checksum file %s", checksumFile.FullURL)
+	checksumFileName, downloadErr := download(
+		checksumFile.FullURL,
+		downloadDir,
+		repo, pkg, isPkgSubdirInRepo,
+		bldr.errPrefix)
+	if downloadErr != nil {
+		return downloadErr
+	}
+	checksumFilePath := filepath.Join(downloadDir, checksumFileName)
+
+	if !checksumFile.Signature.SkipCheck {
+		detachedSig := checksumFile.Signature.DetachedSignature
+		sigFileName, downloadErr := download(
+			detachedSig.FullURL,
+			downloadDir,
+			repo, pkg, isPkgSubdirInRepo,
+			bldr.errPrefix)
+		if downloadErr != nil {
+			return downloadErr
+		}
+		signer, err := verifyTarballSignature(
+			checksumFilePath,
+			filepath.Join(downloadDir, sigFileName),
+			filepath.Join(getDetachedSigDir(), detachedSig.PubKey),
+			bldr.errPrefix)
+		if err != nil {
+			return err
+		}
+		bldr.log("checksum file %s verified by %s", checksumFileName, signerDescription(signer))
+	}
+
+	hashes, parseErr := parseChecksumFile(checksumFilePath, algorithm)
+	if parseErr != nil {
+		return fmt.Errorf("%sError parsing checksum file %s: %s",
+			bldr.errPrefix, checksumFileName, parseErr)
+	}
+	srcFileName := filepath.Base(srcFilePath)
+	expectedHash, found := hashes[srcFileName]
+	if !found {
+		return fmt.Errorf("%s%s hash for %s not found in checksum file %s",
+			bldr.errPrefix, strings.ToUpper(algorithm), srcFileName, checksumFileName)
+	}
+	return checkHash(srcFilePath, algorithm, expectedHash, bldr.errPrefix)
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/manifest"
+)
+
+const testTarball = "testData/upstream-hash-check-good/mrtparse-2.0.1.tar.gz"
+
+func TestCheckHash(t *testing.T) {
+	goodHashes := map[string]string{
+		"sha256":  "ac4456cda847db6a757f0c27cb09ad9b3ee3b59e0d7ca224266aafa326163aec",
+		"sha512":  "c292601a6dbfdd794a5e51d7b2ff7830a215c4f92ac91b3608c9c3f0fc3c75a9e134302d57ab546c8c190c909d56712764f34b015e3eeef7bd002cc133084744",
+		"blake2b": "69AEAAD5E9890E6AC107BDEDBFE2E44B4B55B0B2D1ECE45D11E82EFA01898956B34099285FD4D286930C57EC465115541667B04975875B823B5A191864B82455",
+	}
+	for algorithm, hash := range goodHashes {
+		require.NoError(t, checkHash(testTarball, algorithm, hash, ""))
+	}
+	require.ErrorContains(t, checkHash(testTarball, "sha512", goodHashes["blake2b"], ""),
+		"bad SHA512")
+	require.ErrorContains(t, checkHash(testTarball, "md5", goodHashes["sha256"], ""),
+		"Unsupported hash algorithm md5")
+}
+
+func TestChecksumFileAlgorithm(t *testing.T) {
+	expectedAlgorithms := map[string]string{
+		"https://foo.org/SHA256SUMS":         "sha256",
+		"https://foo.org/foo-1.0.sha512sum":  "sha512",
+		"https://foo.org/B2SUMS":             "blake2b",
+		"https://foo.org/foo-1.0-blake2.txt": "blake2b",
+	}
+	for url, expectedAlgorithm := range expectedAlgorithms {
+		algorithm, err := checksumFileAlgorithm(manifest.ChecksumFile{FullURL: url})
+		require.NoError(t, err)
+		require.Equal(t, expectedAlgorithm, algorithm)
+	}
+
+	algorithm, err := checksumFileAlgorithm(manifest.ChecksumFile{
+		FullURL:   "https://foo.org/CHECKSUM",
+		Algorithm: "sha256",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "sha256", algorithm)
+
+	_, err = checksumFileAlgorithm(manifest.ChecksumFile{FullURL: "https://foo.org/CHECKSUM"})
+	require.ErrorContains(t, err, "specify algorithm")
+}
+
+func TestParseChecksumFile(t *testing.T) {
+	t.Log("Testing GNU format")
+	hashes, err := parseChecksumFile("testData/checksum-file/SHA512SUMS", "sha512")
+	require.NoError(t, err)
+	require.Len(t, hashes, 2)
+	require.NoError(t, checkHash(testTarball, "sha512", hashes["mrtparse-2.0.1.tar.gz"], ""))
+	require.Contains(t, hashes, "mrtparse-2.0.0.tar.gz")
+
+	_, err = parseChecksumFile("testData/checksum-file/SHA512SUMS", "sha256")
+	require.ErrorContains(t, err, "bad sha256 hash length")
+
+	t.Log("Testing BSD format")
+	for _, algorithm := range []string{"sha256", "blake2b"} {
+		hashes, err = parseChecksumFile("testData/checksum-file/CHECKSUM", algorithm)
+		require.NoError(t, err)
+		require.Len(t, hashes, 1)
+		require.NoError(t, checkHash(testTarball, algorithm, hashes["mrtparse-2.0.1.tar.gz"], ""))
+	}
+	hashes, err = parseChecksumFile("testData/checksum-file/CHECKSUM", "sha512")
+	require.NoError(t, err)
+	require.Empty(t, hashes)
+}
@@ -17,6 +17,7 @@ import (
 
 	"code.arista.io/eos/tools/eext/executor"
 	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/rpmfile"
 	"code.arista.io/eos/tools/eext/util"
 )
 
@@ -51,6 +52,10 @@ func getDownloadDir(pkg string) string {
 	return filepath.Join(getPkgWorkingDir(pkg), "upstream")
 }
 
+func getDiffDir(pkg string) string {
+	return filepath.Join(getPkgWorkingDir(pkg), "diff")
+}
+
 func getRpmbuildDir(pkg string) string {
 	return filepath.Join(getPkgWorkingDir(pkg), "rpmbuild")
 }
@@ -85,8 +90,18 @@ func getMockResultsDir(pkg string, arch string) string {
 
 // This doesn't return an absolute path
 // It gives the mock chroot name under mock working directory(not WorkingDir)
-func getMockChrootDirName(pkg string, arch string) string {
-	return fmt.Sprintf("%s-%s", pkg, arch)
+// variant is empty for packages built without a build matrix.
+func getMockChrootDirName(pkg string, variant string, arch string) string {
+	if variant == "" {
+		return fmt.Sprintf("%s-%s", pkg, arch)
+	}
+	return fmt.Sprintf("%s-%s-%s", pkg, variant, arch)
+}
+
+// getVariantDir returns the subdir of dir used for the variant's
+// working files/results, so that the results of variants never collide.
+func getVariantDir(dir string, variant string) string {
+	return filepath.Join(dir, "variants", variant)
 }
 
 func getAllSrpmsDestDir() string {
@@ -97,6 +112,12 @@ func getPkgSrpmsDestDir(pkg string) string {
 	return filepath.Join(getAllSrpmsDestDir(), pkg)
 }
 
+// getPkgReportsDir returns the dir for the reports
+// recording the provenance of the package's build.
+func getPkgReportsDir(pkg string) string {
+	return filepath.Join(viper.GetString("DestDir"), "reports", pkg)
+}
+
 func getPkgSrpmsDir(errPrefix util.ErrPrefix, pkg string) (string, error) {
 	srpmsDirs := viper.GetString("SrpmsDir")
 	for _, srpmsDir := range strings.Split(srpmsDirs, ":") {
@@ -116,6 +137,10 @@ func getPkgRpmsDestDir(pkg string, arch string) string {
 	return filepath.Join(getAllRpmsDestDir(), arch, pkg)
 }
 
+func getPkgMultilibDestDir(pkg string, nativeArch string) string {
+	return filepath.Join(viper.GetString("DestDir"), "multilib", nativeArch, pkg)
+}
+
 func getRpmKeysDir() string {
 	pkiPath := viper.GetString("PkiPath")
 	return filepath.Join(pkiPath, "rpmkeys")
@@ -234,31 +259,59 @@ func filterAndCopy(pathMap map[string]string, executor executor.Executor, errPre
 	return nil
 }
 
-var gpgKeysLoaded = false
-
-func loadGpgKeys(executor executor.Executor) error {
-	if gpgKeysLoaded {
-		return nil
+// checkRpmFile checks that the file at rpmPath is a valid RPM of the
+// expected arch, "src" for SRPMs, and returns its headers.
+func checkRpmFile(rpmPath string, arch string, errPrefix util.ErrPrefix) (
+	*rpmfile.Package, error) {
+	rpmPkg, err := rpmfile.Open(rpmPath)
+	if err != nil {
+		return nil, fmt.Errorf("%sInvalid rpm: %s", errPrefix, err)
 	}
+	if rpmArch := rpmPkg.Arch(); rpmArch != arch {
+		return nil, fmt.Errorf("%s%s is a %s rpm, expected %s",
+			errPrefix, rpmPath, rpmArch, arch)
+	}
+	return rpmPkg, nil
+}
 
-	// Remove any stale keys from rpmdb
-	if _, err := executor.Output("rpm", "-e", "gpg-pubkey", "--allmatches"); err != nil {
-		// Ignore error if no keys installed.
-		if !strings.Contains(err.Error(), "package gpg-pubkey is not installed") {
-			return fmt.Errorf("Error '%s' clearing gpg-pubkey from rpmdb", err)
+// checkRpmFiles runs checkRpmFile on all the RPMs matching rpmGlob
+func checkRpmFiles(rpmGlob string, arch string, errPrefix util.ErrPrefix) error {
+	rpmPaths, _ := filepath.Glob(rpmGlob)
+	for _, rpmPath := range rpmPaths {
+		if _, err := checkRpmFile(rpmPath, arch, errPrefix); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// newRpmKeyring creates a private rpmdb in a temp dir and imports
+// the keys in the rpmkeys dir to it. Signatures are verified against
+// this rpmdb so that the host rpmdb is never modified, and concurrent
+// eext runs don't interfere with each other.
+// The caller is responsible for removing the returned dir.
+func newRpmKeyring(errPrefix util.ErrPrefix) (string, error) {
+	dbPath, mkdtErr := os.MkdirTemp("", "eext-rpmdb")
+	if mkdtErr != nil {
+		return "", fmt.Errorf("%sError '%s' creating temp dir for rpmdb",
+			errPrefix, mkdtErr)
+	}
+
+	if _, err := util.CheckOutput("rpm", "--dbpath", dbPath, "--initdb"); err != nil {
+		os.RemoveAll(dbPath)
+		return "", fmt.Errorf("%sError '%s' creating rpmdb in %s",
+			errPrefix, err, dbPath)
+	}
 
-	// Now add the keys
 	pubKeys, _ := filepath.Glob(filepath.Join(getRpmKeysDir(), "*.pem"))
 	for _, pubKey := range pubKeys {
-		if err := executor.Exec("rpm", "--import", pubKey); err != nil {
-			return fmt.Errorf("Error '%s' importing %s to rpmdb", err, pubKey)
+		if _, err := util.CheckOutput("rpm", "--dbpath", dbPath, "--import", pubKey); err != nil {
+			os.RemoveAll(dbPath)
+			return "", fmt.Errorf("%sError '%s' importing %s to rpmdb",
+				errPrefix, err, pubKey)
 		}
 	}
-
-	gpgKeysLoaded = true
-	return nil
+	return dbPath, nil
 }
 
 func combineSrcEnv(
@@ -313,12 +366,13 @@ func getEextSignature(errPrefix util.ErrPrefix) (
 	return combineSrcEnv(false, ",", errPrefix)
 }
 
-func setup(executor executor.Executor) error {
-	if err := CheckEnv(); err != nil {
-		return err
-	}
-	if err := loadGpgKeys(executor); err != nil {
-		return err
-	}
-	return nil
+func setup() error {
+	return CheckEnv()
+}
+
+// isDryRun checks if the commands are only printed, the steps done in-process
+// have to check it to skip their side effects.
+func isDryRun(ex executor.Executor) bool {
+	_, dryRun := ex.(*executor.DryRunExecutor)
+	return dryRun
 }
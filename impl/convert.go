@@ -0,0 +1,246 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/srcconfig"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// Sources of the upstream SRPM which aren't copied to the repo on convert,
+// the modified SRPM picks them up from the upstream SRPM.
+var convertArchiveSuffixes = []string{
+	".tar.gz", ".tar.xz", ".tar.bz2", ".tar.zst", ".tar.lz", ".tar.lzma",
+	".tgz", ".tbz2", ".txz", ".tar", ".zip", ".gem", ".crate", ".jar",
+}
+
+// Group 2 is the package type, with optional quotes, group 3 the optional comment
+var manifestTypeLineRegex = regexp.MustCompile(
+	`^(\s*(?:-\s+)?type:\s*)(["']?[a-z-]+["']?)(\s*(?:#.*)?)$`)
+
+func isArchiveSource(filename string) bool {
+	for _, suffix := range convertArchiveSuffixes {
+		if strings.HasSuffix(filename, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// setManifestPackageType changes the type of the package pkg in the
+// manifest contents to newType. It edits the type line of the package in
+// place, so that comments and formatting of the manifest are preserved.
+func setManifestPackageType(yamlContents []byte, pkg string, newType string) (
+	[]byte, error) {
+	var orig manifest.Manifest
+	if err := yaml.UnmarshalStrict(yamlContents, &orig); err != nil {
+		return nil, err
+	}
+
+	// Try each type line, the right one changes only the type of pkg.
+	lines := strings.Split(string(yamlContents), "\n")
+	for i, line := range lines {
+		match := manifestTypeLineRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		newLines := append([]string{}, lines...)
+		newLines[i] = match[1] + newType + match[3]
+		newContents := []byte(strings.Join(newLines, "\n"))
+
+		var updated manifest.Manifest
+		if yaml.UnmarshalStrict(newContents, &updated) != nil ||
+			len(updated.Package) != len(orig.Package) {
+			continue
+		}
+		changedOnlyPkg := true
+		for j := range updated.Package {
+			expectedType := orig.Package[j].Type
+			if orig.Package[j].Name == pkg {
+				expectedType = newType
+			}
+			if updated.Package[j].Type != expectedType {
+				changedOnlyPkg = false
+				break
+			}
+		}
+		if changedOnlyPkg {
+			return newContents, nil
+		}
+	}
+	return nil, fmt.Errorf("couldn't find the type of package %s", pkg)
+}
+
+// copyConvertedFilesToRepo copies the spec file and the sources other than
+// the tarballs of the rpmbuild tree to the repo.
+func (bldr *srpmBuilder) copyConvertedFilesToRepo() error {
+	pkg := bldr.pkgSpec.Name
+	rpmbuildDir := getRpmbuildDir(pkg)
+	specDir := getPkgSpecDirInRepo(bldr.repo, pkg, bldr.pkgSpec.Subdir)
+	sourcesDir := getPkgSourcesDirInRepo(bldr.repo, pkg, bldr.pkgSpec.Subdir)
+	for _, dir := range []string{specDir, sourcesDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("%sError '%s' creating %s", bldr.errPrefix, err, dir)
+		}
+	}
+
+	specFiles, _ := filepath.Glob(filepath.Join(rpmbuildDir, "SPECS", "*.spec"))
+	for _, specFile := range specFiles {
+		if err := util.CopyToDestDir(specFile, specDir, bldr.errPrefix); err != nil {
+			return err
+		}
+	}
+
+	sources, _ := filepath.Glob(filepath.Join(rpmbuildDir, "SOURCES", "*"))
+	for _, source := range sources {
+		if isArchiveSource(filepath.Base(source)) {
+			continue
+		}
+		if info, err := os.Stat(source); err != nil || info.IsDir() {
+			continue
+		}
+		if err := util.CopyToDestDir(source, sourcesDir, bldr.errPrefix); err != nil {
+			return err
+		}
+		bldr.log("copied %s to %s", filepath.Base(source), sourcesDir)
+	}
+	return nil
+}
+
+// printConvertPlan prints the files which would be copied to the repo, from
+// the file list of the upstream SRPM, as it isn't extracted on dry runs.
+func (bldr *srpmBuilder) printConvertPlan() error {
+	pkg := bldr.pkgSpec.Name
+	specFile, sources, err := listSrpmFiles(bldr.upstreamSrpmDownloadPath(), bldr.errPrefix)
+	if err != nil {
+		return err
+	}
+	specDir := getPkgSpecDirInRepo(bldr.repo, pkg, bldr.pkgSpec.Subdir)
+	sourcesDir := getPkgSourcesDirInRepo(bldr.repo, pkg, bldr.pkgSpec.Subdir)
+	fmt.Printf("Would copy %s to %s, with the Release patched with %%{eext_release}\n",
+		specFile, specDir)
+	for _, source := range sources {
+		if !isArchiveSource(source) {
+			fmt.Printf("Would copy %s to %s\n", source, sourcesDir)
+		}
+	}
+	return nil
+}
+
+// Convert converts the unmodified-srpm package pkg to an srpm package
+// which can be modified. The upstream SRPM is unpacked, its spec file,
+// with the Release patched with %{eext_release}, is put in the spec dir
+// of the package, and its patches and other non-tarball sources in the
+// sources dir. The type of the package is updated in the manifest.
+// On dry runs, the changes to the repo are only printed.
+func Convert(repo string, pkg string, to string, executor executor.Executor) error {
+	if err := setup(); err != nil {
+		return err
+	}
+	errPrefix := util.ErrPrefix(fmt.Sprintf("impl.Convert(%s): ", pkg))
+
+	if to != "srpm" {
+		return fmt.Errorf("%sConverting to %s isn't supported, only srpm is",
+			errPrefix, to)
+	}
+
+	repoManifest, err := manifest.LoadManifest(repo)
+	if err != nil {
+		return err
+	}
+	var pkgSpec *manifest.Package
+	for i := range repoManifest.Package {
+		if repoManifest.Package[i].Name == pkg {
+			pkgSpec = &repoManifest.Package[i]
+		}
+	}
+	if pkgSpec == nil {
+		return fmt.Errorf("%sInvalid package name %s specified", errPrefix, pkg)
+	}
+	if pkgSpec.Type != "unmodified-srpm" {
+		return fmt.Errorf("%sPackage is of type %s, only unmodified-srpm packages can be converted",
+			errPrefix, pkgSpec.Type)
+	}
+
+	specDir := getPkgSpecDirInRepo(repo, pkg, pkgSpec.Subdir)
+	if specFiles, _ := filepath.Glob(filepath.Join(specDir, "*.spec")); len(specFiles) != 0 {
+		return fmt.Errorf("%sFound spec files %s in the repo, remove them to convert",
+			errPrefix, strings.Join(specFiles, ","))
+	}
+
+	srcConfig, err := srcconfig.LoadSrcConfig()
+	if err != nil {
+		return err
+	}
+
+	bldr := srpmBuilder{
+		pkgSpec:       pkgSpec,
+		repo:          repo,
+		errPrefixBase: util.ErrPrefix(fmt.Sprintf("convert(%s)", pkg)),
+		srcConfig:     srcConfig,
+		executor:      executor,
+	}
+	type stage struct {
+		name string
+		run  func() error
+	}
+	stages := []stage{
+		{"clean", bldr.clean},
+		{"fetchUpstream", bldr.fetchUpstream},
+		{"verifyUpstream", bldr.verifyUpstream},
+	}
+	dryRun := isDryRun(executor)
+	if dryRun {
+		stages = append(stages, stage{"printConvertPlan", bldr.printConvertPlan})
+	} else {
+		stages = append(stages,
+			stage{"setupRpmbuildTree", bldr.setupRpmbuildTreeSrpm},
+			stage{"patchSpecFile", bldr.patchUpstreamSpecFileWithEextRelease},
+			stage{"copyToRepo", bldr.copyConvertedFilesToRepo})
+	}
+	for _, stage := range stages {
+		bldr.setupStageErrPrefix(stage.name)
+		if err := stage.run(); err != nil {
+			return err
+		}
+	}
+
+	manifestPath := filepath.Join(util.GetRepoDir(repo), "eext.yaml")
+	yamlContents, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("%sError '%s' reading %s", errPrefix, err, manifestPath)
+	}
+	newContents, err := setManifestPackageType(yamlContents, pkg, "srpm")
+	if err != nil {
+		return fmt.Errorf("%sError updating %s: %s", errPrefix, manifestPath, err)
+	}
+	if dryRun {
+		fmt.Printf("Would change the type of %s in %s to %s\n", pkg, manifestPath, to)
+		return nil
+	}
+	if err := os.WriteFile(manifestPath, newContents, 0644); err != nil {
+		return fmt.Errorf("%sError '%s' writing %s", errPrefix, err, manifestPath)
+	}
+
+	// The manifest is loaded back to make sure eext accepts it
+	if _, err := manifest.LoadManifest(repo); err != nil {
+		return err
+	}
+	if err := checkRepo(repo, pkg, pkgSpec.Subdir, false, errPrefix); err != nil {
+		return err
+	}
+	log.Printf("SUCCESS: convert %s to %s", pkg, to)
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/manifest"
+)
+
+const convertTestManifest = `---
+package:
+  - name: foo
+    upstream-sources:
+      - full-url: "file:///foo-1.0-1.src.rpm"
+        signature:
+          skip-check: true
+    type: unmodified-srpm  # imported as is
+    build:
+      repo-bundle:
+        - name: el9
+  - name: bar
+    upstream-sources:
+      - full-url: "file:///bar-1.0-1.src.rpm"
+        signature:
+          skip-check: true
+    type: unmodified-srpm
+    build:
+      repo-bundle:
+        - name: el9
+`
+
+func TestSetManifestPackageType(t *testing.T) {
+	newContents, err := setManifestPackageType([]byte(convertTestManifest), "bar", "srpm")
+	require.NoError(t, err)
+	require.Contains(t, string(newContents), "    type: unmodified-srpm  # imported as is\n")
+	require.Contains(t, string(newContents), "    type: srpm\n")
+
+	newContents, err = setManifestPackageType([]byte(convertTestManifest), "foo", "srpm")
+	require.NoError(t, err)
+	require.Contains(t, string(newContents), "    type: srpm  # imported as is\n")
+	require.Contains(t, string(newContents), "    type: unmodified-srpm\n")
+
+	_, err = setManifestPackageType([]byte(convertTestManifest), "baz", "srpm")
+	require.ErrorContains(t, err, "couldn't find the type of package baz")
+}
+
+func TestConvert(t *testing.T) {
+	srcDir := setupInitTest(t)
+	repoDir := filepath.Join(srcDir, "foo")
+	require.NoError(t, os.MkdirAll(repoDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "eext.yaml"),
+		[]byte(convertTestManifest), 0644))
+	srpmPath := writeTestSrpm(t, repoDir, "gzip", []testSrpmFile{
+		{name: "foo.spec", mode: 0100644, contents: "Name: foo\nRelease: 1%{?dist}\n", specFile: true},
+		{name: "foo-1.0.tar.gz", mode: 0100644, contents: "tarball"},
+		{name: "fix-build.patch", mode: 0100644, contents: "patch"},
+		{name: "foo.service", mode: 0100644, contents: "service"},
+	}, nil)
+	require.NoError(t, os.Rename(srpmPath, filepath.Join(repoDir, "foo-1.0-1.src.rpm")))
+
+	err := Convert("foo", "foo", "tarball", &executor.OsExecutor{})
+	require.ErrorContains(t, err, "only srpm is")
+	err = Convert("foo", "baz", "srpm", &executor.OsExecutor{})
+	require.ErrorContains(t, err, "Invalid package name baz")
+
+	t.Log("Testing dry run doesn't change the repo")
+	require.NoError(t, Convert("foo", "foo", "srpm", &executor.DryRunExecutor{}))
+	require.Equal(t, convertTestManifest, readFileString(t, filepath.Join(repoDir, "eext.yaml")))
+	require.NoDirExists(t, filepath.Join(repoDir, "spec"))
+	require.NoDirExists(t, filepath.Join(repoDir, "sources"))
+
+	require.NoError(t, Convert("foo", "foo", "srpm", &executor.OsExecutor{}))
+	repoManifest, err := manifest.LoadManifest("foo")
+	require.NoError(t, err)
+	require.Equal(t, "srpm", repoManifest.Package[0].Type)
+	require.Equal(t, "unmodified-srpm", repoManifest.Package[1].Type)
+	require.Contains(t, readFileString(t, filepath.Join(repoDir, "eext.yaml")),
+		"    type: srpm  # imported as is\n")
+
+	require.Equal(t,
+		"Name: foo\nRelease: 1%{?dist}.%{?eext_release:%{eext_release}}%{!?eext_release:eng}\n",
+		readFileString(t, filepath.Join(repoDir, "spec/foo.spec")))
+	specFiles, _ := filepath.Glob(filepath.Join(repoDir, "spec", "*"))
+	require.Len(t, specFiles, 1)
+	require.Equal(t, "patch", readFileString(t, filepath.Join(repoDir, "sources/fix-build.patch")))
+	require.Equal(t, "service", readFileString(t, filepath.Join(repoDir, "sources/foo.service")))
+	require.NoFileExists(t, filepath.Join(repoDir, "sources/foo-1.0.tar.gz"))
+
+	err = Convert("foo", "foo", "srpm", &executor.OsExecutor{})
+	require.ErrorContains(t, err, "only unmodified-srpm packages can be converted")
+}
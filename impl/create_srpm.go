@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/exp/slices"
 
@@ -20,11 +21,18 @@ import (
 )
 
 type upstreamSrcSpec struct {
-	sourceFile   string
-	sigFile      string
-	pubKeyPath   string
-	skipSigCheck bool
-	gitSpec      gitSpec
+	srcURL             string
+	sourceFile         string
+	sigFile            string
+	pubKeyPath         string
+	allowedSignersPath string
+	skipSigCheck       bool
+	gitSpec            gitSpec
+	submodules         []gitSubmodule
+	// sha256 of the tarball generated from a git source
+	sha256     string
+	verifiedBy string
+	signedAt   time.Time
 }
 
 type srpmBuilder struct {
@@ -34,6 +42,7 @@ type srpmBuilder struct {
 	errPrefixBase util.ErrPrefix
 	errPrefix     util.ErrPrefix
 	upstreamSrc   []upstreamSrcSpec
+	vendored      []vendoredSrcSpec
 	srcConfig     *srcconfig.SrcConfig
 	executor      executor.Executor
 }
@@ -61,13 +70,14 @@ func (bldr *srpmBuilder) setupStageErrPrefix(stage string) {
 func (bldr *srpmBuilder) clean() error {
 	pkg := bldr.pkgSpec.Name
 	pkgSrpmsDestDir := getPkgSrpmsDestDir(pkg)
+	pkgReportsDir := getPkgReportsDir(pkg)
 	pkgWorkingDir := getPkgWorkingDir(pkg)
-	if err := util.RemoveDirs([]string{pkgSrpmsDestDir, pkgWorkingDir},
+	if err := util.RemoveDirs([]string{pkgSrpmsDestDir, pkgReportsDir, pkgWorkingDir},
 		bldr.errPrefix); err != nil {
 		return err
 	}
 
-	for _, dir := range []string{pkgSrpmsDestDir} {
+	for _, dir := range []string{pkgSrpmsDestDir, pkgReportsDir} {
 		if err := os.RemoveAll(dir); err != nil {
 			return fmt.Errorf("%sError '%s' removing %s",
 				bldr.errPrefix, err, dir)
@@ -84,16 +94,18 @@ func (bldr *srpmBuilder) fetchUpstream() error {
 	// First fetch upstream source
 	downloadDir := getDownloadDir(bldr.pkgSpec.Name)
 
-	if err := util.MaybeCreateDirWithParents(downloadDir, bldr.executor, bldr.errPrefix); err != nil {
-		return err
+	// Upstream sources are downloaded and verified on dry runs too,
+	// so the dir is created in-process.
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		return fmt.Errorf("%sError '%s' creating %s", bldr.errPrefix, err, downloadDir)
 	}
 
-	for _, upstreamSrcFromManifest := range bldr.pkgSpec.UpstreamSrc {
+	for i, upstreamSrcFromManifest := range bldr.pkgSpec.UpstreamSrc {
 		upstreamSrcType := bldr.pkgSpec.Type
 		var upstreamSrc *upstreamSrcSpec
 		var err error
 		if upstreamSrcType == "git-upstream" {
-			upstreamSrc, err = bldr.getUpstreamSourceForGit(upstreamSrcFromManifest, downloadDir)
+			upstreamSrc, err = bldr.getUpstreamSourceForGit(upstreamSrcFromManifest, i, downloadDir)
 		} else {
 			upstreamSrc, err = bldr.getUpstreamSourceForOthers(upstreamSrcFromManifest, downloadDir)
 		}
@@ -141,17 +153,25 @@ func (bldr *srpmBuilder) verifyUpstreamSrpm() error {
 			bldr.errPrefix)
 	}
 
-	// Check if downloaded file is a valid rpm
-	err := bldr.executor.Exec("rpm", "-q", "-p", upstreamSrpmFilePath)
+	// Check if downloaded file is a valid SRPM
+	upstreamSrpm, err := checkRpmFile(upstreamSrpmFilePath, "src", bldr.errPrefix)
 	if err != nil {
-		return fmt.Errorf("%sDownloaded SRPM file is not a valid rpm: %s",
-			bldr.errPrefix, err)
+		return err
 	}
+	bldr.log("downloaded SRPM %s", upstreamSrpm.NEVRA())
 
 	if !upstreamSrc.skipSigCheck {
 		if err := verifyRpmSignature(upstreamSrpmFilePath, bldr.errPrefix); err != nil {
 			return err
 		}
+		verifiedBy, err := verifySrpmSigningKey(upstreamSrpmFilePath,
+			bldr.pkgSpec.Name, bldr.pkgSpec.UpstreamSrc[0].SourceBundle.Name,
+			bldr.errPrefix)
+		if err != nil {
+			return err
+		}
+		bldr.upstreamSrc[0].verifiedBy = verifiedBy
+		bldr.log("SRPM signed by %s", verifiedBy)
 	}
 
 	return nil
@@ -165,38 +185,48 @@ func (bldr *srpmBuilder) verifyUpstream() error {
 			return err
 		}
 	} else if bldr.pkgSpec.Type == "git-upstream" {
-		for _, upstreamSrc := range bldr.upstreamSrc {
-			if !upstreamSrc.skipSigCheck {
-				err := verifyGitSignature(upstreamSrc.pubKeyPath, upstreamSrc.gitSpec, bldr.errPrefix)
+		for i := range bldr.upstreamSrc {
+			upstreamSrc := &bldr.upstreamSrc[i]
+			if upstreamSrc.skipSigCheck {
+				continue
+			}
+			if upstreamSrc.allowedSignersPath != "" {
+				verifiedBy, err := verifyGitSSHSignature(upstreamSrc.allowedSignersPath,
+					upstreamSrc.gitSpec, bldr.errPrefix)
 				if err != nil {
 					return err
 				}
+				upstreamSrc.verifiedBy = verifiedBy
+			} else {
+				signer, err := verifyGitSignature(upstreamSrc.pubKeyPath,
+					upstreamSrc.gitSpec, bldr.errPrefix)
+				if err != nil {
+					return err
+				}
+				upstreamSrc.verifiedBy = signerDescription(signer)
+				upstreamSrc.signedAt = signer.Created
 			}
+			bldr.log("revision %s verified by %s", upstreamSrc.gitSpec.Revision,
+				upstreamSrc.verifiedBy)
 		}
 	} else {
 		downloadDir := getDownloadDir(bldr.pkgSpec.Name)
 
-		for _, upstreamSrc := range bldr.upstreamSrc {
+		for i := range bldr.upstreamSrc {
+			upstreamSrc := &bldr.upstreamSrc[i]
 			if !upstreamSrc.skipSigCheck {
 				upstreamSourceFilePath := filepath.Join(downloadDir, upstreamSrc.sourceFile)
 				upstreamSigFilePath := filepath.Join(downloadDir, upstreamSrc.sigFile)
-				uncompressedTarballPath, err := matchTarballSignCmprsn(
-					upstreamSourceFilePath, upstreamSigFilePath,
-					downloadDir, bldr.errPrefix)
-				if err != nil {
-					return err
-				}
-				if uncompressedTarballPath != "" {
-					upstreamSourceFilePath = uncompressedTarballPath
-					defer os.Remove(uncompressedTarballPath)
-				}
-				if err := verifyTarballSignature(
+				signer, err := verifyTarballSignature(
 					upstreamSourceFilePath,
 					upstreamSigFilePath,
 					upstreamSrc.pubKeyPath,
-					bldr.errPrefix); err != nil {
+					bldr.errPrefix)
+				if err != nil {
 					return err
 				}
+				upstreamSrc.verifiedBy = signerDescription(signer)
+				upstreamSrc.signedAt = signer.Created
 			}
 		}
 	}
@@ -204,34 +234,17 @@ func (bldr *srpmBuilder) verifyUpstream() error {
 	return nil
 }
 
-// installs upstream SRPM to create a rpmbuild tree
-// also checks upstream SRPM signature
+// extracts upstream SRPM to create a rpmbuild tree
+// The SRPM is extracted in-process rather than through the executor,
+// so on dry runs this only prints what would be extracted.
 func (bldr *srpmBuilder) setupRpmbuildTreeSrpm() error {
 	upstreamSrpmFilePath := bldr.upstreamSrpmDownloadPath()
 	rpmbuildDir := getRpmbuildDir(bldr.pkgSpec.Name)
-	rpmInstArgs := []string{
-		"--define", fmt.Sprintf("_topdir %s", rpmbuildDir),
-		"-i", upstreamSrpmFilePath,
-	}
-
-	if err := bldr.executor.Exec("rpm", rpmInstArgs...); err != nil {
-		return fmt.Errorf("%sError '%s' installing upstream SRPM file %s",
-			bldr.errPrefix, err, upstreamSrpmFilePath)
-	}
-
-	// Make sure expected dirs have been created
-	pathsToCheck := []string{
-		filepath.Join(rpmbuildDir, "SPECS"),
-	}
-	for _, path := range pathsToCheck {
-		_, pathErr := os.Stat(path)
-		if pathErr != nil {
-			return fmt.Errorf("%s%s not found after installing upstream SRPM : %s",
-				bldr.errPrefix, path, pathErr)
-		}
+	if isDryRun(bldr.executor) {
+		fmt.Printf("Would extract %s to %s\n", upstreamSrpmFilePath, rpmbuildDir)
+		return nil
 	}
-
-	return nil
+	return extractSrpm(upstreamSrpmFilePath, rpmbuildDir, bldr.errPrefix)
 }
 
 // Create rpmbuild tree similar to an SRPM install
@@ -262,6 +275,16 @@ func (bldr *srpmBuilder) setupRpmbuildTreeNonSrpm() error {
 				return err
 			}
 		}
+		// Checked in vendored tarballs are copied with the sources in the repo
+		for _, vendored := range bldr.vendored {
+			if vendored.checkedIn {
+				continue
+			}
+			if err := util.CopyToDestDir(filepath.Join(downloadDir, vendored.file),
+				rpmbuildSourcesDir, bldr.errPrefix); err != nil {
+				return err
+			}
+		}
 	}
 
 	rpmbuildSpecsDir := filepath.Join(rpmbuildDir, "SPECS")
@@ -467,6 +490,10 @@ func (bldr *srpmBuilder) copyBuiltSrpmToDestDir() error {
 			srpmsRpmbuildDir)
 	}
 
+	if _, err := checkRpmFile(filenames[0], "src", bldr.errPrefix); err != nil {
+		return err
+	}
+
 	pkgSrpmsDestDir := getPkgSrpmsDestDir(pkg)
 	if err := util.CopyToDestDir(
 		filenames[0], pkgSrpmsDestDir,
@@ -488,6 +515,10 @@ func (bldr *srpmBuilder) copyResultsToDestDir() error {
 	if err := bldr.copyBuiltSrpmToDestDir(); err != nil {
 		return err
 	}
+
+	if err := bldr.writeReport(); err != nil {
+		return err
+	}
 	bldr.log("successful")
 	return nil
 
@@ -495,7 +526,8 @@ func (bldr *srpmBuilder) copyResultsToDestDir() error {
 
 // This is the entry point to srpmBuilder
 // It runs the stages to build the modified SRPM
-// Stages: Clean, FetchUpstream, PrepAndPatchUpstream, Build, CopyResultsToDestDir
+// Stages: Clean, FetchUpstream, VerifyUpstream, VendorUpstream, SetupRpmbuildTree,
+// Build, CopyResultsToDestDir
 func (bldr *srpmBuilder) runStages() error {
 	// Clean stale directories for this package in preparation
 	// for fresh rebuild.
@@ -514,6 +546,13 @@ func (bldr *srpmBuilder) runStages() error {
 		if err := bldr.verifyUpstream(); err != nil {
 			return err
 		}
+
+		if bldr.pkgSpec.Vendor != nil {
+			bldr.setupStageErrPrefix("vendorUpstream")
+			if err := bldr.vendorUpstream(); err != nil {
+				return err
+			}
+		}
 	}
 
 	bldr.setupStageErrPrefix("setupRpmbuildTree")
@@ -547,7 +586,7 @@ func (bldr *srpmBuilder) runStages() error {
 // If a pkg is specified, only it is built. Otherwise, we walk over all the packages
 // in the manifest and build them.
 func CreateSrpm(repo string, pkg string, extraArgs CreateSrpmExtraCmdlineArgs, executor executor.Executor) error {
-	if err := setup(executor); err != nil {
+	if err := setup(); err != nil {
 		return err
 	}
 
@@ -6,10 +6,14 @@ package impl
 import (
 	"fmt"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/sigverify"
 	"code.arista.io/eos/tools/eext/srcconfig"
 	"code.arista.io/eos/tools/eext/util"
 )
@@ -41,108 +45,93 @@ func getRpmNameFromSpecFile(repo, pkg string, isPkgSubdirInRepo bool) (string, e
 	return rpmName, nil
 }
 
-// We aren't using 'git clone' since it is slow for large repos.
-// This method is faster and only pulls necessary changes.
+// cloneGitRepo returns a git worktree at the revision of the repo at srcURL.
+// The repo is cached in a bare mirror under WorkingDir, shared by all
+// packages and eext runs, so only revisions missing from the mirror are
+// fetched.
 func cloneGitRepo(pkg, srcURL, revision, targetDir string) (string, error) {
-	// Cloning the git repo to a temporary directory
-	cloneDir, err := os.MkdirTemp(targetDir, pkg)
-	if err != nil {
-		return "", fmt.Errorf("error while creating tempDir for %s, %s", pkg, err)
-	}
-	// Init the dir as a git repo
-	err = util.RunSystemCmdInDir(cloneDir, "git", "init")
-	if err != nil {
-		return "", fmt.Errorf("git init at %s failed: %s", cloneDir, err)
-	}
-	// Add the srcURL as the origin for the repo
-	err = util.RunSystemCmdInDir(cloneDir, "git", "remote", "add", "origin", srcURL)
-	if err != nil {
-		return "", fmt.Errorf("adding %s as git remote failed: %s", srcURL, err)
-	}
-	// Fetch repo tags, for user inputs revision as TAG
-	err = util.RunSystemCmdInDir(cloneDir, "git", "fetch", "--tags")
-	if err != nil {
-		return "", fmt.Errorf("fetching tags failed for %s: %s", pkg, err)
-	}
-	// Fetch the code changes for the provided revision
-	err = util.RunSystemCmdInDir(cloneDir, "git", "fetch", "origin", revision)
-	if err != nil {
-		return "", fmt.Errorf("fetching revision %s failed for %s: %s", revision, pkg, err)
-	}
-	// Pull code to repo at provided revision
-	err = util.RunSystemCmdInDir(cloneDir, "git", "reset", "--hard", "FETCH_HEAD")
-	if err != nil {
-		return "", fmt.Errorf("fetching HEAD at %s failed: %s", revision, err)
-	}
+	return checkoutFromGitMirror(pkg, srcURL, revision, targetDir)
+}
 
-	return cloneDir, nil
+// gitArchiveSpec describes the tarball generated from a git source
+type gitArchiveSpec struct {
+	// File name of the tarball
+	Name string
+	// Directory the files are put under in the tarball
+	Prefix string
+	// Include the submodules at the commits pinned in the revision
+	Submodules bool
 }
 
-func generateArchiveFile(targetDir, clonedDir, revision, repo, pkg string, isPkgSubdirInRepo bool,
-	errPrefix util.ErrPrefix) (string, error) {
-	// User should ensure the same fileName is specified in .spec file.
-	// We use Source0.tar.gz as the generated tarball path,
-	// since this can be extended to support multiple sources in future.
-	gitArchiveFile := "Source0.tar.gz"
-	gitArchiveFilePath := filepath.Join(targetDir, gitArchiveFile)
-	parentFolder, err := getRpmNameFromSpecFile(repo, pkg, isPkgSubdirInRepo)
-	if err != nil {
-		return "", err
+// getGitArchiveSpec returns the tarball spec for the git source, which
+// is the index-th upstream source of the package. The prefix defaults to
+// <NAME>-<VERSION> from the spec file.
+func getGitArchiveSpec(gitBundle manifest.GitBundle, index int,
+	repo, pkg string, isPkgSubdirInRepo bool) (gitArchiveSpec, error) {
+	prefix := gitBundle.Prefix
+	if prefix == "" {
+		rpmName, err := getRpmNameFromSpecFile(repo, pkg, isPkgSubdirInRepo)
+		if err != nil {
+			return gitArchiveSpec{}, err
+		}
+		prefix = rpmName
 	}
+	return gitArchiveSpec{
+		Name:       gitBundle.GetArchive(index),
+		Prefix:     path.Clean(prefix),
+		Submodules: gitBundle.Submodules,
+	}, nil
+}
 
-	// Create the tarball from the specified commit/tag revision
-	archiveCmd := []string{"archive",
-		"--prefix", parentFolder + "/",
-		"-o", gitArchiveFilePath,
-		revision,
-	}
-	err = util.RunSystemCmdInDir(clonedDir, "git", archiveCmd...)
-	if err != nil {
-		return "", fmt.Errorf("%sgit archive of %s failed: %s %v", errPrefix, pkg, err, archiveCmd)
+// generateArchiveFile generates the reproducible tarball of the revision in
+// the cloned repo in targetDir. With submodules, they are fetched recursively
+// at the commits pinned in the revision and included in the tarball.
+// It returns the submodules included in the tarball.
+func generateArchiveFile(targetDir string, gitSpec gitSpec, archive gitArchiveSpec,
+	pkg string, errPrefix util.ErrPrefix) ([]gitSubmodule, error) {
+	var submodules []gitSubmodule
+	if archive.Submodules {
+		var err error
+		submodules, err = fetchGitSubmodules(targetDir, pkg, gitSpec.ClonedDir,
+			gitSpec.Revision, gitSpec.SrcUrl, "")
+		if err != nil {
+			return nil, fmt.Errorf("%sError fetching submodules of %s: %s", errPrefix, pkg, err)
+		}
 	}
 
-	return gitArchiveFile, nil
+	// User should ensure the same fileName is specified in .spec file.
+	archivePath := filepath.Join(targetDir, archive.Name)
+	if err := writeGitTarball(archivePath, archive, gitSpec.ClonedDir, gitSpec.Revision,
+		submodules); err != nil {
+		return nil, fmt.Errorf("%sError generating %s for %s: %s",
+			errPrefix, archive.Name, pkg, err)
+	}
+	return submodules, nil
 }
 
 // Download the git repo, and create a tarball at the provided commit/tag.
-func archiveGitRepo(srcURL, targetDir, revision, repo, pkg string, isPkgSubdirInRepo bool,
-	errPrefix util.ErrPrefix) (string, string, error) {
-	cloneDir, err := cloneGitRepo(pkg, srcURL, revision, targetDir)
-	if err != nil {
-		return "", "", fmt.Errorf("cloning git repo failed: %s", err)
-	}
-
-	gitArchiveFile, err := generateArchiveFile(targetDir, cloneDir, revision, repo, pkg, isPkgSubdirInRepo, errPrefix)
+func getGitSpecAndSrcFile(srcUrl, revision, downloadDir, pkg string,
+	archive gitArchiveSpec, errPrefix util.ErrPrefix) (*gitSpec, []gitSubmodule, error) {
+	clonedDir, err := cloneGitRepo(pkg, srcUrl, revision, downloadDir)
 	if err != nil {
-		return "", "", fmt.Errorf("generating git archive failed: %s", err)
+		return nil, nil, fmt.Errorf("cloning git repo failed: %s", err)
 	}
-
-	return gitArchiveFile, cloneDir, nil
-}
-
-func getGitSpecAndSrcFile(srcUrl, revision, downloadDir, repo, pkg string,
-	isPkgSubdirInRepo bool, errPrefix util.ErrPrefix) (*gitSpec, string, error) {
 	spec := gitSpec{
-		SrcUrl:   srcUrl,
-		Revision: revision,
+		SrcUrl:    srcUrl,
+		Revision:  revision,
+		ClonedDir: clonedDir,
 	}
 
-	sourceFile, clonedDir, downloadErr := archiveGitRepo(
-		srcUrl,
-		downloadDir,
-		revision,
-		repo, pkg, isPkgSubdirInRepo,
-		errPrefix)
-	if downloadErr != nil {
-		return nil, "", downloadErr
+	submodules, err := generateArchiveFile(downloadDir, spec, archive, pkg, errPrefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating git archive failed: %s", err)
 	}
 
-	spec.ClonedDir = clonedDir
-	return &spec, sourceFile, nil
+	return &spec, submodules, nil
 }
 
 func (bldr *srpmBuilder) getUpstreamSourceForGit(upstreamSrcFromManifest manifest.UpstreamSrc,
-	downloadDir string) (*upstreamSrcSpec, error) {
+	index int, downloadDir string) (*upstreamSrcSpec, error) {
 
 	repo := bldr.repo
 	pkg := bldr.pkgSpec.Name
@@ -164,67 +153,223 @@ func (bldr *srpmBuilder) getUpstreamSourceForGit(upstreamSrcFromManifest manifes
 
 	upstreamSrc := upstreamSrcSpec{}
 
-	bldr.log("creating tarball for %s from repo %s", pkg, srcParams.SrcURL)
+	archive, err := getGitArchiveSpec(upstreamSrcFromManifest.GitBundle, index,
+		repo, pkg, isPkgSubdirInRepo)
+	if err != nil {
+		return nil, fmt.Errorf("%s%s", bldr.errPrefix, err)
+	}
+
+	bldr.log("creating tarball %s for %s from repo %s", archive.Name, pkg, srcParams.SrcURL)
 	srcUrl := srcParams.SrcURL
 	revision := upstreamSrcFromManifest.GitBundle.Revision
-	spec, sourceFile, err := getGitSpecAndSrcFile(srcUrl, revision, downloadDir,
-		repo, pkg, isPkgSubdirInRepo, bldr.errPrefix)
+	spec, submodules, err := getGitSpecAndSrcFile(srcUrl, revision, downloadDir,
+		pkg, archive, bldr.errPrefix)
 	if err != nil {
 		return nil, err
 	}
-	bldr.log("tarball created")
+	for _, submodule := range submodules {
+		bldr.log("included submodule %s from %s at commit %s pinned in %s",
+			submodule.Path, submodule.URL, submodule.Commit, revision)
+	}
+	archivePath := filepath.Join(downloadDir, archive.Name)
+	archiveSha256, err := util.GenerateHash(archivePath, "sha256")
+	if err != nil {
+		return nil, fmt.Errorf("%s%s", bldr.errPrefix, err)
+	}
+	bldr.log("tarball created with sha256 %s", archiveSha256)
+	// The tarball is reproducible, so the manifest can pin its hashes
+	if err := checkManifestHashes(upstreamSrcFromManifest, archivePath,
+		bldr.errPrefix); err != nil {
+		return nil, err
+	}
 
 	upstreamSrc.gitSpec = *spec
-	upstreamSrc.sourceFile = sourceFile
+	upstreamSrc.sourceFile = archive.Name
+	upstreamSrc.submodules = submodules
+	upstreamSrc.sha256 = archiveSha256
 	upstreamSrc.skipSigCheck = upstreamSrcFromManifest.Signature.SkipCheck
 	pubKey := upstreamSrcFromManifest.Signature.DetachedSignature.PubKey
+	allowedSigners := upstreamSrcFromManifest.Signature.DetachedSignature.AllowedSigners
 
 	if !upstreamSrc.skipSigCheck {
-		if pubKey == "" {
-			return nil, fmt.Errorf("%sexpected public-key for %s to verify git repo",
+		if allowedSigners != "" {
+			upstreamSrc.allowedSignersPath = filepath.Join(getDetachedSigDir(), allowedSigners)
+		} else if pubKey != "" {
+			upstreamSrc.pubKeyPath = filepath.Join(getDetachedSigDir(), pubKey)
+		} else {
+			return nil, fmt.Errorf("%sexpected public-key or allowed-signers for %s to verify git repo",
 				bldr.errPrefix, pkg)
 		}
-		upstreamSrc.pubKeyPath = filepath.Join(getDetachedSigDir(), pubKey)
 	}
 
 	return &upstreamSrc, nil
 }
 
-// verifyGitSignature verifies that the git repo commit/tag is signed.
-func verifyGitSignature(pubKeyPath string, gitSpec gitSpec, errPrefix util.ErrPrefix) error {
-	tmpDir, mkdtErr := os.MkdirTemp("", "eext-keyring")
-	if mkdtErr != nil {
-		return fmt.Errorf("%sError '%s'creating temp dir for keyring",
-			errPrefix, mkdtErr)
+// Line printed by ssh-keygen -Y verify for a good signature
+var sshGoodSigRegex = regexp.MustCompile(`Good "git" signature for (.+) with (\S+) key (\S+)`)
+
+const gpgSignatureStart = "-----BEGIN PGP SIGNATURE-----"
+
+// gitRevisionType returns whether the revision is a "tag" or a "commit"
+func gitRevisionType(gitSpec gitSpec, errPrefix util.ErrPrefix) (string, error) {
+	clonedDir := gitSpec.ClonedDir
+	revision := gitSpec.Revision
+	if err := util.RunSystemCmdInDir(clonedDir, "git", "show-ref", "--quiet", "--verify",
+		"refs/tags/"+revision); err == nil {
+		// the provided ref is a tag
+		return "tag", nil
+	} else if err := util.RunSystemCmdInDir(clonedDir, "git", "cat-file", "-e", revision); err == nil {
+		// found an object with that hash
+		return "commit", nil
+	} else {
+		return "", fmt.Errorf("%sinvalid revision %s provided, provide either a COMMIT or TAG: %s",
+			errPrefix, revision, err)
 	}
-	defer os.RemoveAll(tmpDir)
+}
 
-	err := os.Setenv("GNUPGHOME", tmpDir)
+// verifyGitRevision runs git verify-tag or verify-commit on the revision,
+// depending on whether it is a tag or a commit, and returns the raw output.
+// gitConfig are extra git config options to run git with.
+func verifyGitRevision(gitSpec gitSpec, gitConfig []string,
+	errPrefix util.ErrPrefix) (string, error) {
+	revisionType, err := gitRevisionType(gitSpec, errPrefix)
 	if err != nil {
-		return fmt.Errorf("%sunable to set ENV variable GNUPGHOME", errPrefix)
+		return "", err
 	}
-	defer os.Unsetenv("GNUPGHOME")
+	verifyCmd := "verify-" + revisionType
 
-	if err := util.RunSystemCmd("gpg", "--fingerprint"); err != nil {
-		return fmt.Errorf("%sError '%s'creating keyring",
-			errPrefix, err)
+	var gitArgs []string
+	for _, config := range gitConfig {
+		gitArgs = append(gitArgs, "-c", config)
 	}
+	gitArgs = append(gitArgs, verifyCmd, "--raw", gitSpec.Revision)
+	cmd := exec.Command("git", gitArgs...)
+	cmd.Dir = gitSpec.ClonedDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%sgit %s of %s failed: %s\n%s",
+			errPrefix, verifyCmd, gitSpec.Revision, err, output)
+	}
+	return string(output), nil
+}
 
-	// Import public key
-	if err := util.RunSystemCmd("gpg", "--import", pubKeyPath); err != nil {
-		return fmt.Errorf("%sError '%s' importing public-key %s",
-			errPrefix, err, pubKeyPath)
+// splitGitTagSignature splits a raw tag object into the signed payload
+// and the GPG signature appended to it.
+func splitGitTagSignature(object string) (string, string) {
+	sigStart := strings.LastIndex(object, "\n"+gpgSignatureStart)
+	if sigStart == -1 {
+		return object, ""
 	}
+	return object[:sigStart+1], object[sigStart+1:]
+}
 
-	clonedDir := gitSpec.ClonedDir
-	revision := gitSpec.Revision
-	if err := util.RunSystemCmdInDir(clonedDir, "git", "show-ref", "--quiet", "--tags"); err == nil {
-		// the provided ref is a tag
-		return util.RunSystemCmdInDir(clonedDir, "git", "verify-tag", "-v", revision)
+// splitGitCommitSignature splits a raw commit object into the signed payload,
+// which is the commit without the gpgsig header, and the GPG signature
+// in the gpgsig header.
+func splitGitCommitSignature(object string) (string, string) {
+	headersEnd := strings.Index(object, "\n\n")
+	if headersEnd == -1 {
+		headersEnd = len(object)
 	}
-	if err := util.RunSystemCmdInDir(clonedDir, "git", "cat-file", "-e", revision); err == nil {
-		// found an object with that hash
-		return util.RunSystemCmdInDir(clonedDir, "git", "verify-commit", "-v", revision)
+	var payload, signature strings.Builder
+	inSignature := false
+	for _, line := range strings.SplitAfter(object[:headersEnd+1], "\n") {
+		if inSignature && strings.HasPrefix(line, " ") {
+			// continuation line of the signature
+			signature.WriteString(line[1:])
+			continue
+		}
+		inSignature = strings.HasPrefix(line, "gpgsig ")
+		if inSignature {
+			signature.WriteString(strings.TrimPrefix(line, "gpgsig "))
+			continue
+		}
+		payload.WriteString(line)
+	}
+	if headersEnd < len(object) {
+		payload.WriteString(object[headersEnd+1:])
+	}
+	return payload.String(), signature.String()
+}
+
+// readGitSignature returns the signed payload and the GPG signature
+// of the revision, which is a signed tag or commit.
+func readGitSignature(gitSpec gitSpec, errPrefix util.ErrPrefix) (string, string, error) {
+	revisionType, err := gitRevisionType(gitSpec, errPrefix)
+	if err != nil {
+		return "", "", err
+	}
+	cmd := exec.Command("git", "cat-file", revisionType, gitSpec.Revision)
+	cmd.Dir = gitSpec.ClonedDir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("%sError reading %s %s: %s",
+			errPrefix, revisionType, gitSpec.Revision, err)
+	}
+	object := string(output)
+
+	var payload, signature string
+	if revisionType == "tag" {
+		payload, signature = splitGitTagSignature(object)
+	} else {
+		payload, signature = splitGitCommitSignature(object)
+	}
+	if !strings.HasPrefix(signature, gpgSignatureStart) {
+		return "", "", fmt.Errorf("%sno GPG signature found for %s %s",
+			errPrefix, revisionType, gitSpec.Revision)
+	}
+	return payload, signature, nil
+}
+
+// verifyGitSignature verifies that the git repo commit/tag is signed
+// with the GPG public-key and returns the signing key.
+// The signature is verified in-process, without a gpg keyring.
+func verifyGitSignature(pubKeyPath string, gitSpec gitSpec, errPrefix util.ErrPrefix) (
+	*sigverify.Signer, error) {
+	keyring, err := sigverify.LoadKeyring(pubKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("%sError loading public-key: %s", errPrefix, err)
+	}
+	payload, signature, err := readGitSignature(gitSpec, errPrefix)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := keyring.VerifyDetached(strings.NewReader(payload),
+		strings.NewReader(signature))
+	if err != nil {
+		return nil, fmt.Errorf("%sno valid GPG signature found for revision %s with pubkey %s: %s",
+			errPrefix, gitSpec.Revision, pubKeyPath, err)
+	}
+	return signer, nil
+}
+
+// verifyGitSSHSignature verifies that the git repo commit/tag is signed
+// with one of the SSH keys in the allowed signers file and returns
+// the principal and fingerprint of the signing key.
+func verifyGitSSHSignature(allowedSignersPath string, gitSpec gitSpec,
+	errPrefix util.ErrPrefix) (string, error) {
+	if _, err := os.Stat(allowedSignersPath); err != nil {
+		return "", fmt.Errorf("%sallowed-signers file not found: %s", errPrefix, err)
+	}
+	absAllowedSignersPath, err := filepath.Abs(allowedSignersPath)
+	if err != nil {
+		return "", fmt.Errorf("%s%s", errPrefix, err)
+	}
+
+	gitConfig := []string{
+		"gpg.format=ssh",
+		"gpg.ssh.allowedSignersFile=" + absAllowedSignersPath,
+		// Don't consult a revocation list from the user's git config
+		"gpg.ssh.revocationFile=",
+	}
+	output, err := verifyGitRevision(gitSpec, gitConfig, errPrefix)
+	if err != nil {
+		return "", err
+	}
+	match := sshGoodSigRegex.FindStringSubmatch(output)
+	if match == nil {
+		return "", fmt.Errorf("%sno valid SSH signature found for revision %s:\n%s",
+			errPrefix, gitSpec.Revision, output)
 	}
-	return fmt.Errorf("%sinvalid revision %s provided, provide either a COMMIT or TAG: %s", errPrefix, revision, err)
+	return fmt.Sprintf("ssh %s key %s(%s)", match[2], match[3], match[1]), nil
 }
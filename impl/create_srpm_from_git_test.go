@@ -14,6 +14,7 @@ import (
 	"strings"
 	"testing"
 
+	"code.arista.io/eos/tools/eext/manifest"
 	"code.arista.io/eos/tools/eext/util"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
@@ -182,7 +183,7 @@ func TestVerifyGitSignature(t *testing.T) {
 	for _, data := range testData {
 		gitSpec := data.gitSpec
 
-		err := verifyGitSignature(pubKeyPath, *gitSpec, "")
+		_, err := verifyGitSignature(pubKeyPath, *gitSpec, "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -209,12 +210,18 @@ func TestGitArchive(t *testing.T) {
 	repo := "upstream-git-repo-1"
 	revision := "libpcap-1.10.1"
 
-	archiveFile, err := generateArchiveFile(testWorkingDir, clonedDir, revision, repo, pkg, false, "")
+	archive, err := getGitArchiveSpec(manifest.GitBundle{}, 0, repo, pkg, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	require.Equal(t, gitArchiveSpec{Name: "Source0.tar.gz", Prefix: "libpcap-1.10.1"}, archive)
+	_, err = generateArchiveFile(testWorkingDir,
+		gitSpec{Revision: revision, ClonedDir: clonedDir}, archive, pkg, "")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	archivePath := filepath.Join(testWorkingDir, archiveFile)
+	archivePath := filepath.Join(testWorkingDir, archive.Name)
 	_, err = os.Stat(archivePath)
 	if err != nil {
 		t.Fatal(err)
@@ -4,30 +4,20 @@
 package impl
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"code.arista.io/eos/tools/eext/decompress"
 	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/sigverify"
 	"code.arista.io/eos/tools/eext/srcconfig"
 	"code.arista.io/eos/tools/eext/util"
 )
 
-func checkSHA256Hash(srcFilePath string, sha256InManifest string,
-	errPrefix util.ErrPrefix) error {
-	sha256Hash, err := util.GenerateSha256Hash(srcFilePath)
-	if err != nil {
-		return fmt.Errorf("%s SHA256 generation failed with '%s' for file: %s",
-			errPrefix, err, srcFilePath)
-	}
-	if sha256Hash != sha256InManifest {
-		return fmt.Errorf("%s bad SHA256: '%s' expected: '%s' for file: %s",
-			errPrefix, sha256Hash, sha256InManifest, srcFilePath)
-	}
-	return nil
-}
-
 func (bldr *srpmBuilder) getUpstreamSourceForOthers(upstreamSrcFromManifest manifest.UpstreamSrc,
 	downloadDir string) (*upstreamSrcSpec, error) {
 
@@ -53,6 +43,7 @@ func (bldr *srpmBuilder) getUpstreamSourceForOthers(upstreamSrcFromManifest mani
 	upstreamSrc := upstreamSrcSpec{}
 
 	upstreamSrcType := bldr.pkgSpec.Type
+	upstreamSrc.srcURL = srcParams.SrcURL
 	bldr.log("downloading %s", srcParams.SrcURL)
 	// Download source
 	if upstreamSrc.sourceFile, downloadErr = download(
@@ -64,11 +55,15 @@ func (bldr *srpmBuilder) getUpstreamSourceForOthers(upstreamSrcFromManifest mani
 	}
 	bldr.log("downloaded")
 
-	if upstreamSrcFromManifest.Sha256 != "" {
-		sha256InManifest := upstreamSrcFromManifest.Sha256
-		srcFilePath := filepath.Join(downloadDir, upstreamSrc.sourceFile)
-		err := checkSHA256Hash(srcFilePath, sha256InManifest, bldr.errPrefix)
-		if err != nil {
+	srcFilePath := filepath.Join(downloadDir, upstreamSrc.sourceFile)
+	if err := checkManifestHashes(upstreamSrcFromManifest, srcFilePath,
+		bldr.errPrefix); err != nil {
+		return nil, err
+	}
+
+	if upstreamSrcFromManifest.ChecksumFile.FullURL != "" {
+		if err := bldr.checkChecksumFile(upstreamSrcFromManifest.ChecksumFile,
+			srcFilePath, downloadDir); err != nil {
 			return nil, err
 		}
 	}
@@ -107,10 +102,19 @@ func (bldr *srpmBuilder) getUpstreamSourceForOthers(upstreamSrcFromManifest mani
 }
 
 // verifyRpmSignature verifies that the RPM specified at rpmPath
-// is signed with a valid key in the key ring and that the signatures
-// are valid.
+// is signed with a valid key in the rpmkeys dir and that the signatures
+// are valid. The keys are imported to a private rpmdb for the check.
+// The private rpmdb is a temp dir removed after the check, so it's
+// created on dry runs too, like upstream sources are still downloaded
+// and verified.
 func verifyRpmSignature(rpmPath string, errPrefix util.ErrPrefix) error {
-	output, err := util.CheckOutput("rpm", "-K", rpmPath)
+	dbPath, keyringErr := newRpmKeyring(errPrefix)
+	if keyringErr != nil {
+		return keyringErr
+	}
+	defer os.RemoveAll(dbPath)
+
+	output, err := util.CheckOutput("rpm", "--dbpath", dbPath, "-K", rpmPath)
 	if err != nil {
 		return fmt.Errorf("%s:%s", errPrefix, err)
 	}
@@ -121,90 +125,135 @@ func verifyRpmSignature(rpmPath string, errPrefix util.ErrPrefix) error {
 	return nil
 }
 
+// compressionSuffixes maps the suffixes of compressed files to the
+// compression format and the suffix of the decompressed file.
+var compressionSuffixes = map[string]struct {
+	format string
+	suffix string
+}{
+	".gz":   {decompress.Gzip, ""},
+	".bz2":  {decompress.Bzip2, ""},
+	".xz":   {decompress.Xz, ""},
+	".lzma": {decompress.Lzma, ""},
+	".zst":  {decompress.Zstd, ""},
+	".tgz":  {decompress.Gzip, ".tar"},
+	".tbz2": {decompress.Bzip2, ".tar"},
+	".tbz":  {decompress.Bzip2, ".tar"},
+	".txz":  {decompress.Xz, ".tar"},
+	".tzst": {decompress.Zstd, ".tar"},
+}
+
 // isSigfileApplicable checks if the tarball and sigfile paths correspond to the same package.
-// Returns two bools: if the sigfile is applicable and if decompression is required.
-func isSigfileApplicable(tarballPath, tarballSigPath string) (bool, bool) {
+// Returns if the sigfile is applicable and the compression layers, outermost first,
+// to strip from the tarball to get to the signed file.
+// Eg: foo.tar.gz.xz with foo.tar.sig requires stripping xz and gzip.
+func isSigfileApplicable(tarballPath, tarballSigPath string) (bool, []string) {
 	lastDotIndex := strings.LastIndex(tarballSigPath, ".")
-	if lastDotIndex == -1 || !strings.HasPrefix(
-		tarballPath, tarballSigPath[:lastDotIndex]) {
-		return false, false
+	if lastDotIndex == -1 {
+		return false, nil
+	}
+	signedPath := tarballSigPath[:lastDotIndex]
+
+	var layers []string
+	for path := tarballPath; ; {
+		if path == signedPath {
+			return true, layers
+		}
+		ext := filepath.Ext(path)
+		compression, found := compressionSuffixes[ext]
+		if !found {
+			return false, nil
+		}
+		layers = append(layers, compression.format)
+		path = strings.TrimSuffix(path, ext) + compression.suffix
 	}
-	suffixCount := strings.Count(tarballPath[lastDotIndex:], ".")
-	decompressionRequired := suffixCount > 0
-	return true, decompressionRequired
 }
 
-// uncompressTarball decompresses the compression one layer at a time
-// to match the tarball with its valid signature
-func uncompressTarball(tarballPath string, downloadDir string) (string, error) {
-	if err := util.RunSystemCmd(
-		"7za", "x",
-		"-y", tarballPath,
-		"-o"+downloadDir); err != nil {
-		return "", err
-	}
-	lastDotIndex := strings.LastIndex(tarballPath, ".")
-	return tarballPath[:lastDotIndex], nil
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
 }
 
-// matchTarballSignCmprsn evaluvates and finds correct compressed/uncompressed tarball
-// that matches with the sign file.
+func (mc *multiCloser) Close() error {
+	var err error
+	for i := len(mc.closers) - 1; i >= 0; i-- {
+		if closeErr := mc.closers[i].Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// matchTarballSignCmprsn opens the tarball for verifying its signature,
+// streaming it through the decompression layers required to match the
+// file which was signed.
 func matchTarballSignCmprsn(tarballPath string, tarballSigPath string,
-	downloadDir string, errPrefix util.ErrPrefix) (string, error) {
-	ok, dcmprsnReqd := isSigfileApplicable(tarballPath, tarballSigPath)
+	errPrefix util.ErrPrefix) (io.ReadCloser, error) {
+	ok, layers := isSigfileApplicable(tarballPath, tarballSigPath)
 	if !ok {
-		return "", fmt.Errorf("%sError while matching tarball and signature",
-			errPrefix)
+		return nil, fmt.Errorf("%sError while matching tarball %s and signature %s",
+			errPrefix, filepath.Base(tarballPath), filepath.Base(tarballSigPath))
 	}
-	if dcmprsnReqd {
-		newTarballPath, err := uncompressTarball(tarballPath, downloadDir)
+
+	tarball, err := os.Open(tarballPath)
+	if err != nil {
+		return nil, fmt.Errorf("%sError '%s' opening tarball", errPrefix, err)
+	}
+	stream := &multiCloser{Reader: tarball, closers: []io.Closer{tarball}}
+	for _, format := range layers {
+		bufReader := bufio.NewReader(stream.Reader)
+		detected, err := decompress.Detect(bufReader)
+		if err == nil && detected != format && format != decompress.Lzma {
+			err = fmt.Errorf("expected %s compressed data", format)
+		}
+		var decompressor io.ReadCloser
+		if err == nil {
+			decompressor, err = decompress.NewReader(bufReader, format)
+		}
 		if err != nil {
-			return "", fmt.Errorf("%sError '%s' while decompressing trarball",
-				errPrefix, err)
+			stream.Close()
+			return nil, fmt.Errorf("%sError '%s' while decompressing tarball %s",
+				errPrefix, err, tarballPath)
 		}
-		return newTarballPath, nil
+		stream.Reader = decompressor
+		stream.closers = append(stream.closers, decompressor)
 	}
-	return "", nil
+	return stream, nil
 }
 
-// VerifyTarballSignature verifies that the detached signature of the tarball
-// is valid.
+// verifyTarballSignature verifies that the detached signature of the tarball
+// is valid and returns the signing key.
+// The tarball is decompressed as required to match the signature, and the
+// signature is verified in-process, without a gpg keyring.
 func verifyTarballSignature(
 	tarballPath string, tarballSigPath string, pubKeyPath string,
-	errPrefix util.ErrPrefix) error {
-	tmpDir, mkdtErr := os.MkdirTemp("", "eext-keyring")
-	if mkdtErr != nil {
-		return fmt.Errorf("%sError '%s'creating temp dir for keyring",
-			errPrefix, mkdtErr)
+	errPrefix util.ErrPrefix) (*sigverify.Signer, error) {
+	keyring, err := sigverify.LoadKeyring(pubKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("%sError loading public-key: %s", errPrefix, err)
 	}
-	defer os.RemoveAll(tmpDir)
-
-	keyRingPath := filepath.Join(tmpDir, "eext.gpg")
-	baseArgs := []string{
-		"--homedir", tmpDir,
-		"--no-default-keyring", "--keyring", keyRingPath}
-	gpgCmd := "gpg"
 
-	// Create keyring
-	createKeyRingCmdArgs := append(baseArgs, "--fingerprint")
-	if err := util.RunSystemCmd(gpgCmd, createKeyRingCmdArgs...); err != nil {
-		return fmt.Errorf("%sError '%s'creating keyring",
-			errPrefix, err)
+	signedData, err := matchTarballSignCmprsn(tarballPath, tarballSigPath, errPrefix)
+	if err != nil {
+		return nil, err
 	}
-
-	// Import public key
-	importKeyCmdArgs := append(baseArgs, "--import", pubKeyPath)
-	if err := util.RunSystemCmd(gpgCmd, importKeyCmdArgs...); err != nil {
-		return fmt.Errorf("%sError '%s' importing public-key %s",
-			errPrefix, err, pubKeyPath)
+	defer signedData.Close()
+	signature, err := os.Open(tarballSigPath)
+	if err != nil {
+		return nil, fmt.Errorf("%sError '%s' opening signature", errPrefix, err)
 	}
+	defer signature.Close()
 
-	verifySigArgs := append(baseArgs, "--verify", tarballSigPath, tarballPath)
-	if output, err := util.CheckOutput(gpgCmd, verifySigArgs...); err != nil {
-		return fmt.Errorf("%sError verifying signature %s for tarball %s with pubkey %s."+
-			"\ngpg --verify err: %sstdout:%s",
-			errPrefix, tarballSigPath, tarballPath, pubKeyPath, err, output)
+	signer, err := keyring.VerifyDetached(signedData, signature)
+	if err != nil {
+		return nil, fmt.Errorf("%sError verifying signature %s for tarball %s with pubkey %s: %s",
+			errPrefix, tarballSigPath, tarballPath, pubKeyPath, err)
 	}
+	return signer, nil
+}
 
-	return nil
+// signerDescription describes the key which made a good OpenPGP signature,
+// for logs and reports.
+func signerDescription(signer *sigverify.Signer) string {
+	return fmt.Sprintf("gpg key %s", signer.Fingerprint)
 }
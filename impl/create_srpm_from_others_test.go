@@ -1,48 +1,96 @@
 package impl
 
 import (
+	"bytes"
+	"compress/gzip"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
 )
 
 func TestIsSigfileApplicable(t *testing.T) {
 	testCases := []struct {
 		tarballPath   string
 		signaturePath string
-		out1          bool
-		out2          bool
+		applicable    bool
+		layers        []string
 	}{
-		{"foo.tar.gz", "foo.tar.gz.sig", true, false},
-		{"foo.tar.gz", "foo.tar.sig", true, true},
-		{"foobar.tar.gz", "signature", false, false},
-		{"foo.tar.gz", "bar.tar.gz.sig", false, false},
+		{"foo.tar.gz", "foo.tar.gz.sig", true, nil},
+		{"foo.tar.gz", "foo.tar.sig", true, []string{"gzip"}},
+		{"foobar.tar.gz", "signature", false, nil},
+		{"foo.tar.gz", "bar.tar.gz.sig", false, nil},
+		{"foo.tar.gz.xz", "foo.tar.asc", true, []string{"xz", "gzip"}},
+		{"foo.tar.gz.xz", "foo.tar.gz.asc", true, []string{"xz"}},
+		{"foo.tgz", "foo.tar.sig", true, []string{"gzip"}},
+		{"foo.tgz", "foo.tgz.sig", true, nil},
+		{"foo.tar.zst", "foo.tar.sign", true, []string{"zstd"}},
+		{"foo.tbz2", "foo.tar.sig", true, []string{"bzip2"}},
+		{"foo.tar.7z", "foo.tar.sig", false, nil},
+		{"foo-1.2.tar.gz", "foo-1.sig", false, nil},
 	}
 	for _, tc := range testCases {
-		res1, res2 := isSigfileApplicable(tc.tarballPath, tc.signaturePath)
-		if res1 != tc.out1 || res2 != tc.out2 {
-			t.Errorf("isSigfileApplicable for (%s, %s) -> (%t, %t); expected (%t, %t)",
-				tc.tarballPath, tc.signaturePath, res1, res2, tc.out1, tc.out2)
+		applicable, layers := isSigfileApplicable(tc.tarballPath, tc.signaturePath)
+		if applicable != tc.applicable || !reflect.DeepEqual(layers, tc.layers) {
+			t.Errorf("isSigfileApplicable for (%s, %s) -> (%t, %v); expected (%t, %v)",
+				tc.tarballPath, tc.signaturePath, applicable, layers, tc.applicable, tc.layers)
 		}
 	}
 }
 
 func TestMatchTarballSignature(t *testing.T) {
 	t.Log("Test tarball Signature Match")
-	curPath, _ := os.Getwd()
-	workingDir := filepath.Join(curPath, "testData/tarballSig/checkTarball")
-	tarballSigPath := filepath.Join(workingDir, "libpcap-1.10.4.tar.gz.sig")
-	tarballPath := filepath.Join(workingDir, "libpcap-1.10.4.tar.gz")
-	intermediateTarball, err := matchTarballSignCmprsn(
-		tarballPath,
-		tarballSigPath,
-		workingDir,
-		"TestmatchTarballSignature : ",
-	)
-	os.Remove(intermediateTarball)
+	dir := t.TempDir()
+	tarballContents := []byte("dummy tarball contents")
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	_, err := gzipWriter.Write(tarballContents)
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	var xzipped bytes.Buffer
+	xzWriter, err := xz.NewWriter(&xzipped)
+	require.NoError(t, err)
+	_, err = xzWriter.Write(gzipped.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, xzWriter.Close())
+
+	tarballPath := filepath.Join(dir, "foo-1.0.tar.gz.xz")
+	require.NoError(t, os.WriteFile(tarballPath, xzipped.Bytes(), 0644))
+	for sigName, expected := range map[string][]byte{
+		"foo-1.0.tar.gz.xz.sig": xzipped.Bytes(),
+		"foo-1.0.tar.gz.sig":    gzipped.Bytes(),
+		"foo-1.0.tar.asc":       tarballContents,
+	} {
+		t.Logf("Matching %s", sigName)
+		signedData, err := matchTarballSignCmprsn(tarballPath,
+			filepath.Join(dir, sigName), "TestmatchTarballSignature : ")
+		require.NoError(t, err)
+		data, err := io.ReadAll(signedData)
+		require.NoError(t, err)
+		require.NoError(t, signedData.Close())
+		require.Equal(t, expected, data)
+	}
+
+	t.Log("Test mismatched compression")
+	badTarballPath := filepath.Join(dir, "bar-1.0.tgz")
+	require.NoError(t, os.WriteFile(badTarballPath, xzipped.Bytes(), 0644))
+	_, err = matchTarballSignCmprsn(badTarballPath, filepath.Join(dir, "bar-1.0.tar.sig"),
+		"TestmatchTarballSignature : ")
+	require.ErrorContains(t, err, "expected gzip compressed data")
+
+	t.Log("Test mismatched signature")
+	_, err = matchTarballSignCmprsn(tarballPath, filepath.Join(dir, "bar-1.0.tar.sig"),
+		"TestmatchTarballSignature : ")
+	require.ErrorContains(t, err, "Error while matching tarball")
+
+	entries, err := os.ReadDir(dir)
 	require.NoError(t, err)
+	require.Len(t, entries, 2, "no intermediate files are written")
 }
 
 func getSHA256hash(folderName string, sha256InManifest string) error {
@@ -50,7 +98,7 @@ func getSHA256hash(folderName string, sha256InManifest string) error {
 	cwd, _ := os.Getwd()
 	downloadDir := filepath.Join(cwd, folderName)
 	srcFilePath := filepath.Join(downloadDir, sourceFile)
-	checkSHA256HashErr := checkSHA256Hash(srcFilePath, sha256InManifest, "")
+	checkSHA256HashErr := checkHash(srcFilePath, "sha256", sha256InManifest, "")
 	return checkSHA256HashErr
 }
 
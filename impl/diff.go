@@ -0,0 +1,391 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/srcconfig"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// DiffExtraCmdlineArgs is a bundle of extra args for impl.Diff
+// Format is text or json.
+// SkipPrep skips the diff of the source trees after %prep,
+// which runs rpmbuild.
+type DiffExtraCmdlineArgs struct {
+	Format   string
+	SkipPrep bool
+}
+
+// diffChanges lists the files added, removed and changed
+// in the eext built SRPM, relative to upstream.
+type diffChanges struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+// pkgDiff is what eext diff reports for a package.
+// PrepDiff is nil if the %prep source trees weren't compared.
+type pkgDiff struct {
+	Package  string      `json:"package"`
+	Upstream []string    `json:"upstream"`
+	Srpm     string      `json:"srpm"`
+	SpecDiff string      `json:"spec-diff"`
+	Patches  diffChanges `json:"patches"`
+	Sources  diffChanges `json:"sources"`
+	PrepDiff *string     `json:"prep-diff,omitempty"`
+}
+
+func isPatchFile(filename string) bool {
+	return strings.HasSuffix(filename, ".patch") || strings.HasSuffix(filename, ".diff")
+}
+
+// diffSources compares the files in the SOURCES dirs by their sha256,
+// and returns the changes to the patches and to the other sources.
+func diffSources(upstreamSourcesDir string, eextSourcesDir string) (
+	diffChanges, diffChanges, error) {
+	hashes := func(dir string) (map[string]string, error) {
+		fileHashes := make(map[string]string)
+		files, _ := filepath.Glob(filepath.Join(dir, "*"))
+		for _, file := range files {
+			if info, err := os.Stat(file); err != nil || info.IsDir() {
+				continue
+			}
+			hash, err := util.GenerateHash(file, "sha256")
+			if err != nil {
+				return nil, err
+			}
+			fileHashes[filepath.Base(file)] = hash
+		}
+		return fileHashes, nil
+	}
+	upstreamHashes, err := hashes(upstreamSourcesDir)
+	if err != nil {
+		return diffChanges{}, diffChanges{}, err
+	}
+	eextHashes, err := hashes(eextSourcesDir)
+	if err != nil {
+		return diffChanges{}, diffChanges{}, err
+	}
+
+	patches := diffChanges{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	sources := diffChanges{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	changesOf := func(filename string) *diffChanges {
+		if isPatchFile(filename) {
+			return &patches
+		}
+		return &sources
+	}
+	for filename, hash := range eextHashes {
+		changes := changesOf(filename)
+		if upstreamHash, found := upstreamHashes[filename]; !found {
+			changes.Added = append(changes.Added, filename)
+		} else if upstreamHash != hash {
+			changes.Changed = append(changes.Changed, filename)
+		}
+	}
+	for filename := range upstreamHashes {
+		if _, found := eextHashes[filename]; !found {
+			changes := changesOf(filename)
+			changes.Removed = append(changes.Removed, filename)
+		}
+	}
+	for _, changes := range []*diffChanges{&patches, &sources} {
+		sort.Strings(changes.Added)
+		sort.Strings(changes.Removed)
+		sort.Strings(changes.Changed)
+	}
+	return patches, sources, nil
+}
+
+// readSpecFile returns the name and contents of the spec file in specsDir,
+// which are empty if there's none.
+func readSpecFile(specsDir string) (string, string, error) {
+	specFiles, _ := filepath.Glob(filepath.Join(specsDir, "*.spec"))
+	if len(specFiles) == 0 {
+		return "", "", nil
+	}
+	if len(specFiles) > 1 {
+		return "", "", fmt.Errorf("multiple spec files %s in %s",
+			strings.Join(specFiles, ","), specsDir)
+	}
+	contents, err := os.ReadFile(specFiles[0])
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Base(specFiles[0]), string(contents), nil
+}
+
+// prep runs %prep of the spec file in the rpmbuild tree at rpmbuildDir,
+// to get the source tree the package is built from in BUILD.
+func (bldr *srpmBuilder) prep(rpmbuildDir string) error {
+	specFiles, _ := filepath.Glob(filepath.Join(rpmbuildDir, "SPECS", "*.spec"))
+	if len(specFiles) != 1 {
+		return fmt.Errorf("%sNo/multiple spec files %s in %s",
+			bldr.errPrefix, strings.Join(specFiles, ","), rpmbuildDir)
+	}
+	// The output isn't part of the diff
+	if _, err := bldr.executor.Output("rpmbuild", "-bp", "--nodeps",
+		"--define", fmt.Sprintf("_topdir %s", rpmbuildDir),
+		specFiles[0]); err != nil {
+		return fmt.Errorf("%s%%prep of %s failed: %s", bldr.errPrefix, specFiles[0], err)
+	}
+	return nil
+}
+
+// extractUpstreamTarballs extracts the upstream tarballs for the diff of
+// the source trees, for upstreams without a spec file. They are extracted
+// to the dir %prep of the eext spec file extracted its sources to,
+// which is under <name>-<version>-build with rpm >= 4.20.
+func (bldr *srpmBuilder) extractUpstreamTarballs(upstreamBuildDir string,
+	eextBuildDir string) error {
+	destDir := upstreamBuildDir
+	entries, _ := os.ReadDir(eextBuildDir)
+	if len(entries) == 1 && entries[0].IsDir() &&
+		strings.HasSuffix(entries[0].Name(), "-build") {
+		destDir = filepath.Join(upstreamBuildDir, entries[0].Name())
+	}
+	downloadDir := getDownloadDir(bldr.pkgSpec.Name)
+	for _, upstreamSrc := range bldr.upstreamSrc {
+		if !isArchiveSource(upstreamSrc.sourceFile) {
+			continue
+		}
+		tarballPath := filepath.Join(downloadDir, upstreamSrc.sourceFile)
+		if err := extractTarball(tarballPath, destDir); err != nil {
+			return fmt.Errorf("%sError extracting %s: %s", bldr.errPrefix, tarballPath, err)
+		}
+	}
+	return nil
+}
+
+// diff compares the upstream sources, fetched and verified before,
+// with the eext built SRPM at srpmPath.
+func (bldr *srpmBuilder) diff(srpmPath string, skipPrep bool) (*pkgDiff, error) {
+	pkg := bldr.pkgSpec.Name
+	diffDir := getDiffDir(pkg)
+	if err := os.RemoveAll(diffDir); err != nil {
+		return nil, fmt.Errorf("%sError '%s' removing %s", bldr.errPrefix, err, diffDir)
+	}
+	upstreamDir := filepath.Join(diffDir, "upstream")
+	eextDir := filepath.Join(diffDir, "eext")
+
+	result := &pkgDiff{
+		Package: pkg,
+		Srpm:    filepath.Base(srpmPath),
+	}
+	for _, upstreamSrc := range bldr.upstreamSrc {
+		result.Upstream = append(result.Upstream, upstreamSrc.sourceFile)
+	}
+
+	// The upstream is laid out like an rpmbuild tree, like the eext SRPM
+	if bldr.pkgSpec.Type == "srpm" || bldr.pkgSpec.Type == "unmodified-srpm" {
+		if err := extractSrpm(bldr.upstreamSrpmDownloadPath(), upstreamDir,
+			bldr.errPrefix); err != nil {
+			return nil, err
+		}
+	} else {
+		upstreamSourcesDir := filepath.Join(upstreamDir, "SOURCES")
+		if err := os.MkdirAll(upstreamSourcesDir, 0755); err != nil {
+			return nil, fmt.Errorf("%sError '%s' creating %s",
+				bldr.errPrefix, err, upstreamSourcesDir)
+		}
+		downloadDir := getDownloadDir(pkg)
+		for _, upstreamSrc := range bldr.upstreamSrc {
+			if err := util.CopyToDestDir(filepath.Join(downloadDir, upstreamSrc.sourceFile),
+				upstreamSourcesDir, bldr.errPrefix); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := extractSrpm(srpmPath, eextDir, bldr.errPrefix); err != nil {
+		return nil, err
+	}
+
+	upstreamSpecName, upstreamSpec, err := readSpecFile(filepath.Join(upstreamDir, "SPECS"))
+	if err != nil {
+		return nil, fmt.Errorf("%s%s", bldr.errPrefix, err)
+	}
+	eextSpecName, eextSpec, err := readSpecFile(filepath.Join(eextDir, "SPECS"))
+	if err != nil {
+		return nil, fmt.Errorf("%s%s", bldr.errPrefix, err)
+	}
+	upstreamSpecLabel, eextSpecLabel := "/dev/null", "/dev/null"
+	if upstreamSpecName != "" {
+		upstreamSpecLabel = "upstream/" + upstreamSpecName
+	}
+	if eextSpecName != "" {
+		eextSpecLabel = "eext/" + eextSpecName
+	}
+	result.SpecDiff = unifiedDiff(upstreamSpecLabel, eextSpecLabel, upstreamSpec, eextSpec)
+
+	result.Patches, result.Sources, err = diffSources(
+		filepath.Join(upstreamDir, "SOURCES"), filepath.Join(eextDir, "SOURCES"))
+	if err != nil {
+		return nil, fmt.Errorf("%s%s", bldr.errPrefix, err)
+	}
+
+	if !skipPrep {
+		if err := bldr.prep(eextDir); err != nil {
+			return nil, err
+		}
+		upstreamBuildDir := filepath.Join(upstreamDir, "BUILD")
+		eextBuildDir := filepath.Join(eextDir, "BUILD")
+		if upstreamSpecName != "" {
+			if err := bldr.prep(upstreamDir); err != nil {
+				return nil, err
+			}
+		} else if err := bldr.extractUpstreamTarballs(upstreamBuildDir,
+			eextBuildDir); err != nil {
+			return nil, err
+		}
+		prepDiff, err := diffTrees(upstreamBuildDir, eextBuildDir, "upstream", "eext")
+		if err != nil {
+			return nil, fmt.Errorf("%sError diffing source trees: %s", bldr.errPrefix, err)
+		}
+		result.PrepDiff = &prepDiff
+	}
+	return result, nil
+}
+
+// writeDiff writes the diff of the package in the format, text or json.
+func writeDiff(w io.Writer, result *pkgDiff, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	fmt.Fprintf(w, "Package: %s\n", result.Package)
+	fmt.Fprintf(w, "Upstream: %s\n", strings.Join(result.Upstream, ", "))
+	fmt.Fprintf(w, "SRPM: %s\n", result.Srpm)
+	fmt.Fprintf(w, "\nSpec file:\n")
+	if result.SpecDiff == "" {
+		fmt.Fprintf(w, "No changes\n")
+	} else {
+		fmt.Fprint(w, result.SpecDiff)
+	}
+	for _, section := range []struct {
+		title   string
+		changes diffChanges
+	}{
+		{"Patches", result.Patches},
+		{"Sources", result.Sources},
+	} {
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		if len(section.changes.Added)+len(section.changes.Removed)+
+			len(section.changes.Changed) == 0 {
+			fmt.Fprintf(w, "No changes\n")
+		}
+		for _, filename := range section.changes.Added {
+			fmt.Fprintf(w, "A %s\n", filename)
+		}
+		for _, filename := range section.changes.Removed {
+			fmt.Fprintf(w, "D %s\n", filename)
+		}
+		for _, filename := range section.changes.Changed {
+			fmt.Fprintf(w, "M %s\n", filename)
+		}
+	}
+	if result.PrepDiff != nil {
+		fmt.Fprintf(w, "\nSource tree after %%prep:\n")
+		if *result.PrepDiff == "" {
+			fmt.Fprintf(w, "No changes\n")
+		} else {
+			fmt.Fprint(w, *result.PrepDiff)
+		}
+	}
+	return nil
+}
+
+// diffPackage fetches and verifies the upstream of the package pkg, and
+// compares it with the SRPM built by eext create-srpm.
+func diffPackage(repo string, pkg string, extraArgs DiffExtraCmdlineArgs,
+	executor executor.Executor, errPrefix util.ErrPrefix) (*pkgDiff, error) {
+	repoManifest, err := manifest.LoadManifest(repo)
+	if err != nil {
+		return nil, err
+	}
+	var pkgSpec *manifest.Package
+	for i := range repoManifest.Package {
+		if repoManifest.Package[i].Name == pkg {
+			pkgSpec = &repoManifest.Package[i]
+		}
+	}
+	if pkgSpec == nil {
+		return nil, fmt.Errorf("%sInvalid package name %s specified", errPrefix, pkg)
+	}
+	if pkgSpec.Type == "standalone" {
+		return nil, fmt.Errorf("%sstandalone packages have no upstream to diff against",
+			errPrefix)
+	}
+
+	srpmsDir, err := getPkgSrpmsDir(errPrefix, pkg)
+	if err != nil {
+		return nil, err
+	}
+	srpms, _ := filepath.Glob(filepath.Join(srpmsDir, "*.src.rpm"))
+	if len(srpms) != 1 {
+		return nil, fmt.Errorf("%sExpected one SRPM in %s, found %d, run eext create-srpm first",
+			errPrefix, srpmsDir, len(srpms))
+	}
+
+	srcConfig, err := srcconfig.LoadSrcConfig()
+	if err != nil {
+		return nil, err
+	}
+	bldr := srpmBuilder{
+		pkgSpec:       pkgSpec,
+		repo:          repo,
+		errPrefixBase: util.ErrPrefix(fmt.Sprintf("diff(%s)", pkg)),
+		srcConfig:     srcConfig,
+		executor:      executor,
+	}
+	bldr.setupStageErrPrefix("fetchUpstream")
+	if err := bldr.fetchUpstream(); err != nil {
+		return nil, err
+	}
+	bldr.setupStageErrPrefix("verifyUpstream")
+	if err := bldr.verifyUpstream(); err != nil {
+		return nil, err
+	}
+	bldr.setupStageErrPrefix("diff")
+	return bldr.diff(srpms[0], extraArgs.SkipPrep)
+}
+
+// Diff shows what eext changes relative to upstream for the package pkg.
+// The upstream SRPM or tarballs are compared with the SRPM built by
+// eext create-srpm: the diff of the spec files, the patches and sources
+// which are added, removed or changed, and the diff of the source trees
+// after %prep, unless extraArgs.SkipPrep is set.
+func Diff(repo string, pkg string, extraArgs DiffExtraCmdlineArgs,
+	executor executor.Executor) error {
+	if err := setup(); err != nil {
+		return err
+	}
+	errPrefix := util.ErrPrefix(fmt.Sprintf("impl.Diff(%s): ", pkg))
+
+	format := extraArgs.Format
+	if format == "" {
+		format = "text"
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("%sUnsupported format %s, expected text or json", errPrefix, format)
+	}
+
+	result, err := diffPackage(repo, pkg, extraArgs, executor, errPrefix)
+	if err != nil {
+		return err
+	}
+	return writeDiff(os.Stdout, result, format)
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/testutil"
+)
+
+const diffTestManifest = `---
+package:
+  - name: foo
+    upstream-sources:
+      - full-url: "file:///upstream/foo-1.0-1.src.rpm"
+        signature:
+          skip-check: true
+    type: srpm
+    build:
+      repo-bundle:
+        - name: el9
+`
+
+func TestDiffPackage(t *testing.T) {
+	srcDir := t.TempDir()
+	srpmsDir := t.TempDir()
+	testutil.SetupViperConfig(srcDir, t.TempDir(), t.TempDir(), srpmsDir,
+		"", "", "", "", "", "")
+	defer viper.Reset()
+
+	repoDir := filepath.Join(srcDir, "foo")
+	upstreamDir := filepath.Join(repoDir, "upstream")
+	eextSrpmDir := filepath.Join(srpmsDir, "foo")
+	for _, dir := range []string{upstreamDir, eextSrpmDir} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "eext.yaml"),
+		[]byte(diffTestManifest), 0644))
+	writeTestSrpm(t, upstreamDir, "gzip", []testSrpmFile{
+		{name: "foo.spec", mode: 0100644, contents: "Name: foo\nRelease: 1\nPatch0: fix-build.patch\n", specFile: true},
+		{name: "foo-1.0.tar.gz", mode: 0100644, contents: "tarball"},
+		{name: "fix-build.patch", mode: 0100644, contents: "patch"},
+		{name: "old.patch", mode: 0100644, contents: "old"},
+	}, nil)
+
+	_, err := diffPackage("foo", "foo", DiffExtraCmdlineArgs{SkipPrep: true},
+		&executor.OsExecutor{}, "")
+	require.ErrorContains(t, err, "run eext create-srpm first")
+
+	writeTestSrpm(t, eextSrpmDir, "gzip", []testSrpmFile{
+		{name: "foo.spec", mode: 0100644, contents: "Name: foo\nRelease: 1.eng\nPatch0: fix-build.patch\n", specFile: true},
+		{name: "foo-1.0.tar.gz", mode: 0100644, contents: "tarball"},
+		{name: "fix-build.patch", mode: 0100644, contents: "patch v2"},
+		{name: "new.patch", mode: 0100644, contents: "new"},
+		{name: "foo.service", mode: 0100644, contents: "service"},
+	}, nil)
+
+	result, err := diffPackage("foo", "foo", DiffExtraCmdlineArgs{SkipPrep: true},
+		&executor.OsExecutor{}, "")
+	require.NoError(t, err)
+	require.Equal(t, &pkgDiff{
+		Package:  "foo",
+		Upstream: []string{"foo-1.0-1.src.rpm"},
+		Srpm:     "foo-1.0-1.src.rpm",
+		SpecDiff: `--- upstream/foo.spec
++++ eext/foo.spec
+@@ -1,3 +1,3 @@
+ Name: foo
+-Release: 1
++Release: 1.eng
+ Patch0: fix-build.patch
+`,
+		Patches: diffChanges{
+			Added:   []string{"new.patch"},
+			Removed: []string{"old.patch"},
+			Changed: []string{"fix-build.patch"},
+		},
+		Sources: diffChanges{
+			Added:   []string{"foo.service"},
+			Removed: []string{},
+			Changed: []string{},
+		},
+	}, result)
+
+	_, err = diffPackage("foo", "bar", DiffExtraCmdlineArgs{}, &executor.OsExecutor{}, "")
+	require.ErrorContains(t, err, "Invalid package name bar")
+}
+
+func TestWriteDiff(t *testing.T) {
+	prepDiff := ""
+	result := &pkgDiff{
+		Package:  "foo",
+		Upstream: []string{"foo-1.0.tar.gz"},
+		Srpm:     "foo-1.0-1.src.rpm",
+		SpecDiff: "--- /dev/null\n+++ eext/foo.spec\n@@ -0,0 +1 @@\n+Name: foo\n",
+		Patches: diffChanges{
+			Added:   []string{"new.patch"},
+			Removed: []string{},
+			Changed: []string{"fix-build.patch"},
+		},
+		Sources:  diffChanges{Added: []string{}, Removed: []string{}, Changed: []string{}},
+		PrepDiff: &prepDiff,
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeDiff(&out, result, "text"))
+	require.Equal(t, `Package: foo
+Upstream: foo-1.0.tar.gz
+SRPM: foo-1.0-1.src.rpm
+
+Spec file:
+--- /dev/null
++++ eext/foo.spec
+@@ -0,0 +1 @@
++Name: foo
+
+Patches:
+A new.patch
+M fix-build.patch
+
+Sources:
+No changes
+
+Source tree after %prep:
+No changes
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, writeDiff(&out, result, "json"))
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Equal(t, result.SpecDiff, decoded["spec-diff"])
+	require.Equal(t, "", decoded["prep-diff"])
+	require.Equal(t, []any{"new.patch"}, decoded["patches"].(map[string]any)["added"])
+	require.Equal(t, []any{}, decoded["sources"].(map[string]any)["changed"])
+
+	// The source tree diff is left out if %prep wasn't run
+	result.PrepDiff = nil
+	out.Reset()
+	require.NoError(t, writeDiff(&out, result, "json"))
+	require.NotContains(t, out.String(), "prep-diff")
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.arista.io/eos/tools/eext/rpmfile"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+const (
+	modeTypeMask    = 0170000
+	modeTypeRegular = 0100000
+	// setuid, setgid and sticky bits
+	modeSpecialBits = 07000
+)
+
+// srpmPayloadFileName validates the name of a file in an SRPM payload,
+// which has to be a plain file name, and returns it.
+func srpmPayloadFileName(name string) (string, error) {
+	fileName := strings.TrimPrefix(name, "./")
+	if fileName == "" || fileName == "." || fileName == ".." ||
+		strings.Contains(fileName, "/") {
+		return "", fmt.Errorf("bad file name '%s'", name)
+	}
+	return fileName, nil
+}
+
+// checkSrpmPayloadFileMode checks that the file is a regular file
+// without setuid/setgid/sticky bits.
+func checkSrpmPayloadFileMode(file *rpmfile.PayloadFile) error {
+	if file.Mode&modeTypeMask != modeTypeRegular {
+		return fmt.Errorf("%s isn't a regular file, mode %o", file.Name, file.Mode)
+	}
+	if file.Mode&modeSpecialBits != 0 {
+		return fmt.Errorf("%s has bad mode %o", file.Name, file.Mode)
+	}
+	return nil
+}
+
+// extractSrpmFile writes the current file in the payload to destPath,
+// and checks its size and digest against the SRPM header.
+func extractSrpmFile(srpm *rpmfile.Package, payload *rpmfile.PayloadReader,
+	file *rpmfile.PayloadFile, headerFile rpmfile.File, destPath string) error {
+	if uint64(file.Size) != headerFile.Size {
+		return fmt.Errorf("%s size %d doesn't match %d in header",
+			file.Name, file.Size, headerFile.Size)
+	}
+	digest, err := srpm.NewFileDigest()
+	if err != nil {
+		return err
+	}
+
+	// O_EXCL so that a file can't be extracted twice,
+	// or through a symlink.
+	destFile, err := os.OpenFile(destPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY,
+		os.FileMode(file.Mode&0777))
+	if err != nil {
+		return err
+	}
+	defer destFile.Close()
+	if _, err := io.Copy(io.MultiWriter(destFile, digest), payload); err != nil {
+		return fmt.Errorf("error extracting %s: %s", file.Name, err)
+	}
+	if headerFile.Digest != "" {
+		if fileDigest := hex.EncodeToString(digest.Sum(nil)); fileDigest != headerFile.Digest {
+			return fmt.Errorf("%s digest %s doesn't match %s in header",
+				file.Name, fileDigest, headerFile.Digest)
+		}
+	}
+	return destFile.Close()
+}
+
+// srpmSpecFiles returns the names of the spec files among the files in the
+// header of srpm. Old SRPMs without file flags are recognized by the suffix.
+func srpmSpecFiles(srpm *rpmfile.Package, headerFiles []rpmfile.File) map[string]bool {
+	fileFlags := srpm.Header.Uint64Array(rpmfile.TagFileFlags)
+	isSpecFile := make(map[string]bool)
+	for i, headerFile := range headerFiles {
+		fileName := strings.TrimPrefix(headerFile.Path, "/")
+		if (i < len(fileFlags) && fileFlags[i]&rpmfile.FileFlagSpecFile != 0) ||
+			(len(fileFlags) == 0 && strings.HasSuffix(fileName, ".spec")) {
+			isSpecFile[fileName] = true
+		}
+	}
+	return isSpecFile
+}
+
+// listSrpmFiles returns the spec file and the sources in the header of the
+// SRPM at srpmPath, which extractSrpm extracts to SPECS and SOURCES.
+func listSrpmFiles(srpmPath string, errPrefix util.ErrPrefix) (string, []string, error) {
+	srpmFile, err := os.Open(srpmPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("%sError '%s' opening %s", errPrefix, err, srpmPath)
+	}
+	defer srpmFile.Close()
+	srpm, err := rpmfile.Read(bufio.NewReader(srpmFile))
+	if err != nil {
+		return "", nil, fmt.Errorf("%sInvalid SRPM %s: %s", errPrefix, srpmPath, err)
+	}
+	if !srpm.IsSource() {
+		return "", nil, fmt.Errorf("%s%s isn't an SRPM", errPrefix, srpmPath)
+	}
+	headerFiles, err := srpm.Files()
+	if err != nil {
+		return "", nil, fmt.Errorf("%sInvalid SRPM %s: %s", errPrefix, srpmPath, err)
+	}
+
+	isSpecFile := srpmSpecFiles(srpm, headerFiles)
+	var specFiles, sources []string
+	for _, headerFile := range headerFiles {
+		fileName, err := srpmPayloadFileName(strings.TrimPrefix(headerFile.Path, "/"))
+		if err != nil {
+			return "", nil, fmt.Errorf("%sInvalid SRPM %s: %s", errPrefix, srpmPath, err)
+		}
+		if isSpecFile[fileName] {
+			specFiles = append(specFiles, fileName)
+		} else {
+			sources = append(sources, fileName)
+		}
+	}
+	if len(specFiles) != 1 {
+		return "", nil, fmt.Errorf("%sInvalid SRPM %s: expected one spec file, found %d",
+			errPrefix, srpmPath, len(specFiles))
+	}
+	return specFiles[0], sources, nil
+}
+
+// extractSrpm extracts the spec file in the SRPM at srpmPath to
+// rpmbuildDir/SPECS and the sources to rpmbuildDir/SOURCES, like rpm -i.
+// The files in the payload have to match the file list in the header.
+func extractSrpm(srpmPath string, rpmbuildDir string, errPrefix util.ErrPrefix) error {
+	specsDir := filepath.Join(rpmbuildDir, "SPECS")
+	sourcesDir := filepath.Join(rpmbuildDir, "SOURCES")
+	for _, dir := range []string{specsDir, sourcesDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("%sError '%s' creating %s", errPrefix, err, dir)
+		}
+	}
+
+	srpmFile, err := os.Open(srpmPath)
+	if err != nil {
+		return fmt.Errorf("%sError '%s' opening %s", errPrefix, err, srpmPath)
+	}
+	defer srpmFile.Close()
+	reader := bufio.NewReader(srpmFile)
+	srpm, err := rpmfile.Read(reader)
+	if err != nil {
+		return fmt.Errorf("%sInvalid SRPM %s: %s", errPrefix, srpmPath, err)
+	}
+	if !srpm.IsSource() {
+		return fmt.Errorf("%s%s isn't an SRPM", errPrefix, srpmPath)
+	}
+
+	headerFiles, err := srpm.Files()
+	if err != nil {
+		return fmt.Errorf("%sInvalid SRPM %s: %s", errPrefix, srpmPath, err)
+	}
+	pending := make(map[string]rpmfile.File)
+	for _, headerFile := range headerFiles {
+		pending[strings.TrimPrefix(headerFile.Path, "/")] = headerFile
+	}
+	isSpecFile := srpmSpecFiles(srpm, headerFiles)
+
+	payload, err := srpm.NewPayloadReader(reader)
+	if err != nil {
+		return fmt.Errorf("%sInvalid SRPM %s: %s", errPrefix, srpmPath, err)
+	}
+	defer payload.Close()
+
+	numSpecFiles := 0
+	for {
+		file, err := payload.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%sInvalid SRPM %s: %s", errPrefix, srpmPath, err)
+		}
+
+		fileName, err := srpmPayloadFileName(file.Name)
+		if err != nil {
+			return fmt.Errorf("%sInvalid SRPM %s: %s", errPrefix, srpmPath, err)
+		}
+		headerFile, found := pending[fileName]
+		if !found {
+			return fmt.Errorf("%sInvalid SRPM %s: %s isn't in the header or is duplicated",
+				errPrefix, srpmPath, file.Name)
+		}
+		delete(pending, fileName)
+		if err := checkSrpmPayloadFileMode(file); err != nil {
+			return fmt.Errorf("%sInvalid SRPM %s: %s", errPrefix, srpmPath, err)
+		}
+
+		destDir := sourcesDir
+		if isSpecFile[fileName] {
+			destDir = specsDir
+			numSpecFiles++
+		}
+		if err := extractSrpmFile(srpm, payload, file, headerFile,
+			filepath.Join(destDir, fileName)); err != nil {
+			return fmt.Errorf("%sError extracting SRPM %s: %s", errPrefix, srpmPath, err)
+		}
+	}
+
+	if len(pending) != 0 {
+		var missing []string
+		for fileName := range pending {
+			missing = append(missing, fileName)
+		}
+		return fmt.Errorf("%sInvalid SRPM %s: files %s missing in payload",
+			errPrefix, srpmPath, strings.Join(missing, ", "))
+	}
+	if numSpecFiles != 1 {
+		return fmt.Errorf("%sInvalid SRPM %s: expected one spec file, found %d",
+			errPrefix, srpmPath, numSpecFiles)
+	}
+	return nil
+}
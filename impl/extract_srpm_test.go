@@ -0,0 +1,242 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+
+	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/rpmfile"
+)
+
+type testSrpmFile struct {
+	name     string
+	mode     uint32
+	contents string
+	specFile bool
+}
+
+// writeTestSrpm writes an SRPM with the files in its payload.
+// modifyHeader can make the header inconsistent with the payload.
+func writeTestSrpm(t *testing.T, dir string, compressor string,
+	files []testSrpmFile, modifyHeader func(hdr *rpmfile.Header)) string {
+	hdr := rpmfile.NewHeader()
+	hdr.SetString(rpmfile.TagName, "foo")
+	hdr.SetString(rpmfile.TagVersion, "1.0")
+	hdr.SetString(rpmfile.TagRelease, "1")
+	hdr.SetString(rpmfile.TagArch, "x86_64")
+	hdr.SetString(rpmfile.TagPayloadFormat, "cpio")
+	hdr.SetString(rpmfile.TagPayloadCompress, compressor)
+	hdr.SetInt32Array(rpmfile.TagFileDigestAlgo, []uint32{8})
+
+	var baseNames, digests []string
+	var dirIndexes, sizes, flags []uint32
+	var modes []uint16
+	var cpio bytes.Buffer
+	payloadWriter := rpmfile.NewPayloadWriter(&cpio)
+	for _, file := range files {
+		digest := sha256.Sum256([]byte(file.contents))
+		baseNames = append(baseNames, filepath.Base(file.name))
+		digests = append(digests, hex.EncodeToString(digest[:]))
+		dirIndexes = append(dirIndexes, 0)
+		sizes = append(sizes, uint32(len(file.contents)))
+		modes = append(modes, uint16(file.mode))
+		var flag uint32
+		if file.specFile {
+			flag = rpmfile.FileFlagSpecFile
+		}
+		flags = append(flags, flag)
+		require.NoError(t, payloadWriter.WriteFile(
+			rpmfile.PayloadFile{Name: file.name, Mode: file.mode},
+			[]byte(file.contents)))
+	}
+	require.NoError(t, payloadWriter.Close())
+	hdr.SetStringArray(rpmfile.TagDirNames, []string{""})
+	hdr.SetStringArray(rpmfile.TagBaseNames, baseNames)
+	hdr.SetInt32Array(rpmfile.TagDirIndexes, dirIndexes)
+	hdr.SetStringArray(rpmfile.TagFileDigests, digests)
+	hdr.SetInt32Array(rpmfile.TagFileSizes, sizes)
+	hdr.SetInt16Array(rpmfile.TagFileModes, modes)
+	hdr.SetInt32Array(rpmfile.TagFileFlags, flags)
+	if modifyHeader != nil {
+		modifyHeader(hdr)
+	}
+
+	var payload bytes.Buffer
+	var compressWriter io.WriteCloser
+	var err error
+	switch compressor {
+	case "gzip":
+		compressWriter = gzip.NewWriter(&payload)
+	case "xz":
+		compressWriter, err = xz.NewWriter(&payload)
+	case "zstd":
+		compressWriter, err = zstd.NewWriter(&payload)
+	}
+	require.NoError(t, err)
+	_, err = compressWriter.Write(cpio.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, compressWriter.Close())
+
+	srpmPath := filepath.Join(dir, "foo-1.0-1.src.rpm")
+	srpmFile, err := os.Create(srpmPath)
+	require.NoError(t, err)
+	defer srpmFile.Close()
+	require.NoError(t, rpmfile.NewPackage(hdr).Write(srpmFile, payload.Bytes()))
+	return srpmPath
+}
+
+var testSrpmFiles = []testSrpmFile{
+	{name: "foo.spec", mode: 0100644, contents: "Name: foo\n", specFile: true},
+	{name: "foo-1.0.tar.gz", mode: 0100644, contents: "tarball"},
+	{name: "fix-build.patch", mode: 0100600, contents: "patch"},
+}
+
+func TestExtractSrpm(t *testing.T) {
+	for _, compressor := range []string{"gzip", "xz", "zstd"} {
+		t.Logf("Testing %s payload", compressor)
+		dir := t.TempDir()
+		srpmPath := writeTestSrpm(t, dir, compressor, testSrpmFiles, nil)
+		rpmbuildDir := filepath.Join(dir, "rpmbuild")
+		require.NoError(t, extractSrpm(srpmPath, rpmbuildDir, ""))
+
+		for path, contents := range map[string]string{
+			"SPECS/foo.spec":          "Name: foo\n",
+			"SOURCES/foo-1.0.tar.gz":  "tarball",
+			"SOURCES/fix-build.patch": "patch",
+		} {
+			data, err := os.ReadFile(filepath.Join(rpmbuildDir, path))
+			require.NoError(t, err)
+			require.Equal(t, contents, string(data))
+		}
+		info, err := os.Stat(filepath.Join(rpmbuildDir, "SOURCES/fix-build.patch"))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
+
+func TestExtractBadSrpm(t *testing.T) {
+	withFile := func(file testSrpmFile) []testSrpmFile {
+		return append(append([]testSrpmFile{}, testSrpmFiles...), file)
+	}
+	testCases := []struct {
+		desc         string
+		files        []testSrpmFile
+		modifyHeader func(hdr *rpmfile.Header)
+		expectedErr  string
+	}{
+		{
+			desc:        "path traversal",
+			files:       withFile(testSrpmFile{name: "../../evil", mode: 0100644}),
+			expectedErr: "bad file name '../../evil'",
+		},
+		{
+			desc:        "subdir",
+			files:       withFile(testSrpmFile{name: "./sub/file", mode: 0100644}),
+			expectedErr: "bad file name './sub/file'",
+		},
+		{
+			desc:        "symlink",
+			files:       withFile(testSrpmFile{name: "link", mode: 0120777, contents: "/etc/passwd"}),
+			expectedErr: "link isn't a regular file",
+		},
+		{
+			desc:        "setuid",
+			files:       withFile(testSrpmFile{name: "setuid", mode: 0104755}),
+			expectedErr: "setuid has bad mode",
+		},
+		{
+			desc:  "digest mismatch",
+			files: testSrpmFiles,
+			modifyHeader: func(hdr *rpmfile.Header) {
+				digests := hdr.StringArray(rpmfile.TagFileDigests)
+				digests[1] = digests[0]
+				hdr.SetStringArray(rpmfile.TagFileDigests, digests)
+			},
+			expectedErr: "foo-1.0.tar.gz digest",
+		},
+		{
+			desc:  "size mismatch",
+			files: testSrpmFiles,
+			modifyHeader: func(hdr *rpmfile.Header) {
+				hdr.SetInt32Array(rpmfile.TagFileSizes, []uint32{10, 8, 5})
+			},
+			expectedErr: "foo-1.0.tar.gz size 7 doesn't match 8 in header",
+		},
+		{
+			desc:  "file not in header",
+			files: testSrpmFiles,
+			modifyHeader: func(hdr *rpmfile.Header) {
+				hdr.SetStringArray(rpmfile.TagBaseNames,
+					[]string{"foo.spec", "foo-1.0.tar.gz", "other.patch"})
+			},
+			expectedErr: "fix-build.patch isn't in the header",
+		},
+		{
+			desc:        "no spec file",
+			files:       testSrpmFiles[1:],
+			expectedErr: "expected one spec file, found 0",
+		},
+		{
+			desc:  "binary rpm",
+			files: testSrpmFiles,
+			modifyHeader: func(hdr *rpmfile.Header) {
+				hdr.SetString(rpmfile.TagSourceRPM, "foo-1.0-1.src.rpm")
+			},
+			expectedErr: "isn't an SRPM",
+		},
+	}
+	for _, tc := range testCases {
+		t.Logf("Testing %s", tc.desc)
+		dir := t.TempDir()
+		srpmPath := writeTestSrpm(t, dir, "xz", tc.files, tc.modifyHeader)
+		rpmbuildDir := filepath.Join(dir, "a", "rpmbuild")
+		err := extractSrpm(srpmPath, rpmbuildDir, "")
+		require.ErrorContains(t, err, tc.expectedErr)
+		require.NoFileExists(t, filepath.Join(dir, "a", "evil"))
+	}
+
+	t.Log("Testing missing file in payload")
+	dir := t.TempDir()
+	srpmPath := writeTestSrpm(t, dir, "gzip", testSrpmFiles, func(hdr *rpmfile.Header) {
+		hdr.SetStringArray(rpmfile.TagBaseNames,
+			[]string{"foo.spec", "foo-1.0.tar.gz", "fix-build.patch", "missing.patch"})
+		hdr.SetInt32Array(rpmfile.TagDirIndexes, []uint32{0, 0, 0, 0})
+	})
+	err := extractSrpm(srpmPath, filepath.Join(dir, "rpmbuild"), "")
+	require.ErrorContains(t, err, "files missing.patch missing in payload")
+}
+
+func TestSetupRpmbuildTreeSrpmDryRun(t *testing.T) {
+	viper.Set("WorkingDir", t.TempDir())
+	defer viper.Reset()
+	downloadDir := getDownloadDir("foo")
+	require.NoError(t, os.MkdirAll(downloadDir, 0755))
+	srpmPath := writeTestSrpm(t, downloadDir, "gzip", testSrpmFiles, nil)
+
+	bldr := &srpmBuilder{
+		pkgSpec:     &manifest.Package{Name: "foo", Type: "srpm"},
+		upstreamSrc: []upstreamSrcSpec{{sourceFile: filepath.Base(srpmPath)}},
+		executor:    &executor.DryRunExecutor{},
+	}
+	require.NoError(t, bldr.setupRpmbuildTreeSrpm())
+	require.NoDirExists(t, getRpmbuildDir("foo"))
+
+	bldr.executor = &executor.OsExecutor{}
+	require.NoError(t, bldr.setupRpmbuildTreeSrpm())
+	require.FileExists(t, filepath.Join(getRpmbuildDir("foo"), "SPECS/foo.spec"))
+}
@@ -0,0 +1,518 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"code.arista.io/eos/tools/eext/manifest"
+)
+
+// fetchProxyCaFilename is the CA certificate of the fetch proxy,
+// in the dir bind mounted to fetchProxyChrootDir in the chroot.
+const fetchProxyCaFilename = "ca.pem"
+
+// fetchProxySocketFilename is the unix socket the fetch proxy listens on.
+const fetchProxySocketFilename = "proxy.sock"
+
+// fetchProxyForwarderFilename is the copy of eext which forwards
+// fetchProxyChrootAddr to the socket in the chroot.
+const fetchProxyForwarderFilename = "eext"
+
+// fetchProxyRpmbuildFilename wraps rpmbuild in the forwarder,
+// it is the rpmbuild command of the mock configuration.
+const fetchProxyRpmbuildFilename = "rpmbuild"
+
+const fetchProxyChrootDir = "/etc/pki/eext-fetch-proxy"
+
+// fetchProxyChrootAddr is where the build reaches the fetch proxy.
+// The build runs in the private network namespace of the chroot, which
+// only has the loopback interface.
+const fetchProxyChrootAddr = "127.0.0.1:3128"
+
+// The sun_path of unix socket addresses is 108 bytes, with the terminating NUL.
+const maxUnixSocketPathLen = 107
+
+// Validity of the certificates generated by the fetch proxy,
+// they only need to outlive the build.
+const fetchProxyCertValidity = 7 * 24 * time.Hour
+
+// Headers which only apply to a single hop, these aren't forwarded.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// fetchRecord records a response fetched by the build through the fetch proxy.
+// Sha256 and Size are of the response body.
+type fetchRecord struct {
+	Method string `yaml:"method"`
+	URL    string `yaml:"url"`
+	Status int    `yaml:"status"`
+	Size   int64  `yaml:"size"`
+	Sha256 string `yaml:"sha256"`
+}
+
+// fetchProxy is a local HTTP(S) proxy which forwards the requests allowed by
+// the network policy of a package and records what was fetched.
+// HTTPS connections are intercepted with certificates signed by a CA
+// generated for the proxy, so that the policy applies to the full URLs and the
+// responses can be recorded. The build trusts the CA through the environment
+// set up by env.
+type fetchProxy struct {
+	policy *manifest.NetworkPolicy
+	// dir has the socket of the proxy and the files needed in the chroot,
+	// it is bind mounted to fetchProxyChrootDir.
+	dir string
+	// transport forwards the allowed requests
+	transport http.RoundTripper
+	listener  net.Listener
+	server    *http.Server
+
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caPEM  []byte
+
+	mutex     sync.Mutex
+	leafCerts map[string]*tls.Certificate
+	fetched   []fetchRecord
+	blocked   []string
+}
+
+// newCertificate creates a certificate from template signed by the parent,
+// or self-signed if parent is nil.
+func newCertificate(template *x509.Certificate, parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(fetchProxyCertValidity)
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// newFetchProxy creates a fetch proxy for the policy, listening on a unix
+// socket in a new temporary dir. The dir is kept short, unix socket paths
+// are limited in length. Call start to serve requests and close to remove
+// the dir.
+func newFetchProxy(policy *manifest.NetworkPolicy) (*fetchProxy, error) {
+	caCert, caKey, err := newCertificate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "eext fetch proxy CA"},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error generating CA: %s", err)
+	}
+
+	dir, err := os.MkdirTemp("", "eext-fetch-proxy-")
+	if err != nil {
+		return nil, err
+	}
+	listener, err := listenFetchProxySocket(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Responses are recorded as is
+	transport.DisableCompression = true
+	proxy := &fetchProxy{
+		policy:    policy,
+		dir:       dir,
+		transport: transport,
+		listener:  listener,
+		caCert:    caCert,
+		caKey:     caKey,
+		caPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}),
+		leafCerts: make(map[string]*tls.Certificate),
+	}
+	proxy.server = &http.Server{Handler: proxy}
+	return proxy, nil
+}
+
+// listenFetchProxySocket listens on the socket of the fetch proxy in dir.
+// The build doesn't run as the same user as eext, the dir and the socket
+// are made accessible to all users.
+func listenFetchProxySocket(dir string) (net.Listener, error) {
+	socketPath := filepath.Join(dir, fetchProxySocketFilename)
+	if len(socketPath) > maxUnixSocketPathLen {
+		return nil, fmt.Errorf("socket path %s is too long, use a shorter TMPDIR", socketPath)
+	}
+	if err := os.Chmod(dir, 0755); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socketPath, 0777); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+func (proxy *fetchProxy) start() {
+	go proxy.server.Serve(proxy.listener)
+}
+
+func (proxy *fetchProxy) close() error {
+	err := proxy.server.Close()
+	if removeErr := os.RemoveAll(proxy.dir); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+func (proxy *fetchProxy) socketPath() string {
+	return filepath.Join(proxy.dir, fetchProxySocketFilename)
+}
+
+// writeCa writes the CA certificate to the dir of the proxy.
+func (proxy *fetchProxy) writeCa() error {
+	return os.WriteFile(filepath.Join(proxy.dir, fetchProxyCaFilename), proxy.caPEM, 0644)
+}
+
+// writeForwarder copies eext to the dir of the proxy, along with the
+// rpmbuild wrapper which runs rpmbuild with fetchProxyChrootAddr forwarded
+// to the socket of the proxy, see FetchProxyForward.
+func (proxy *fetchProxy) writeForwarder() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	forwarderPath := filepath.Join(proxy.dir, fetchProxyForwarderFilename)
+	if err := copyFile(executable, forwarderPath, 0755); err != nil {
+		return err
+	}
+	wrapper := fmt.Sprintf("#!/bin/sh\nexec %s fetch-proxy-forward %s %s /usr/bin/rpmbuild \"$@\"\n",
+		filepath.Join(fetchProxyChrootDir, fetchProxyForwarderFilename),
+		filepath.Join(fetchProxyChrootDir, fetchProxySocketFilename),
+		fetchProxyChrootAddr)
+	return os.WriteFile(filepath.Join(proxy.dir, fetchProxyRpmbuildFilename), []byte(wrapper), 0755)
+}
+
+// copyFile copies src to dst, created with perm.
+func copyFile(src string, dst string, perm os.FileMode) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		return err
+	}
+	return dstFile.Close()
+}
+
+// env returns the environment which points the tools in the chroot
+// to the proxy and makes them trust its CA.
+// The chroot has no network besides loopback, the tools which don't
+// honor the proxy environment can't reach anything.
+func (proxy *fetchProxy) env() map[string]string {
+	proxyURL := "http://" + fetchProxyChrootAddr
+	caPath := filepath.Join(fetchProxyChrootDir, fetchProxyCaFilename)
+	return map[string]string{
+		"http_proxy":          proxyURL,
+		"https_proxy":         proxyURL,
+		"HTTP_PROXY":          proxyURL,
+		"HTTPS_PROXY":         proxyURL,
+		"SSL_CERT_FILE":       caPath,
+		"CURL_CA_BUNDLE":      caPath,
+		"REQUESTS_CA_BUNDLE":  caPath,
+		"PIP_CERT":            caPath,
+		"GIT_SSL_CAINFO":      caPath,
+		"CARGO_HTTP_CAINFO":   caPath,
+		"NODE_EXTRA_CA_CERTS": caPath,
+	}
+}
+
+// leafCert returns the certificate the proxy presents for host.
+func (proxy *fetchProxy) leafCert(host string) (*tls.Certificate, error) {
+	proxy.mutex.Lock()
+	defer proxy.mutex.Unlock()
+	if cert, found := proxy.leafCerts[host]; found {
+		return cert, nil
+	}
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: host},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	cert, key, err := newCertificate(template, proxy.caCert, proxy.caKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsCert := &tls.Certificate{
+		Certificate: [][]byte{cert.Raw},
+		PrivateKey:  key,
+		Leaf:        cert,
+	}
+	proxy.leafCerts[host] = tlsCert
+	return tlsCert, nil
+}
+
+func (proxy *fetchProxy) block(w http.ResponseWriter, target string) {
+	log.Printf("fetch proxy: blocked %s, not allowed by the network policy", target)
+	proxy.mutex.Lock()
+	proxy.blocked = append(proxy.blocked, target)
+	proxy.mutex.Unlock()
+	http.Error(w, fmt.Sprintf("eext: %s is not allowed by the network policy of the package", target),
+		http.StatusForbidden)
+}
+
+func (proxy *fetchProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		proxy.serveConnect(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "eext: not a proxy request", http.StatusBadRequest)
+		return
+	}
+	proxy.forward(w, r, r.URL)
+}
+
+// serveConnect intercepts the HTTPS connection to an allowed host,
+// and forwards the requests in it.
+func (proxy *fetchProxy) serveConnect(w http.ResponseWriter, r *http.Request) {
+	host, port, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("eext: bad host %s", r.Host), http.StatusBadRequest)
+		return
+	}
+	if !proxy.policy.AllowsHTTPSHost(host, port) {
+		proxy.block(w, "https://"+r.Host)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "eext: can't intercept connection", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		conn.Close()
+		return
+	}
+
+	urlHost := r.Host
+	if port == "443" {
+		urlHost = host
+	}
+	tlsConn := tls.Server(conn, &tls.Config{
+		NextProtos: []string{"http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return proxy.leafCert(host)
+		},
+	})
+	// The URL is from the CONNECT target, not the Host header of the requests
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxy.forward(w, r, &url.URL{
+				Scheme:   "https",
+				Host:     urlHost,
+				Path:     r.URL.Path,
+				RawPath:  r.URL.RawPath,
+				RawQuery: r.URL.RawQuery,
+			})
+		}),
+	}
+	server.Serve(newSingleConnListener(tlsConn))
+}
+
+// forward forwards the request for target if the policy allows it, and
+// records the response.
+// The URL checked against the policy is the one forwarded, the path isn't
+// cleaned by either.
+func (proxy *fetchProxy) forward(w http.ResponseWriter, r *http.Request, target *url.URL) {
+	target = &url.URL{
+		Scheme:   target.Scheme,
+		Host:     target.Host,
+		Path:     target.Path,
+		RawPath:  target.RawPath,
+		RawQuery: target.RawQuery,
+	}
+	if !proxy.policy.AllowsURL(target) {
+		proxy.block(w, target.String())
+		return
+	}
+
+	outReq := r.Clone(r.Context())
+	outReq.URL = target
+	outReq.Host = ""
+	outReq.RequestURI = ""
+	for _, header := range hopByHopHeaders {
+		outReq.Header.Del(header)
+	}
+	resp, err := proxy.transport.RoundTrip(outReq)
+	if err != nil {
+		log.Printf("fetch proxy: error fetching %s: %s", target, err)
+		http.Error(w, fmt.Sprintf("eext: error fetching %s", target), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for header, values := range resp.Header {
+		w.Header()[header] = values
+	}
+	for _, header := range hopByHopHeaders {
+		w.Header().Del(header)
+	}
+	w.WriteHeader(resp.StatusCode)
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(w, hash), resp.Body)
+	if err != nil {
+		log.Printf("fetch proxy: error fetching %s: %s", target, err)
+		return
+	}
+
+	proxy.mutex.Lock()
+	defer proxy.mutex.Unlock()
+	proxy.fetched = append(proxy.fetched, fetchRecord{
+		Method: r.Method,
+		URL:    target.String(),
+		Status: resp.StatusCode,
+		Size:   size,
+		Sha256: hex.EncodeToString(hash.Sum(nil)),
+	})
+}
+
+// records returns what was fetched and blocked so far, sorted
+// and without duplicates.
+func (proxy *fetchProxy) records() ([]fetchRecord, []string) {
+	proxy.mutex.Lock()
+	defer proxy.mutex.Unlock()
+
+	var fetched []fetchRecord
+	seen := make(map[fetchRecord]bool)
+	for _, record := range proxy.fetched {
+		if !seen[record] {
+			seen[record] = true
+			fetched = append(fetched, record)
+		}
+	}
+	sort.Slice(fetched, func(i, j int) bool {
+		if fetched[i].URL != fetched[j].URL {
+			return fetched[i].URL < fetched[j].URL
+		}
+		return fetched[i].Method < fetched[j].Method
+	})
+
+	var blocked []string
+	seenBlocked := make(map[string]bool)
+	for _, target := range proxy.blocked {
+		if !seenBlocked[target] {
+			seenBlocked[target] = true
+			blocked = append(blocked, target)
+		}
+	}
+	sort.Strings(blocked)
+	return fetched, blocked
+}
+
+// singleConnListener hands out a single connection to http.Server.Serve,
+// and makes it return once the connection is closed.
+type singleConnListener struct {
+	conn   net.Conn
+	once   sync.Once
+	closed chan struct{}
+}
+
+type notifyingConn struct {
+	net.Conn
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (conn *notifyingConn) Close() error {
+	conn.once.Do(func() { close(conn.closed) })
+	return conn.Conn.Close()
+}
+
+func newSingleConnListener(conn net.Conn) *singleConnListener {
+	return &singleConnListener{conn: conn, closed: make(chan struct{})}
+}
+
+func (listener *singleConnListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	listener.once.Do(func() {
+		conn = &notifyingConn{Conn: listener.conn, closed: listener.closed}
+	})
+	if conn != nil {
+		return conn, nil
+	}
+	<-listener.closed
+	return nil, io.EOF
+}
+
+func (listener *singleConnListener) Close() error {
+	return nil
+}
+
+func (listener *singleConnListener) Addr() net.Addr {
+	return listener.conn.LocalAddr()
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// forwardConn pipes conn to the unix socket at socketPath, until either
+// side is done.
+func forwardConn(conn net.Conn, socketPath string) {
+	defer conn.Close()
+	upstream, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(upstream, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// forwardConns forwards the connections accepted by listener to the unix
+// socket at socketPath, until the listener is closed.
+func forwardConns(listener net.Listener, socketPath string) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go forwardConn(conn, socketPath)
+	}
+}
+
+// FetchProxyForward runs command with connections to listenAddr forwarded
+// to the fetch proxy listening on the unix socket at socketPath, and returns
+// the exit code of the command.
+// It wraps rpmbuild in the chroot of builds with a network policy: the chroot
+// only has the loopback interface, the socket of the proxy on the host is
+// bind mounted in it.
+func FetchProxyForward(socketPath string, listenAddr string, command []string) (int, error) {
+	errPrefix := util.ErrPrefix("impl.FetchProxyForward: ")
+	if len(command) == 0 {
+		return 1, fmt.Errorf("%sNo command specified", errPrefix)
+	}
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return 1, fmt.Errorf("%sError '%s' listening on %s", errPrefix, err, listenAddr)
+	}
+	defer listener.Close()
+	go forwardConns(listener, socketPath)
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+			return exitErr.ExitCode(), nil
+		}
+		return 1, fmt.Errorf("%sError '%s' running %s", errPrefix, err, command[0])
+	}
+	return 0, nil
+}
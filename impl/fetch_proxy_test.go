@@ -0,0 +1,145 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/manifest"
+)
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFetchProxy(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "contents of "+r.URL.Path)
+	})
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+	httpsServer := httptest.NewTLSServer(handler)
+	defer httpsServer.Close()
+	httpURL, _ := url.Parse(httpServer.URL)
+	httpsURL, _ := url.Parse(httpsServer.URL)
+
+	policy := &manifest.NetworkPolicy{Allow: []string{
+		httpServer.URL + "/allowed/",
+		httpsServer.URL + "/allowed/",
+	}}
+	proxy, err := newFetchProxy(policy)
+	require.NoError(t, err)
+	// The proxy must trust the test server, like the build trusts the proxy
+	upstreamRoots := x509.NewCertPool()
+	upstreamRoots.AddCert(httpsServer.Certificate())
+	proxy.transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: upstreamRoots}}
+	proxy.start()
+	defer proxy.close()
+
+	require.NoError(t, proxy.writeCa())
+	caPEM, err := os.ReadFile(filepath.Join(proxy.dir, fetchProxyCaFilename))
+	require.NoError(t, err)
+	proxyRoots := x509.NewCertPool()
+	require.True(t, proxyRoots.AppendCertsFromPEM(caPEM))
+	// The client reaches the proxy through the forwarder, like the build
+	forwarder, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer forwarder.Close()
+	go forwardConns(forwarder, proxy.socketPath())
+	proxyURL, _ := url.Parse("http://" + forwarder.Addr().String())
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: proxyRoots},
+	}}
+
+	fetch := func(rawURL string) (int, string) {
+		resp, err := client.Get(rawURL)
+		require.NoError(t, err, rawURL)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	for _, server := range []string{httpServer.URL, httpsServer.URL, httpsServer.URL} {
+		path := "/allowed/foo.tar.gz"
+		status, body := fetch(server + path)
+		require.Equal(t, http.StatusOK, status, server)
+		require.Equal(t, "contents of "+path, body)
+	}
+	for _, rawURL := range []string{
+		httpServer.URL + "/other",
+		httpsServer.URL + "/other",
+		// The server would resolve these outside of the allowed prefix
+		httpServer.URL + "/allowed/../other",
+		httpsServer.URL + "/allowed/%2e%2e/other",
+	} {
+		status, _ := fetch(rawURL)
+		require.Equal(t, http.StatusForbidden, status, rawURL)
+	}
+	// Connections to hosts which aren't allowed at all are refused
+	_, err = client.Get("https://" + httpURL.Host + "/allowed/foo")
+	require.Error(t, err)
+
+	fetched, blocked := proxy.records()
+	require.Equal(t, []fetchRecord{
+		{
+			Method: "GET",
+			URL:    httpServer.URL + "/allowed/foo.tar.gz",
+			Status: http.StatusOK,
+			Size:   int64(len("contents of /allowed/foo.tar.gz")),
+			Sha256: sha256Hex("contents of /allowed/foo.tar.gz"),
+		},
+		{
+			Method: "GET",
+			URL:    "https://" + httpsURL.Host + "/allowed/foo.tar.gz",
+			Status: http.StatusOK,
+			Size:   int64(len("contents of /allowed/foo.tar.gz")),
+			Sha256: sha256Hex("contents of /allowed/foo.tar.gz"),
+		},
+	}, fetched)
+	require.ElementsMatch(t, []string{
+		httpServer.URL + "/other",
+		"https://" + httpsURL.Host + "/other",
+		httpServer.URL + "/allowed/../other",
+		"https://" + httpsURL.Host + "/allowed/%2e%2e/other",
+		"https://" + httpURL.Host,
+	}, blocked)
+}
+
+func TestFetchProxyForward(t *testing.T) {
+	proxy, err := newFetchProxy(&manifest.NetworkPolicy{})
+	require.NoError(t, err)
+	proxy.start()
+	dir := proxy.dir
+	require.NoError(t, proxy.close())
+	// The dir of the proxy is removed with it
+	require.NoDirExists(t, dir)
+
+	exitCode, err := FetchProxyForward(proxy.socketPath(), "127.0.0.1:0",
+		[]string{"sh", "-c", "exit 3"})
+	require.NoError(t, err)
+	require.Equal(t, 3, exitCode)
+
+	exitCode, err = FetchProxyForward(proxy.socketPath(), "127.0.0.1:0", []string{"true"})
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+
+	_, err = FetchProxyForward(proxy.socketPath(), "127.0.0.1:0", []string{"/nonexistent"})
+	require.ErrorContains(t, err, "Error")
+}
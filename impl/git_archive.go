@@ -0,0 +1,208 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Compression level of generated tarballs, same as gzip's default.
+// The level is pinned, since it changes the compressed output.
+const tarballGzipLevel = 6
+
+// Owner of the files in generated tarballs
+const tarballOwner = "root"
+
+// gitCommitTime returns the committer time of the commit the revision
+// points to in the repo in gitDir
+func gitCommitTime(gitDir string, revision string) (time.Time, error) {
+	output, err := runGit(gitDir, "show", "-s", "--format=%ct", revision+"^{commit}")
+	if err != nil {
+		return time.Time{}, err
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad commit time '%s' of %s", output, revision)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// normalizeTarHeader returns the header for the entry in the generated
+// tarball, which only depends on the git tree entry and modTime, and not on
+// the git version or config used to archive it.
+func normalizeTarHeader(header *tar.Header, modTime time.Time) (*tar.Header, error) {
+	normalized := &tar.Header{
+		Typeflag: header.Typeflag,
+		Name:     header.Name,
+		ModTime:  modTime,
+		Uname:    tarballOwner,
+		Gname:    tarballOwner,
+	}
+	switch header.Typeflag {
+	case tar.TypeDir:
+		normalized.Mode = 0755
+	case tar.TypeReg:
+		normalized.Size = header.Size
+		if header.Mode&0111 != 0 {
+			normalized.Mode = 0755
+		} else {
+			normalized.Mode = 0644
+		}
+	case tar.TypeSymlink:
+		normalized.Mode = 0777
+		normalized.Linkname = header.Linkname
+	case tar.TypeXGlobalHeader:
+		// git archive records the commit ID here
+		normalized = &tar.Header{
+			Typeflag:   header.Typeflag,
+			Name:       header.Name,
+			PAXRecords: map[string]string{"comment": header.PAXRecords["comment"]},
+		}
+	default:
+		return nil, fmt.Errorf("unexpected type %c of %s", header.Typeflag, header.Name)
+	}
+	return normalized, nil
+}
+
+// copyTarEntries copies the entries of the tarball in tarReader to tarWriter,
+// with normalized headers. written tracks the entries in the tarball, the
+// directories of submodules are in both the parent's and the submodule's
+// archives.
+func copyTarEntries(tarWriter *tar.Writer, tarReader *tar.Reader, modTime time.Time,
+	written map[string]bool) error {
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			// Only the top level repo's commit ID is kept
+			if len(written) != 0 {
+				continue
+			}
+		} else if written[header.Name] {
+			if header.Typeflag == tar.TypeDir {
+				continue
+			}
+			return fmt.Errorf("duplicate file %s", header.Name)
+		}
+		written[header.Name] = true
+		normalized, err := normalizeTarHeader(header, modTime)
+		if err != nil {
+			return err
+		}
+		if err := tarWriter.WriteHeader(normalized); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tarWriter, tarReader); err != nil {
+			return err
+		}
+	}
+}
+
+// appendGitArchive appends the files of the revision in gitDir to the
+// tarball, under prefix. Files are in the order of the git tree, which
+// is fixed for the revision.
+// git archive runs without the system and global git config, and with
+// the end of line conversion of files pinned, as those change the
+// archived files, Eg: core.autocrlf.
+func appendGitArchive(tarWriter *tar.Writer, gitDir string, revision string, prefix string,
+	modTime time.Time, written map[string]bool) error {
+	cmd := exec.Command("git", "-c", "core.autocrlf=false", "-c", "core.eol=lf",
+		"archive", "--format=tar", "--prefix", prefix, revision)
+	cmd.Dir = gitDir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL=/dev/null")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := copyTarEntries(tarWriter, tar.NewReader(stdout), modTime, written); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("error in git archive of %s: %s", revision, err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git archive of %s failed: %s\n%s", revision, err, stderr.String())
+	}
+	return nil
+}
+
+// writeTarball writes a tarball with the entries written by writeEntries
+// to archivePath. It's gzip compressed with a pinned level unless
+// archivePath ends with .tar, and the gzip header has no file name or mtime.
+func writeTarball(archivePath string, writeEntries func(*tar.Writer) error) error {
+	archiveFile, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archiveFile.Close()
+
+	var writer io.Writer = archiveFile
+	var gzipWriter *gzip.Writer
+	if !strings.HasSuffix(archivePath, ".tar") {
+		gzipWriter, err = gzip.NewWriterLevel(archiveFile, tarballGzipLevel)
+		if err != nil {
+			return err
+		}
+		writer = gzipWriter
+	}
+	tarWriter := tar.NewWriter(writer)
+	if err := writeEntries(tarWriter); err != nil {
+		return err
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			return err
+		}
+	}
+	return archiveFile.Close()
+}
+
+// writeGitTarball writes the tarball of the revision in gitDir, with the
+// submodules folded in, to archivePath. The tarball is reproducible: the
+// same revision always gives a byte-identical tarball, whichever git
+// version and user or system git config generates it. All entries have the commit time of the revision
+// as mtime, are owned by root, and have normalized modes.
+func writeGitTarball(archivePath string, archive gitArchiveSpec, gitDir string,
+	revision string, submodules []gitSubmodule) error {
+	modTime, err := gitCommitTime(gitDir, revision)
+	if err != nil {
+		return err
+	}
+	return writeTarball(archivePath, func(tarWriter *tar.Writer) error {
+		written := make(map[string]bool)
+		if err := appendGitArchive(tarWriter, gitDir, revision, archive.Prefix+"/",
+			modTime, written); err != nil {
+			return err
+		}
+		for _, submodule := range submodules {
+			if err := appendGitArchive(tarWriter, submodule.gitDir, submodule.Commit,
+				path.Join(archive.Prefix, submodule.Path)+"/", modTime, written); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+//go:build containerized
+
+package impl
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readTarHeaders returns the headers of the entries in the tarball
+func readTarHeaders(t *testing.T, tarballPath string) map[string]*tar.Header {
+	tarball, err := os.Open(tarballPath)
+	require.NoError(t, err)
+	defer tarball.Close()
+	var reader io.Reader = tarball
+	if filepath.Ext(tarballPath) == ".gz" {
+		reader, err = gzip.NewReader(tarball)
+		require.NoError(t, err)
+	}
+	tarReader := tar.NewReader(reader)
+	headers := make(map[string]*tar.Header)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		headers[header.Name] = header
+	}
+	return headers
+}
+
+func TestWriteGitTarballReproducible(t *testing.T) {
+	repoDir := t.TempDir()
+	commitDate := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	env := append([]string{"GIT_COMMITTER_DATE=" + commitDate.Format(time.RFC3339)},
+		testGitEnv...)
+	runInDir(t, repoDir, env, "git", "init", "-q")
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "src"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "src/main.c"), []byte("main\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "configure"), []byte("#!/bin/sh\n"), 0700))
+	require.NoError(t, os.Symlink("src/main.c", filepath.Join(repoDir, "main.c")))
+	runInDir(t, repoDir, env, "git", "add", ".")
+	runInDir(t, repoDir, env, "git", "commit", "-q", "-m", "commit")
+
+	archive := gitArchiveSpec{Name: "foo-1.0.tar.gz", Prefix: "foo-1.0"}
+	firstPath := filepath.Join(t.TempDir(), archive.Name)
+	require.NoError(t, writeGitTarball(firstPath, archive, repoDir, "HEAD", nil))
+
+	headers := readTarHeaders(t, firstPath)
+	expectedModes := map[string]int64{
+		"foo-1.0/":           0755,
+		"foo-1.0/src/":       0755,
+		"foo-1.0/src/main.c": 0644,
+		"foo-1.0/configure":  0755,
+		"foo-1.0/main.c":     0777,
+	}
+	for name, mode := range expectedModes {
+		header, found := headers[name]
+		require.True(t, found, name)
+		require.Equal(t, mode, header.Mode, name)
+		require.Equal(t, 0, header.Uid, name)
+		require.Equal(t, 0, header.Gid, name)
+		require.Equal(t, "root", header.Uname, name)
+		require.Equal(t, "root", header.Gname, name)
+		require.True(t, commitDate.Equal(header.ModTime), name)
+	}
+	require.Equal(t, "src/main.c", headers["foo-1.0/main.c"].Linkname)
+
+	t.Log("Testing the tarball doesn't depend on git config")
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "tar.umask")
+	t.Setenv("GIT_CONFIG_VALUE_0", "0077")
+	secondPath := filepath.Join(t.TempDir(), archive.Name)
+	require.NoError(t, writeGitTarball(secondPath, archive, repoDir, "HEAD", nil))
+	first, err := os.ReadFile(firstPath)
+	require.NoError(t, err)
+	second, err := os.ReadFile(secondPath)
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	t.Log("Testing the tarball doesn't depend on the global git config")
+	globalConfig := filepath.Join(t.TempDir(), "gitconfig")
+	require.NoError(t, os.WriteFile(globalConfig,
+		[]byte("[core]\n\tautocrlf = true\n\teol = crlf\n"), 0644))
+	t.Setenv("GIT_CONFIG_GLOBAL", globalConfig)
+	thirdPath := filepath.Join(t.TempDir(), archive.Name)
+	require.NoError(t, writeGitTarball(thirdPath, archive, repoDir, "HEAD", nil))
+	third, err := os.ReadFile(thirdPath)
+	require.NoError(t, err)
+	require.Equal(t, first, third)
+
+	t.Log("Testing uncompressed tarball")
+	archive.Name = "foo-1.0.tar"
+	tarPath := filepath.Join(t.TempDir(), archive.Name)
+	require.NoError(t, writeGitTarball(tarPath, archive, repoDir, "HEAD", nil))
+	require.Len(t, readTarHeaders(t, tarPath), len(headers))
+}
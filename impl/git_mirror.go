@@ -0,0 +1,153 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/viper"
+)
+
+// Characters not used in names of mirror dirs
+var mirrorNameUnsafeRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func getGitMirrorsDir() string {
+	return filepath.Join(viper.GetString("WorkingDir"), "git-mirrors")
+}
+
+// getGitMirrorDir returns the dir of the bare mirror of the repo at srcURL.
+// Mirrors are keyed by a hash of the URL, the last part of the URL is
+// only there to make the mirror dirs easy to tell apart.
+func getGitMirrorDir(srcURL string) string {
+	name := strings.TrimSuffix(path.Base(strings.TrimRight(srcURL, "/")), ".git")
+	name = strings.Trim(mirrorNameUnsafeRegex.ReplaceAllString(name, "_"), "._")
+	hash := sha256.Sum256([]byte(srcURL))
+	return filepath.Join(getGitMirrorsDir(), fmt.Sprintf("%s-%x.git", name, hash[:8]))
+}
+
+// lockGitMirror takes an exclusive lock on the mirror, waiting for other
+// eext processes holding it. The returned function releases the lock.
+func lockGitMirror(mirrorDir string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(mirrorDir), 0755); err != nil {
+		return nil, err
+	}
+	lockFile, err := os.OpenFile(mirrorDir+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX); err != nil {
+		lockFile.Close()
+		return nil, fmt.Errorf("error locking %s: %s", lockFile.Name(), err)
+	}
+	// Closing the file releases the lock
+	return func() { lockFile.Close() }, nil
+}
+
+// hasGitRevision checks if the revision is a commit, or points to one,
+// in the repo in gitDir.
+func hasGitRevision(gitDir string, revision string) bool {
+	_, err := runGit(gitDir, "rev-parse", "--verify", "-q", revision+"^{commit}")
+	return err == nil
+}
+
+// initGitMirror creates the bare mirror of the repo at srcURL,
+// if it doesn't exist yet.
+func initGitMirror(mirrorDir string, srcURL string) error {
+	if _, err := os.Stat(mirrorDir); err == nil {
+		return nil
+	}
+	// Init in a temp dir, so that an interrupted init doesn't leave
+	// a broken mirror behind.
+	tmpDir, err := os.MkdirTemp(filepath.Dir(mirrorDir), filepath.Base(mirrorDir)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	for _, args := range [][]string{
+		{"init", "-q", "--bare"},
+		{"remote", "add", "origin", srcURL},
+		// Revisions fetched by commit ID aren't at a ref,
+		// so they mustn't be pruned.
+		{"config", "gc.auto", "0"},
+	} {
+		if _, err := runGit(tmpDir, args...); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmpDir, mirrorDir)
+}
+
+// updateGitMirror fetches the revision to the mirror, unless it's already
+// there. Only the requested tag, or else the commit, is fetched, so tags
+// moved upstream don't affect fetching other revisions. The tag isn't
+// forced, so a tag once fetched to the mirror always points to the same
+// object.
+func updateGitMirror(mirrorDir string, revision string) error {
+	if hasGitRevision(mirrorDir, revision) {
+		return nil
+	}
+	tagRef := "refs/tags/" + revision
+	_, tagErr := runGit(mirrorDir, "fetch", "-q", "--no-tags", "origin", tagRef+":"+tagRef)
+	if tagErr == nil && hasGitRevision(mirrorDir, revision) {
+		return nil
+	}
+	// Not a tag, fetch the commit for the provided revision
+	if _, err := runGit(mirrorDir, "fetch", "-q", "--no-tags", "origin", revision); err != nil {
+		if tagErr != nil {
+			return fmt.Errorf("fetching revision %s failed: %s\n%s", revision, tagErr, err)
+		}
+		return fmt.Errorf("fetching revision %s failed: %s", revision, err)
+	}
+	if !hasGitRevision(mirrorDir, revision) {
+		return fmt.Errorf("revision %s not found after fetching it", revision)
+	}
+	return nil
+}
+
+// checkoutFromGitMirror makes sure the revision of the repo at srcURL is in
+// its mirror, fetching it if needed, and adds a worktree of the mirror in
+// targetDir, detached at the revision. The worktree shares the objects and
+// refs of the mirror, and files aren't checked out, since the worktree is
+// only used to read git objects.
+func checkoutFromGitMirror(pkg, srcURL, revision, targetDir string) (string, error) {
+	mirrorDir := getGitMirrorDir(srcURL)
+	unlock, err := lockGitMirror(mirrorDir)
+	if err != nil {
+		return "", fmt.Errorf("error locking git mirror of %s: %s", srcURL, err)
+	}
+	defer unlock()
+
+	if err := initGitMirror(mirrorDir, srcURL); err != nil {
+		return "", fmt.Errorf("error creating git mirror of %s at %s: %s",
+			srcURL, mirrorDir, err)
+	}
+	if err := updateGitMirror(mirrorDir, revision); err != nil {
+		return "", fmt.Errorf("error updating git mirror of %s at %s: %s",
+			srcURL, mirrorDir, err)
+	}
+
+	// Drop worktrees of earlier runs, whose dirs have been removed
+	if _, err := runGit(mirrorDir, "worktree", "prune"); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return "", err
+	}
+	worktreeDir, err := os.MkdirTemp(targetDir, pkg)
+	if err != nil {
+		return "", fmt.Errorf("error while creating tempDir for %s, %s", pkg, err)
+	}
+	if _, err := runGit(mirrorDir, "worktree", "add", "-q", "--detach", "--no-checkout",
+		worktreeDir, revision+"^{commit}"); err != nil {
+		return "", fmt.Errorf("adding worktree at %s failed: %s", worktreeDir, err)
+	}
+	return worktreeDir, nil
+}
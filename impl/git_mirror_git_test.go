@@ -0,0 +1,138 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+//go:build containerized
+
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// requireWorktreeAt checks that the worktree is detached at commit
+// and has the tags of the mirror.
+func requireWorktreeAt(t *testing.T, worktreeDir string, commit string, tag string) {
+	require.Equal(t, commit,
+		strings.TrimSpace(runInDir(t, worktreeDir, testGitEnv, "git", "rev-parse", "HEAD")))
+	runInDir(t, worktreeDir, testGitEnv, "git", "show-ref", "--quiet", "--verify", "refs/tags/"+tag)
+}
+
+func TestCloneGitRepoFromMirror(t *testing.T) {
+	viper.Set("WorkingDir", t.TempDir())
+	defer viper.Reset()
+
+	upstreamDir := filepath.Join(t.TempDir(), "upstream")
+	commit1 := setupGitRepo(t, upstreamDir, "main.c", nil)
+	runInDir(t, upstreamDir, testGitEnv, "git", "tag", "v1")
+
+	downloadDir := t.TempDir()
+	worktreeDir, err := cloneGitRepo("foo", upstreamDir, "v1", downloadDir)
+	require.NoError(t, err)
+	require.Equal(t, downloadDir, filepath.Dir(worktreeDir))
+	requireWorktreeAt(t, worktreeDir, commit1, "v1")
+	mirrorDir := getGitMirrorDir(upstreamDir)
+	require.DirExists(t, mirrorDir)
+
+	t.Log("Testing revision already in the mirror isn't fetched")
+	hiddenUpstreamDir := upstreamDir + ".hidden"
+	require.NoError(t, os.Rename(upstreamDir, hiddenUpstreamDir))
+	worktreeDir, err = cloneGitRepo("foo", upstreamDir, "v1", t.TempDir())
+	require.NoError(t, err)
+	requireWorktreeAt(t, worktreeDir, commit1, "v1")
+	_, err = cloneGitRepo("foo", upstreamDir, "v2", t.TempDir())
+	require.ErrorContains(t, err, "fetching revision v2 failed")
+	require.NoError(t, os.Rename(hiddenUpstreamDir, upstreamDir))
+
+	t.Log("Testing new revisions are fetched to the mirror")
+	require.NoError(t, os.WriteFile(filepath.Join(upstreamDir, "main.c"), []byte("v2\n"), 0644))
+	runInDir(t, upstreamDir, testGitEnv, "git", "commit", "-q", "-a", "-m", "v2")
+	commit2 := strings.TrimSpace(runInDir(t, upstreamDir, testGitEnv, "git", "rev-parse", "HEAD"))
+	runInDir(t, upstreamDir, testGitEnv, "git", "tag", "v2")
+	var wg sync.WaitGroup
+	worktreeDirs := make([]string, 4)
+	errs := make([]error, 4)
+	for i := range worktreeDirs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			worktreeDirs[i], errs[i] = cloneGitRepo("foo", upstreamDir, "v2", downloadDir)
+		}(i)
+	}
+	wg.Wait()
+	for i := range worktreeDirs {
+		require.NoError(t, errs[i])
+		requireWorktreeAt(t, worktreeDirs[i], commit2, "v2")
+	}
+
+	t.Log("Testing commit not at a tag")
+	runInDir(t, upstreamDir, testGitEnv, "git", "commit", "-q", "--allow-empty", "-m", "v3")
+	commit3 := strings.TrimSpace(runInDir(t, upstreamDir, testGitEnv, "git", "rev-parse", "HEAD"))
+	worktreeDir, err = cloneGitRepo("foo", upstreamDir, commit3, t.TempDir())
+	require.NoError(t, err)
+	requireWorktreeAt(t, worktreeDir, commit3, "v2")
+
+	t.Log("Testing worktrees of removed download dirs are pruned")
+	require.NoError(t, os.RemoveAll(downloadDir))
+	_, err = cloneGitRepo("foo", upstreamDir, "v1", t.TempDir())
+	require.NoError(t, err)
+	worktrees := runInDir(t, mirrorDir, testGitEnv, "git", "worktree", "list", "--porcelain")
+	require.NotContains(t, worktrees, downloadDir)
+
+	t.Log("Testing tag moved upstream")
+	runInDir(t, upstreamDir, testGitEnv, "git", "tag", "-f", "v1")
+	worktreeDir, err = cloneGitRepo("foo", upstreamDir, "v1", t.TempDir())
+	require.NoError(t, err)
+	requireWorktreeAt(t, worktreeDir, commit1, "v1")
+	// New tags are still fetched
+	runInDir(t, upstreamDir, testGitEnv, "git", "tag", "v3")
+	worktreeDir, err = cloneGitRepo("foo", upstreamDir, "v3", t.TempDir())
+	require.NoError(t, err)
+	requireWorktreeAt(t, worktreeDir, commit3, "v3")
+}
+
+func TestUpdateGitMirror(t *testing.T) {
+	upstreamDir := t.TempDir()
+	git := func(dir string, args ...string) string {
+		return strings.TrimSpace(runInDir(t, dir, testGitEnv, "git", args...))
+	}
+	git(upstreamDir, "init", "-q")
+	git(upstreamDir, "commit", "-q", "--allow-empty", "-m", "first")
+	git(upstreamDir, "tag", "-m", "v1.0", "v1.0")
+	git(upstreamDir, "tag", "nightly")
+	first := git(upstreamDir, "rev-parse", "HEAD")
+
+	mirrorDir := filepath.Join(t.TempDir(), "mirror.git")
+	require.NoError(t, initGitMirror(mirrorDir, upstreamDir))
+	require.NoError(t, updateGitMirror(mirrorDir, "v1.0"))
+	require.Equal(t, first, git(mirrorDir, "rev-parse", "v1.0^{commit}"))
+	// Only the requested tag is fetched
+	require.Equal(t, "v1.0", git(mirrorDir, "tag"))
+
+	t.Log("Fetching a new tag after an unrelated tag moved upstream")
+	require.NoError(t, updateGitMirror(mirrorDir, "nightly"))
+	git(upstreamDir, "commit", "-q", "--allow-empty", "-m", "second")
+	git(upstreamDir, "tag", "-f", "nightly")
+	git(upstreamDir, "tag", "-m", "v2.0", "v2.0")
+	second := git(upstreamDir, "rev-parse", "HEAD")
+	require.NoError(t, updateGitMirror(mirrorDir, "v2.0"))
+	require.Equal(t, second, git(mirrorDir, "rev-parse", "v2.0^{commit}"))
+	// Tags in the mirror aren't moved
+	require.NoError(t, updateGitMirror(mirrorDir, "nightly"))
+	require.Equal(t, first, git(mirrorDir, "rev-parse", "nightly^{commit}"))
+
+	t.Log("Fetching a commit")
+	git(upstreamDir, "commit", "-q", "--allow-empty", "-m", "third")
+	third := git(upstreamDir, "rev-parse", "HEAD")
+	require.NoError(t, updateGitMirror(mirrorDir, third))
+	require.Equal(t, third, git(mirrorDir, "rev-parse", third+"^{commit}"))
+
+	t.Log("Fetching a missing revision")
+	require.ErrorContains(t, updateGitMirror(mirrorDir, "v3.0"), "fetching revision v3.0 failed")
+}
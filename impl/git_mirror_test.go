@@ -0,0 +1,38 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetGitMirrorDir(t *testing.T) {
+	viper.Set("WorkingDir", "/var/eext")
+	defer viper.Reset()
+
+	mirrorDirRegex := regexp.MustCompile(`^/var/eext/git-mirrors/frr-[0-9a-f]{16}\.git$`)
+	frrURLs := []string{
+		"https://github.com/FRRouting/frr",
+		"https://github.com/FRRouting/frr.git",
+		"https://github.com/FRRouting/frr/",
+		"git@github.com:FRRouting/frr.git",
+	}
+	mirrorDirs := make(map[string]bool)
+	for _, srcURL := range frrURLs {
+		mirrorDir := getGitMirrorDir(srcURL)
+		require.Regexp(t, mirrorDirRegex, mirrorDir)
+		mirrorDirs[mirrorDir] = true
+	}
+	// Different URLs have different mirrors
+	require.Len(t, mirrorDirs, len(frrURLs))
+	require.Equal(t, getGitMirrorDir(frrURLs[0]), getGitMirrorDir(frrURLs[0]))
+
+	require.Equal(t, "/var/eext/git-mirrors",
+		filepath.Dir(getGitMirrorDir("https://example.com/a b/../..")))
+}
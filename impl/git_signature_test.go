@@ -0,0 +1,142 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+//go:build containerized
+
+package impl
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// runInDir runs a command in dir with extra environment variables
+// and returns its stdout.
+func runInDir(t *testing.T, dir string, env []string, name string, args ...string) string {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	output, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		t.Fatalf("%s %s failed: %s\n%s", name, strings.Join(args, " "), err, exitErr.Stderr)
+	}
+	require.NoError(t, err)
+	return string(output)
+}
+
+// setupSignedGitRepo creates a git repo with two commits, the first one
+// unsigned and the second one and its tag signed with the signing options.
+func setupSignedGitRepo(t *testing.T, env []string, signingConfig ...string) string {
+	repoDir := t.TempDir()
+	gitEnv := append([]string{
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+	}, env...)
+	var configArgs []string
+	for _, config := range signingConfig {
+		configArgs = append(configArgs, "-c", config)
+	}
+
+	runInDir(t, repoDir, gitEnv, "git", "init", "-q")
+	runInDir(t, repoDir, gitEnv, "git", "commit", "-q", "--allow-empty", "-m", "unsigned")
+	runInDir(t, repoDir, gitEnv, "git", "tag", "unsigned-tag")
+	runInDir(t, repoDir, gitEnv, "git",
+		append(configArgs, "commit", "-q", "-S", "--allow-empty", "-m", "signed")...)
+	runInDir(t, repoDir, gitEnv, "git",
+		append(configArgs, "tag", "-s", "-m", "signed tag", "signed-tag")...)
+	return repoDir
+}
+
+func TestVerifyGitSSHSignature(t *testing.T) {
+	keyDir := t.TempDir()
+	trustedKey := filepath.Join(keyDir, "trusted")
+	otherKey := filepath.Join(keyDir, "other")
+	for _, key := range []string{trustedKey, otherKey} {
+		runInDir(t, keyDir, nil, "ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "", "-f", key)
+	}
+	fingerprint := strings.Fields(runInDir(t, keyDir, nil, "ssh-keygen", "-l", "-f", trustedKey+".pub"))[1]
+
+	pubKey := func(key string) string {
+		contents, err := os.ReadFile(key + ".pub")
+		require.NoError(t, err)
+		return strings.TrimSpace(string(contents))
+	}
+	allowedSigners := filepath.Join(keyDir, "allowed_signers")
+	require.NoError(t, os.WriteFile(allowedSigners, []byte(fmt.Sprintf(
+		"# trusted upstream maintainers\nmaintainer@example.com %s\n", pubKey(trustedKey))), 0644))
+	otherAllowedSigners := filepath.Join(keyDir, "other_allowed_signers")
+	require.NoError(t, os.WriteFile(otherAllowedSigners, []byte(fmt.Sprintf(
+		"other@example.com %s\n", pubKey(otherKey))), 0644))
+
+	repoDir := setupSignedGitRepo(t, nil, "gpg.format=ssh", "user.signingkey="+trustedKey)
+
+	for _, revision := range []string{"signed-tag", "HEAD"} {
+		t.Logf("Verifying %s", revision)
+		verifiedBy, err := verifyGitSSHSignature(allowedSigners,
+			gitSpec{Revision: revision, ClonedDir: repoDir}, "")
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("ssh ED25519 key %s(maintainer@example.com)", fingerprint),
+			verifiedBy)
+	}
+
+	t.Log("Verifying with untrusted key")
+	_, err := verifyGitSSHSignature(otherAllowedSigners,
+		gitSpec{Revision: "HEAD", ClonedDir: repoDir}, "")
+	require.Error(t, err)
+
+	for _, revision := range []string{"unsigned-tag", "HEAD~1"} {
+		t.Logf("Verifying unsigned %s", revision)
+		_, err := verifyGitSSHSignature(allowedSigners,
+			gitSpec{Revision: revision, ClonedDir: repoDir}, "")
+		require.Error(t, err)
+	}
+}
+
+func TestVerifyGitGPGSignature(t *testing.T) {
+	gnupgHome := t.TempDir()
+	gpgEnv := []string{"GNUPGHOME=" + gnupgHome}
+	runInDir(t, gnupgHome, gpgEnv, "gpg", "--batch", "--passphrase", "",
+		"--quick-gen-key", "Test Signer <signer@example.com>", "ed25519", "sign", "never")
+	var fingerprint string
+	for _, line := range strings.Split(runInDir(t, gnupgHome, gpgEnv,
+		"gpg", "--with-colons", "--fingerprint", "signer@example.com"), "\n") {
+		if strings.HasPrefix(line, "fpr:") {
+			fingerprint = strings.Split(line, ":")[9]
+			break
+		}
+	}
+	require.NotEmpty(t, fingerprint)
+	pubKeyPath := filepath.Join(t.TempDir(), "signer.pem")
+	require.NoError(t, os.WriteFile(pubKeyPath, []byte(runInDir(t, gnupgHome, gpgEnv,
+		"gpg", "--armor", "--export", fingerprint)), 0644))
+
+	repoDir := setupSignedGitRepo(t, gpgEnv, "user.signingkey="+fingerprint)
+
+	for _, revision := range []string{"signed-tag", "HEAD"} {
+		t.Logf("Verifying %s", revision)
+		signer, err := verifyGitSignature(pubKeyPath,
+			gitSpec{Revision: revision, ClonedDir: repoDir}, "")
+		require.NoError(t, err)
+		require.Equal(t, fingerprint, signer.Fingerprint)
+		require.False(t, signer.Created.IsZero())
+	}
+
+	t.Log("Verifying unsigned commit")
+	_, err := verifyGitSignature(pubKeyPath,
+		gitSpec{Revision: "HEAD~1", ClonedDir: repoDir}, "")
+	require.Error(t, err)
+
+	t.Log("Verifying SSH allowed signers against a GPG signed commit")
+	allowedSigners := filepath.Join(t.TempDir(), "allowed_signers")
+	require.NoError(t, os.WriteFile(allowedSigners, nil, 0644))
+	_, err = verifyGitSSHSignature(allowedSigners,
+		gitSpec{Revision: "HEAD", ClonedDir: repoDir}, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// gitSubmodule is a submodule at the commit pinned in its parent's revision
+type gitSubmodule struct {
+	// Path of the submodule in the top level repo
+	Path   string
+	URL    string
+	Commit string
+	// Bare repo the commit is fetched to
+	gitDir string
+}
+
+// Tree entry of a submodule in git ls-tree output
+var gitlinkRegex = regexp.MustCompile(`^160000 commit ([0-9a-f]+)\t(.+)$`)
+
+// runGit runs git in dir and returns stdout
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return "", fmt.Errorf("git %s failed: %s\n%s",
+			strings.Join(args, " "), err, exitErr.Stderr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s", strings.Join(args, " "), err)
+	}
+	return string(output), nil
+}
+
+// resolveSubmoduleURL resolves a submodule URL relative to the URL of the
+// parent repo, like git does for URLs starting with ./ or ../
+func resolveSubmoduleURL(parentURL string, submoduleURL string) string {
+	if !strings.HasPrefix(submoduleURL, "./") && !strings.HasPrefix(submoduleURL, "../") {
+		return submoduleURL
+	}
+	parsedURL, err := url.Parse(parentURL)
+	if err != nil || parsedURL.Scheme == "" {
+		return path.Join(parentURL, submoduleURL)
+	}
+	parsedURL.Path = path.Join(parsedURL.Path, submoduleURL)
+	return parsedURL.String()
+}
+
+// listGitSubmodules returns the submodules pinned in the revision of the
+// repo in gitDir, with paths relative to the repo.
+func listGitSubmodules(gitDir string, revision string, parentURL string) (
+	[]gitSubmodule, error) {
+	tree, err := runGit(gitDir, "ls-tree", "-r", "-z", revision)
+	if err != nil {
+		return nil, err
+	}
+	var submodules []gitSubmodule
+	for _, entry := range strings.Split(tree, "\x00") {
+		if match := gitlinkRegex.FindStringSubmatch(entry); match != nil {
+			submodules = append(submodules, gitSubmodule{Path: match[2], Commit: match[1]})
+		}
+	}
+	if len(submodules) == 0 {
+		return nil, nil
+	}
+
+	// Map the submodule paths to URLs with .gitmodules in the revision
+	config, err := runGit(gitDir, "config", "-z", "--blob", revision+":.gitmodules",
+		"--get-regexp", `^submodule\..*\.(path|url)$`)
+	if err != nil {
+		return nil, fmt.Errorf("error reading .gitmodules: %s", err)
+	}
+	paths := make(map[string]string)
+	urls := make(map[string]string)
+	for _, entry := range strings.Split(config, "\x00") {
+		key, value, found := strings.Cut(entry, "\n")
+		if !found {
+			continue
+		}
+		if name := strings.TrimSuffix(key, ".path"); name != key {
+			paths[name] = value
+		} else if name := strings.TrimSuffix(key, ".url"); name != key {
+			urls[name] = value
+		}
+	}
+	for i := range submodules {
+		submodule := &submodules[i]
+		for name, submodulePath := range paths {
+			if submodulePath == submodule.Path && urls[name] != "" {
+				submodule.URL = resolveSubmoduleURL(parentURL, urls[name])
+			}
+		}
+		if submodule.URL == "" {
+			return nil, fmt.Errorf("no url in .gitmodules for submodule %s", submodule.Path)
+		}
+		cleanPath := path.Clean(submodule.Path)
+		if cleanPath != submodule.Path || path.IsAbs(cleanPath) ||
+			cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+			return nil, fmt.Errorf("bad submodule path %s", submodule.Path)
+		}
+	}
+	return submodules, nil
+}
+
+// fetchGitSubmodule fetches the pinned commit of the submodule to a new bare
+// repo in targetDir. The commit is looked up by its object ID, which is part
+// of the parent's revision, so the submodule contents are verified by the
+// parent revision's signature.
+func fetchGitSubmodule(targetDir string, pkg string, submodule *gitSubmodule) error {
+	gitDir, err := os.MkdirTemp(targetDir, pkg+"-submodule")
+	if err != nil {
+		return err
+	}
+	if _, err := runGit(gitDir, "init", "-q", "--bare"); err != nil {
+		return err
+	}
+	if _, err := runGit(gitDir, "fetch", "-q", submodule.URL, submodule.Commit); err != nil {
+		// Not all servers allow fetching commits which aren't at a ref
+		if _, err := runGit(gitDir, "fetch", "-q", submodule.URL,
+			"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"); err != nil {
+			return err
+		}
+	}
+	fetched, err := runGit(gitDir, "rev-parse", "--verify", "-q", submodule.Commit+"^{commit}")
+	if err != nil || strings.TrimSpace(fetched) != submodule.Commit {
+		return fmt.Errorf("commit %s pinned for submodule %s not found in %s",
+			submodule.Commit, submodule.Path, submodule.URL)
+	}
+	submodule.gitDir = gitDir
+	return nil
+}
+
+// fetchGitSubmodules fetches the submodules pinned in the revision of the
+// repo in gitDir, and their submodules recursively. The paths of the
+// returned submodules are prefixed with pathPrefix.
+func fetchGitSubmodules(targetDir string, pkg string, gitDir string, revision string,
+	parentURL string, pathPrefix string) ([]gitSubmodule, error) {
+	submodules, err := listGitSubmodules(gitDir, revision, parentURL)
+	if err != nil {
+		return nil, err
+	}
+	var allSubmodules []gitSubmodule
+	for _, submodule := range submodules {
+		if err := fetchGitSubmodule(targetDir, pkg, &submodule); err != nil {
+			return nil, err
+		}
+		nestedSubmodules, err := fetchGitSubmodules(targetDir, pkg, submodule.gitDir,
+			submodule.Commit, submodule.URL, path.Join(pathPrefix, submodule.Path))
+		if err != nil {
+			return nil, err
+		}
+		submodule.Path = path.Join(pathPrefix, submodule.Path)
+		allSubmodules = append(allSubmodules, submodule)
+		allSubmodules = append(allSubmodules, nestedSubmodules...)
+	}
+	return allSubmodules, nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+//go:build containerized
+
+package impl
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testGitEnv = []string{
+	"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+	"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+}
+
+// setupGitRepo creates a git repo at dir with a file, and with submodules
+// at the paths and urls in submodules, and returns the commit.
+func setupGitRepo(t *testing.T, dir string, file string, submodules map[string]string) string {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	runInDir(t, dir, testGitEnv, "git", "init", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(file+"\n"), 0644))
+	runInDir(t, dir, testGitEnv, "git", "add", file)
+	for subPath, url := range submodules {
+		runInDir(t, dir, testGitEnv, "git", "-c", "protocol.file.allow=always",
+			"submodule", "add", "-q", url, subPath)
+	}
+	runInDir(t, dir, testGitEnv, "git", "commit", "-q", "-m", "commit")
+	return strings.TrimSpace(runInDir(t, dir, testGitEnv, "git", "rev-parse", "HEAD"))
+}
+
+// readTarGz returns the names and contents of the files in the tarball
+func readTarGz(t *testing.T, tarballPath string) map[string]string {
+	tarball, err := os.Open(tarballPath)
+	require.NoError(t, err)
+	defer tarball.Close()
+	gzipReader, err := gzip.NewReader(tarball)
+	require.NoError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+	files := make(map[string]string)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		contents, err := io.ReadAll(tarReader)
+		require.NoError(t, err)
+		files[header.Name] = string(contents)
+	}
+	return files
+}
+
+func TestGenerateArchiveFileWithSubmodules(t *testing.T) {
+	reposDir := t.TempDir()
+	innerCommit := setupGitRepo(t, filepath.Join(reposDir, "inner"), "inner.c", nil)
+	libCommit := setupGitRepo(t, filepath.Join(reposDir, "lib"), "lib.c",
+		map[string]string{"deps/inner": "../inner"})
+	parentDir := filepath.Join(reposDir, "parent")
+	setupGitRepo(t, parentDir, "main.c", map[string]string{"lib": "../lib"})
+
+	targetDir := t.TempDir()
+	archive := gitArchiveSpec{Name: "foo-1.0.tar.gz", Prefix: "foo-1.0", Submodules: true}
+	spec := gitSpec{SrcUrl: parentDir, Revision: "HEAD", ClonedDir: parentDir}
+	submodules, err := generateArchiveFile(targetDir, spec, archive, "foo", "")
+	require.NoError(t, err)
+	require.Len(t, submodules, 2)
+	require.Equal(t, "lib", submodules[0].Path)
+	require.Equal(t, filepath.Join(reposDir, "lib"), submodules[0].URL)
+	require.Equal(t, libCommit, submodules[0].Commit)
+	require.Equal(t, "lib/deps/inner", submodules[1].Path)
+	require.Equal(t, innerCommit, submodules[1].Commit)
+
+	require.Equal(t, map[string]string{
+		"foo-1.0/.gitmodules":            readFileString(t, filepath.Join(parentDir, ".gitmodules")),
+		"foo-1.0/main.c":                 "main.c\n",
+		"foo-1.0/lib/.gitmodules":        readFileString(t, filepath.Join(reposDir, "lib/.gitmodules")),
+		"foo-1.0/lib/lib.c":              "lib.c\n",
+		"foo-1.0/lib/deps/inner/inner.c": "inner.c\n",
+	}, readTarGz(t, filepath.Join(targetDir, archive.Name)))
+
+	t.Log("Testing submodule commit not in the submodule repo")
+	// A new commit in lib, which isn't pinned in parent
+	otherLibDir := filepath.Join(reposDir, "other-lib")
+	setupGitRepo(t, otherLibDir, "lib.c", nil)
+	runInDir(t, parentDir, testGitEnv, "git", "config", "-f", ".gitmodules",
+		"submodule.lib.url", "../other-lib")
+	runInDir(t, parentDir, testGitEnv, "git", "commit", "-q", "-a", "-m", "other lib")
+	_, err = generateArchiveFile(t.TempDir(), spec, archive, "foo", "")
+	require.ErrorContains(t, err, "commit "+libCommit+" pinned for submodule lib not found")
+}
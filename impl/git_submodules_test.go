@@ -0,0 +1,28 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSubmoduleURL(t *testing.T) {
+	testCases := []struct {
+		parentURL    string
+		submoduleURL string
+		expected     string
+	}{
+		{"https://github.com/FRRouting/frr", "https://github.com/CESNET/libyang",
+			"https://github.com/CESNET/libyang"},
+		{"https://github.com/FRRouting/frr.git", "../libyang.git",
+			"https://github.com/FRRouting/libyang.git"},
+		{"https://github.com/FRRouting/frr", "./libyang", "https://github.com/FRRouting/frr/libyang"},
+		{"/src/repos/frr", "../libyang", "/src/repos/libyang"},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, resolveSubmoduleURL(tc.parentURL, tc.submoduleURL))
+	}
+}
@@ -0,0 +1,497 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"code.arista.io/eos/tools/eext/dnfconfig"
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/rpmfile"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// InitExtraCmdlineArgs is a bundle of extra args for impl.Init
+// Name overrides the package name guessed from the upstream source.
+// Revision is the commit/tag of git upstream sources.
+// RepoBundle lists the repo-bundles to build with, the one named after
+// Distro by default.
+type InitExtraCmdlineArgs struct {
+	Name       string
+	Revision   string
+	Subdir     bool
+	Distro     string
+	RepoBundle []string
+}
+
+// Suffixes of the upstream tarballs eext init recognizes
+var initTarballSuffixes = []string{
+	".tar.gz", ".tar.xz", ".tar.bz2", ".tar.zst", ".tgz", ".tbz2", ".txz", ".tar",
+}
+
+var (
+	initNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.+-]*$`)
+	// <name>-<version> with an optional v before the version
+	initNameVersionRegex = regexp.MustCompile(`^(.+?)-v?([0-9][A-Za-z0-9._+~]*)$`)
+	// A tag which is a version, with an optional prefix like v or <name>-
+	initVersionTagRegex = regexp.MustCompile(`^(?:.*[-_])?v?([0-9][A-Za-z0-9._+~]*)$`)
+	gitCommitRegex      = regexp.MustCompile(`^[0-9a-f]{7,64}$`)
+)
+
+// initPackage is what eext init sets up the repo with.
+type initPackage struct {
+	Name         string
+	Version      string
+	Type         string
+	SrcURL       string
+	Sha256       string
+	SkipSigCheck bool
+	Subdir       bool
+	Distro       string
+	RepoBundle   []string
+	// Git source, for git-upstream packages
+	Git *manifest.GitBundle
+	// Source file and its top dir, for the starter spec
+	SourceFile string
+	TopDir     string
+	// Files to import into the repo's spec and sources dirs, for srpm packages
+	SpecFile string
+	Patches  []string
+}
+
+const initManifestTemplate = `---
+package:
+  - name: {{.Name}}
+{{- if .Subdir}}
+    subdir: true
+{{- end}}
+    upstream-sources:
+{{- if .Git}}
+      - git:
+          url: {{.Git.Url}}
+          revision: {{.Git.Revision}}
+          prefix: {{.Git.Prefix}}
+{{- if .Git.Submodules}}
+          submodules: true
+{{- end}}
+{{- else}}
+      - full-url: {{.SrcURL}}
+{{- end}}
+        sha256: {{.Sha256}}
+{{- if .SkipSigCheck}}
+        # TODO: Verify the upstream source, with detached-sig for tarballs,
+        # or public-key/allowed-signers for git sources.
+        signature:
+          skip-check: true
+{{- end}}
+    type: {{.Type}}
+    build:
+{{- if .Distro}}
+      distro: {{.Distro}}
+{{- end}}
+      repo-bundle:
+{{- range .RepoBundle}}
+        - name: {{.}}
+{{- end}}
+`
+
+const initSpecTemplate = `Name:           {{.Name}}
+Version:        {{.Version}}
+Release:        %{?eext_release:%{eext_release}}%{!?eext_release:eng}
+Summary:        TODO
+License:        TODO
+URL:            {{.SrcURL}}
+Source0:        {{.SourceFile}}
+
+%description
+TODO
+
+%prep
+{{- if .TopDir}}
+%autosetup -p1 -n {{.TopDir}}
+{{- else}}
+%autosetup -p1 -c
+{{- end}}
+
+%build
+
+%install
+
+%files
+
+%changelog
+* {{.Date}} TODO <TODO> - {{.Version}}
+- Initial package generated by eext init
+`
+
+var (
+	parsedInitManifestTemplate = template.Must(template.New("manifest").Parse(initManifestTemplate))
+	parsedInitSpecTemplate     = template.Must(template.New("spec").Parse(initSpecTemplate))
+)
+
+// initPackageType returns the package type for the upstream source.
+func initPackageType(srcURL string, revision string) (string, error) {
+	uri, err := url.Parse(srcURL)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case revision != "" || strings.HasSuffix(uri.Path, ".git") ||
+		uri.Scheme == "git" || uri.Scheme == "ssh":
+		if revision == "" {
+			return "", fmt.Errorf("specify the revision of git source %s", srcURL)
+		}
+		return "git-upstream", nil
+	case strings.HasSuffix(uri.Path, ".src.rpm"):
+		return "srpm", nil
+	}
+	for _, suffix := range initTarballSuffixes {
+		if strings.HasSuffix(uri.Path, suffix) {
+			return "tarball", nil
+		}
+	}
+	return "", fmt.Errorf("%s isn't an SRPM, tarball or git URL, "+
+		"specify the revision for git URLs without a .git suffix", srcURL)
+}
+
+// guessNameVersion guesses the package name and version from the
+// upstream source URL, and the revision for git sources.
+// The version is empty if it can't be guessed.
+func guessNameVersion(pkgType string, srcURL string, revision string) (string, string) {
+	uri, err := url.Parse(srcURL)
+	if err != nil {
+		return "", ""
+	}
+	base := path.Base(uri.Path)
+	switch pkgType {
+	case "git-upstream":
+		name := strings.TrimSuffix(base, ".git")
+		if match := initVersionTagRegex.FindStringSubmatch(revision); match != nil &&
+			!gitCommitRegex.MatchString(revision) {
+			return name, match[1]
+		}
+		return name, ""
+	case "srpm":
+		// <name>-<version>-<release>.src.rpm
+		nvr := strings.Split(strings.TrimSuffix(base, ".src.rpm"), "-")
+		if len(nvr) < 3 {
+			return strings.Join(nvr, "-"), ""
+		}
+		return strings.Join(nvr[:len(nvr)-2], "-"), nvr[len(nvr)-2]
+	}
+	for _, suffix := range initTarballSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			base = strings.TrimSuffix(base, suffix)
+			break
+		}
+	}
+	if match := initNameVersionRegex.FindStringSubmatch(base); match != nil {
+		return match[1], match[2]
+	}
+	return base, ""
+}
+
+// tarballTopDir returns the dir all the files in the tarball are under,
+// or an empty string if there isn't one.
+func tarballTopDir(tarballPath string) (string, error) {
+	tarReader, closer, err := openTarball(tarballPath)
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+
+	topDir := ""
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return topDir, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading %s: %s", tarballPath, err)
+		}
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+		name := path.Clean(header.Name)
+		first, rest, _ := strings.Cut(name, "/")
+		if rest == "" && header.Typeflag != tar.TypeDir {
+			return "", nil
+		}
+		if topDir == "" {
+			topDir = first
+		} else if first != topDir {
+			return "", nil
+		}
+	}
+}
+
+// initRepoBundles checks the repo-bundles belong to the distro, and
+// defaults to the repo-bundle named after it.
+func initRepoBundles(dnfConfig *dnfconfig.DnfConfig, distro string,
+	repoBundles []string) ([]string, error) {
+	if _, found := dnfConfig.GetDistro(distro); !found {
+		return nil, fmt.Errorf("unknown distro %s", distro)
+	}
+	if len(repoBundles) == 0 {
+		if _, found := dnfConfig.DnfRepoBundleConfig[distro]; !found {
+			return nil, fmt.Errorf("no repo-bundle named after distro %s, specify the repo-bundles",
+				distro)
+		}
+		return []string{distro}, nil
+	}
+	for _, bundleName := range repoBundles {
+		bundleConfig, found := dnfConfig.DnfRepoBundleConfig[bundleName]
+		if !found {
+			return nil, fmt.Errorf("unknown repo-bundle %s", bundleName)
+		}
+		if !bundleConfig.InDistro(distro) {
+			return nil, fmt.Errorf("repo-bundle %s doesn't belong to distro %s",
+				bundleName, distro)
+		}
+	}
+	return repoBundles, nil
+}
+
+// initFromSrpm imports the spec file and patches of the upstream SRPM.
+func (pkg *initPackage) initFromSrpm(srpmPath string, nameOverride string, workDir string,
+	errPrefix util.ErrPrefix) error {
+	srpm, err := rpmfile.Open(srpmPath)
+	if err != nil {
+		return fmt.Errorf("%sInvalid SRPM %s: %s", errPrefix, srpmPath, err)
+	}
+	if nameOverride == "" {
+		pkg.Name = srpm.Name()
+	}
+	pkg.Version = srpm.Version()
+	// SRPMs are verified with the keys in the rpmkeys dir if signed
+	keyIDs, err := srpm.SignatureKeyIDs()
+	if err != nil {
+		return fmt.Errorf("%sInvalid SRPM %s: %s", errPrefix, srpmPath, err)
+	}
+	pkg.SkipSigCheck = len(keyIDs) == 0
+
+	rpmbuildDir := filepath.Join(workDir, "rpmbuild")
+	if err := extractSrpm(srpmPath, rpmbuildDir, errPrefix); err != nil {
+		return err
+	}
+	specFiles, _ := filepath.Glob(filepath.Join(rpmbuildDir, "SPECS", "*.spec"))
+	pkg.SpecFile = specFiles[0]
+	for _, pattern := range []string{"*.patch", "*.diff"} {
+		patches, _ := filepath.Glob(filepath.Join(rpmbuildDir, "SOURCES", pattern))
+		pkg.Patches = append(pkg.Patches, patches...)
+	}
+	return nil
+}
+
+// initFromGit generates the tarball of the revision to compute its sha256.
+// The prefix of the tarball is pinned, so that the hash doesn't change
+// with the Version in the spec file.
+func (pkg *initPackage) initFromGit(workDir string, errPrefix util.ErrPrefix) (string, error) {
+	clonedDir, err := cloneGitRepo(pkg.Name, pkg.Git.Url, pkg.Git.Revision, workDir)
+	if err != nil {
+		return "", fmt.Errorf("%sCloning git repo failed: %s", errPrefix, err)
+	}
+	submodules, err := listGitSubmodules(clonedDir, pkg.Git.Revision, pkg.Git.Url)
+	if err != nil {
+		return "", fmt.Errorf("%sError listing submodules of %s: %s",
+			errPrefix, pkg.Git.Url, err)
+	}
+	pkg.Git.Submodules = len(submodules) != 0
+	pkg.Git.Prefix = fmt.Sprintf("%s-%s", pkg.Name, pkg.Version)
+	archive := gitArchiveSpec{
+		Name:       pkg.Git.GetArchive(0),
+		Prefix:     pkg.Git.Prefix,
+		Submodules: pkg.Git.Submodules,
+	}
+	spec := gitSpec{
+		SrcUrl:    pkg.Git.Url,
+		Revision:  pkg.Git.Revision,
+		ClonedDir: clonedDir,
+	}
+	if _, err := generateArchiveFile(workDir, spec, archive, pkg.Name, errPrefix); err != nil {
+		return "", err
+	}
+	pkg.SourceFile = archive.Name
+	pkg.TopDir = archive.Prefix
+	return filepath.Join(workDir, archive.Name), nil
+}
+
+// executeTemplate writes the output of the template to a new file.
+func executeTemplate(tmpl *template.Template, filePath string, data any) error {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := tmpl.Execute(file, data); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// writeRepo writes the manifest, spec file and sources of the package.
+func (pkg *initPackage) writeRepo(repo string, errPrefix util.ErrPrefix) error {
+	specDir := getPkgSpecDirInRepo(repo, pkg.Name, pkg.Subdir)
+	sourcesDir := getPkgSourcesDirInRepo(repo, pkg.Name, pkg.Subdir)
+	for _, dir := range []string{specDir, sourcesDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("%sError '%s' creating %s", errPrefix, err, dir)
+		}
+	}
+
+	if pkg.SpecFile != "" {
+		if err := util.CopyToDestDir(pkg.SpecFile, specDir, errPrefix); err != nil {
+			return err
+		}
+		for _, patch := range pkg.Patches {
+			if err := util.CopyToDestDir(patch, sourcesDir, errPrefix); err != nil {
+				return err
+			}
+		}
+	} else {
+		specPath := filepath.Join(specDir, pkg.Name+".spec")
+		data := struct {
+			*initPackage
+			Date string
+		}{pkg, time.Now().Format("Mon Jan 02 2006")}
+		if err := executeTemplate(parsedInitSpecTemplate, specPath, data); err != nil {
+			return fmt.Errorf("%sError '%s' writing %s", errPrefix, err, specPath)
+		}
+	}
+
+	manifestPath := filepath.Join(util.GetRepoDir(repo), "eext.yaml")
+	if err := executeTemplate(parsedInitManifestTemplate, manifestPath, pkg); err != nil {
+		return fmt.Errorf("%sError '%s' writing %s", errPrefix, err, manifestPath)
+	}
+	return nil
+}
+
+// Init sets up the repo for a new package built from the upstream source at
+// srcURL, which is an SRPM, a tarball or a git repo. It writes the manifest,
+// with the sha256 of the upstream source, and the spec file. The spec file
+// and patches of upstream SRPMs are imported, a starter spec file is
+// written for the others.
+func Init(repo string, srcURL string, extraArgs InitExtraCmdlineArgs) error {
+	if err := setup(); err != nil {
+		return err
+	}
+	errPrefix := util.ErrPrefix("impl.Init: ")
+
+	pkgType, err := initPackageType(srcURL, extraArgs.Revision)
+	if err != nil {
+		return fmt.Errorf("%s%s", errPrefix, err)
+	}
+	name, version := guessNameVersion(pkgType, srcURL, extraArgs.Revision)
+	if extraArgs.Name != "" {
+		name = extraArgs.Name
+	}
+	if !initNameRegex.MatchString(name) {
+		return fmt.Errorf("%sBad package name '%s', specify the name", errPrefix, name)
+	}
+	if version == "" {
+		version = "0"
+	}
+
+	distro := extraArgs.Distro
+	if distro == "" {
+		distro = defaultDistro
+	}
+	dnfConfig, err := dnfconfig.LoadDnfConfig(viper.GetString("DnfConfigFile"))
+	if err != nil {
+		return err
+	}
+	repoBundles, err := initRepoBundles(dnfConfig, distro, extraArgs.RepoBundle)
+	if err != nil {
+		return fmt.Errorf("%s%s", errPrefix, err)
+	}
+
+	repoDir := util.GetRepoDir(repo)
+	manifestPath := filepath.Join(repoDir, "eext.yaml")
+	if _, err := os.Stat(manifestPath); err == nil {
+		return fmt.Errorf("%s%s already exists", errPrefix, manifestPath)
+	}
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		return fmt.Errorf("%sError '%s' creating %s", errPrefix, err, repoDir)
+	}
+
+	workDir, err := os.MkdirTemp(viper.GetString("WorkingDir"), "init-")
+	if err != nil {
+		return fmt.Errorf("%sError '%s' creating temporary dir", errPrefix, err)
+	}
+	defer os.RemoveAll(workDir)
+
+	pkg := &initPackage{
+		Name:         name,
+		Version:      version,
+		Type:         pkgType,
+		SrcURL:       srcURL,
+		SkipSigCheck: true,
+		Subdir:       extraArgs.Subdir,
+		RepoBundle:   repoBundles,
+	}
+	if distro != defaultDistro {
+		pkg.Distro = distro
+	}
+
+	var srcPath string
+	if pkgType == "git-upstream" {
+		pkg.Git = &manifest.GitBundle{Url: srcURL, Revision: extraArgs.Revision}
+		log.Printf("%sgenerating tarball of %s at %s", errPrefix, srcURL, extraArgs.Revision)
+		if srcPath, err = pkg.initFromGit(workDir, errPrefix); err != nil {
+			return err
+		}
+	} else {
+		log.Printf("%sdownloading %s", errPrefix, srcURL)
+		pkg.SourceFile, err = download(srcURL, workDir, repo, name, extraArgs.Subdir, errPrefix)
+		if err != nil {
+			return err
+		}
+		srcPath = filepath.Join(workDir, pkg.SourceFile)
+		if pkgType == "srpm" {
+			if err := pkg.initFromSrpm(srcPath, extraArgs.Name, workDir, errPrefix); err != nil {
+				return err
+			}
+		} else if pkg.TopDir, err = tarballTopDir(srcPath); err != nil {
+			return fmt.Errorf("%s%s", errPrefix, err)
+		}
+	}
+	if pkg.Sha256, err = util.GenerateHash(srcPath, "sha256"); err != nil {
+		return fmt.Errorf("%s%s", errPrefix, err)
+	}
+
+	specDir := getPkgSpecDirInRepo(repo, pkg.Name, pkg.Subdir)
+	if specFiles, _ := filepath.Glob(filepath.Join(specDir, "*.spec")); len(specFiles) != 0 {
+		return fmt.Errorf("%sFound spec files %s, the repo is already set up",
+			errPrefix, strings.Join(specFiles, ","))
+	}
+	if err := pkg.writeRepo(repo, errPrefix); err != nil {
+		return err
+	}
+
+	// The manifest is loaded back to make sure eext accepts it
+	if _, err := manifest.LoadManifest(repo); err != nil {
+		return err
+	}
+	if err := checkRepo(repo, pkg.Name, pkg.Subdir, false, errPrefix); err != nil {
+		return err
+	}
+	if pkgType != "srpm" {
+		log.Printf("%sWrote a starter spec file, fill in the TODOs", errPrefix)
+	}
+	log.Printf("SUCCESS: init %s(%s)", pkg.Name, pkgType)
+	return nil
+}
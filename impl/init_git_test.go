@@ -0,0 +1,46 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+//go:build containerized
+
+package impl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+func TestInitGit(t *testing.T) {
+	srcDir := setupInitTest(t)
+	upstreamDir := filepath.Join(t.TempDir(), "foo.git")
+	setupGitRepo(t, upstreamDir, "README", nil)
+	runInDir(t, upstreamDir, testGitEnv, "git", "tag", "v1.4")
+
+	require.NoError(t, Init("foo", upstreamDir, InitExtraCmdlineArgs{Revision: "v1.4"}))
+	repoManifest, err := manifest.LoadManifest("foo")
+	require.NoError(t, err)
+	pkgSpec := repoManifest.Package[0]
+	require.Equal(t, "foo", pkgSpec.Name)
+	require.Equal(t, "git-upstream", pkgSpec.Type)
+	gitBundle := pkgSpec.UpstreamSrc[0].GitBundle
+	require.Equal(t, manifest.GitBundle{Url: upstreamDir, Revision: "v1.4", Prefix: "foo-1.4"}, gitBundle)
+
+	// The sha256 is of the tarball create-srpm generates
+	tarballDir := t.TempDir()
+	_, _, err = getGitSpecAndSrcFile(upstreamDir, "v1.4", tarballDir, "foo",
+		gitArchiveSpec{Name: "Source0.tar.gz", Prefix: "foo-1.4"}, "")
+	require.NoError(t, err)
+	expectedSha256, err := util.GenerateHash(filepath.Join(tarballDir, "Source0.tar.gz"), "sha256")
+	require.NoError(t, err)
+	require.Equal(t, expectedSha256, pkgSpec.UpstreamSrc[0].Sha256)
+
+	spec := readFileString(t, filepath.Join(srcDir, "foo/spec/foo.spec"))
+	require.Contains(t, spec, "Version:        1.4\n")
+	require.Contains(t, spec, "Source0:        Source0.tar.gz\n")
+	require.Contains(t, spec, "%autosetup -p1 -n foo-1.4\n")
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/testutil"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+func TestGuessNameVersion(t *testing.T) {
+	for _, testCase := range []struct {
+		pkgType  string
+		srcURL   string
+		revision string
+		name     string
+		version  string
+	}{
+		{"tarball", "https://foo.org/dl/foo-bar-1.2.3.tar.gz", "", "foo-bar", "1.2.3"},
+		{"tarball", "https://foo.org/dl/foo-v2.0rc1.tar.xz", "", "foo", "2.0rc1"},
+		{"tarball", "file:///foo.tgz", "", "foo", ""},
+		{"srpm", "https://foo.org/foo-bar-1.0-3.fc40.src.rpm", "", "foo-bar", "1.0"},
+		{"git-upstream", "https://github.com/foo/bar.git", "v1.7.13", "bar", "1.7.13"},
+		{"git-upstream", "https://github.com/foo/libbar", "libbar-1.10.1", "libbar", "1.10.1"},
+		{"git-upstream", "https://github.com/foo/bar", "1234abcd", "bar", ""},
+		{"git-upstream", "https://github.com/foo/bar", "main", "bar", ""},
+	} {
+		name, version := guessNameVersion(testCase.pkgType, testCase.srcURL, testCase.revision)
+		require.Equal(t, testCase.name, name, testCase.srcURL)
+		require.Equal(t, testCase.version, version, testCase.srcURL)
+	}
+}
+
+func TestInitPackageType(t *testing.T) {
+	for srcURL, pkgType := range map[string]string{
+		"https://foo.org/foo-1.0-1.src.rpm":   "srpm",
+		"https://foo.org/foo-1.0.tar.bz2":     "tarball",
+		"https://github.com/foo/foo.git":      "git-upstream",
+		"ssh://git@foo.org/foo":               "git-upstream",
+		"https://foo.org/foo-1.0.tar.gz?dl=1": "tarball",
+	} {
+		actual, err := initPackageType(srcURL, "v1.0")
+		if pkgType == "git-upstream" {
+			require.NoError(t, err)
+			require.Equal(t, pkgType, actual, srcURL)
+		}
+		actual, err = initPackageType(srcURL, "")
+		if pkgType == "git-upstream" {
+			require.ErrorContains(t, err, "specify the revision")
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, pkgType, actual, srcURL)
+		}
+	}
+	_, err := initPackageType("https://foo.org/foo-1.0.zip", "")
+	require.Error(t, err)
+}
+
+func TestTarballTopDir(t *testing.T) {
+	dir := func(name string) testTarEntry {
+		return testTarEntry{header: tar.Header{Typeflag: tar.TypeDir, Name: name, Mode: 0755}}
+	}
+	file := func(name string) testTarEntry {
+		return testTarEntry{header: tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644}}
+	}
+	for expected, entries := range map[string][]testTarEntry{
+		"foo-1.0": {dir("foo-1.0/"), file("foo-1.0/README"), dir("foo-1.0/src/")},
+		"bar":     {file("./bar/README")},
+		"":        {dir("foo-1.0/"), file("foo-1.0/README"), file("Makefile")},
+	} {
+		topDir, err := tarballTopDir(writeTestTarball(t, entries))
+		require.NoError(t, err)
+		require.Equal(t, expected, topDir)
+	}
+}
+
+func readFileString(t *testing.T, path string) string {
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(contents)
+}
+
+func setupInitTest(t *testing.T) string {
+	srcDir := t.TempDir()
+	testutil.SetupViperConfig(srcDir, t.TempDir(), t.TempDir(), "", "", "", "", "", "", "")
+	t.Cleanup(viper.Reset)
+	return srcDir
+}
+
+func TestInitTarball(t *testing.T) {
+	srcDir := setupInitTest(t)
+	tarballPath := writeTestTarball(t, []testTarEntry{
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "foo-1.2/README", Mode: 0644}, contents: "foo"},
+	})
+	repoDir := filepath.Join(srcDir, "foo")
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "foo"), 0755))
+	expectedSha256, err := util.GenerateHash(tarballPath, "sha256")
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(tarballPath, filepath.Join(repoDir, "foo", "foo-1.2.tar.gz")))
+
+	require.NoError(t, Init("foo", "file:///foo-1.2.tar.gz", InitExtraCmdlineArgs{
+		Subdir:     true,
+		RepoBundle: []string{"el9", "epel9"},
+	}))
+	repoManifest, err := manifest.LoadManifest("foo")
+	require.NoError(t, err)
+	pkgSpec := repoManifest.Package[0]
+	require.Equal(t, "foo", pkgSpec.Name)
+	require.Equal(t, "tarball", pkgSpec.Type)
+	require.True(t, pkgSpec.Subdir)
+	require.Equal(t, "file:///foo-1.2.tar.gz", pkgSpec.UpstreamSrc[0].FullURL)
+	require.Equal(t, expectedSha256, pkgSpec.UpstreamSrc[0].Sha256)
+	require.True(t, pkgSpec.UpstreamSrc[0].Signature.SkipCheck)
+	require.Equal(t, []manifest.RepoBundle{{Name: "el9"}, {Name: "epel9"}}, pkgSpec.Build.RepoBundle)
+
+	spec := readFileString(t, filepath.Join(repoDir, "foo/spec/foo.spec"))
+	for _, expectedLine := range []string{
+		"Name:           foo",
+		"Version:        1.2",
+		"Release:        %{?eext_release:%{eext_release}}%{!?eext_release:eng}",
+		"Source0:        foo-1.2.tar.gz",
+		"%autosetup -p1 -n foo-1.2",
+	} {
+		require.Contains(t, spec, expectedLine+"\n")
+	}
+	require.DirExists(t, filepath.Join(repoDir, "foo/sources"))
+
+	err = Init("foo", "file:///foo-1.2.tar.gz", InitExtraCmdlineArgs{})
+	require.ErrorContains(t, err, "eext.yaml already exists")
+	err = Init("bar", "https://foo.org/bar-1.0.tar.gz",
+		InitExtraCmdlineArgs{RepoBundle: []string{"fc40-snapshot"}})
+	require.ErrorContains(t, err, "repo-bundle fc40-snapshot doesn't belong to distro el9")
+}
+
+func TestInitSrpm(t *testing.T) {
+	srcDir := setupInitTest(t)
+	repoDir := filepath.Join(srcDir, "foo")
+	require.NoError(t, os.MkdirAll(repoDir, 0755))
+	srpmPath := writeTestSrpm(t, repoDir, "gzip", testSrpmFiles, nil)
+
+	require.NoError(t, Init("foo", "file:///"+filepath.Base(srpmPath), InitExtraCmdlineArgs{
+		Distro:     "fc40",
+		RepoBundle: []string{"fc40-snapshot"},
+	}))
+	repoManifest, err := manifest.LoadManifest("foo")
+	require.NoError(t, err)
+	pkgSpec := repoManifest.Package[0]
+	require.Equal(t, "foo", pkgSpec.Name)
+	require.Equal(t, "srpm", pkgSpec.Type)
+	require.Equal(t, "fc40", pkgSpec.Build.Distro)
+	// The test SRPM isn't signed
+	require.True(t, pkgSpec.UpstreamSrc[0].Signature.SkipCheck)
+
+	require.Equal(t, "Name: foo\n", readFileString(t, filepath.Join(repoDir, "spec/foo.spec")))
+	require.Equal(t, "patch", readFileString(t, filepath.Join(repoDir, "sources/fix-build.patch")))
+	require.NoFileExists(t, filepath.Join(repoDir, "sources/foo-1.0.tar.gz"))
+}
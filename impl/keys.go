@@ -0,0 +1,305 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.arista.io/eos/tools/eext/rpmfile"
+	"code.arista.io/eos/tools/eext/trustpolicy"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// gpgKey is a primary key or subkey in a public-key file
+type gpgKey struct {
+	file        string
+	keyID       string
+	fingerprint string
+	userID      string
+	created     time.Time
+	expires     time.Time
+	revoked     bool
+}
+
+// status returns one of valid/expired/revoked
+func (k *gpgKey) status(now time.Time) string {
+	if k.revoked {
+		return "revoked"
+	}
+	if !k.expires.IsZero() && !now.Before(k.expires) {
+		return "expired"
+	}
+	return "valid"
+}
+
+func colonsTime(field string) time.Time {
+	secs, err := strconv.ParseInt(field, 10, 64)
+	if err != nil || secs == 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0).UTC()
+}
+
+// parseGpgColons parses the keys from the output of
+// gpg --with-colons --fixed-list-mode --show-keys.
+// Subkeys inherit the user ID, revocation and expiry of their primary key.
+func parseGpgColons(output string, file string) []gpgKey {
+	var keys []gpgKey
+	primary := -1
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 10 {
+			continue
+		}
+		switch fields[0] {
+		case "pub", "sub":
+			key := gpgKey{
+				file:    file,
+				keyID:   strings.ToUpper(fields[4]),
+				created: colonsTime(fields[5]),
+				expires: colonsTime(fields[6]),
+				revoked: fields[1] == "r",
+			}
+			if fields[0] == "pub" {
+				primary = len(keys)
+			} else if primary >= 0 {
+				key.userID = keys[primary].userID
+				key.revoked = key.revoked || keys[primary].revoked
+				primaryExpires := keys[primary].expires
+				if !primaryExpires.IsZero() &&
+					(key.expires.IsZero() || primaryExpires.Before(key.expires)) {
+					key.expires = primaryExpires
+				}
+			}
+			keys = append(keys, key)
+		case "fpr":
+			if len(keys) > 0 && keys[len(keys)-1].fingerprint == "" {
+				keys[len(keys)-1].fingerprint = strings.ToUpper(fields[9])
+			}
+		case "uid":
+			if primary >= 0 && keys[primary].userID == "" {
+				keys[primary].userID = fields[9]
+			}
+		}
+	}
+	return keys
+}
+
+// readGpgKeys returns the keys in the public-key file
+func readGpgKeys(pubKeyPath string, errPrefix util.ErrPrefix) ([]gpgKey, error) {
+	tmpDir, mkdtErr := os.MkdirTemp("", "eext-keyring")
+	if mkdtErr != nil {
+		return nil, fmt.Errorf("%sError '%s'creating temp dir for keyring",
+			errPrefix, mkdtErr)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	output, err := util.CheckOutput("gpg", "--homedir", tmpDir,
+		"--with-colons", "--fixed-list-mode", "--show-keys", pubKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("%sError '%s' reading public-key %s",
+			errPrefix, err, pubKeyPath)
+	}
+	keys := parseGpgColons(output, filepath.Base(pubKeyPath))
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%sNo keys found in public-key %s",
+			errPrefix, pubKeyPath)
+	}
+	return keys, nil
+}
+
+// readGpgKeysInDir returns the keys in all the public-key files
+// under dir, with the file names relative to dir.
+func readGpgKeysInDir(dir string, errPrefix util.ErrPrefix) ([]gpgKey, error) {
+	var keys []gpgKey
+	walkErr := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !(strings.HasSuffix(path, ".pem") || strings.HasSuffix(path, ".asc")) {
+			return nil
+		}
+		fileKeys, err := readGpgKeys(path, errPrefix)
+		if err != nil {
+			return err
+		}
+		relPath, _ := filepath.Rel(dir, path)
+		for i := range fileKeys {
+			fileKeys[i].file = relPath
+		}
+		keys = append(keys, fileKeys...)
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	return keys, nil
+}
+
+func findGpgKey(keys []gpgKey, keyID string) *gpgKey {
+	for i := range keys {
+		if trustpolicy.KeyAllowed([]string{keyID}, keys[i].fingerprint) {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+// getRpmSigningKeyID returns the ID of the key which signed the RPM
+func getRpmSigningKeyID(rpmPath string, errPrefix util.ErrPrefix) (string, error) {
+	rpmPkg, err := rpmfile.Open(rpmPath)
+	if err != nil {
+		return "", fmt.Errorf("%sError '%s' reading %s", errPrefix, err, rpmPath)
+	}
+	keyIDs, err := rpmPkg.SignatureKeyIDs()
+	if err != nil {
+		return "", fmt.Errorf("%sError '%s' reading signature of %s",
+			errPrefix, err, rpmPath)
+	}
+	if len(keyIDs) == 0 {
+		return "", fmt.Errorf("%s%s isn't signed", errPrefix, rpmPath)
+	}
+	if len(keyIDs) > 1 {
+		return "", fmt.Errorf("%s%s is signed by multiple keys %s",
+			errPrefix, rpmPath, strings.Join(keyIDs, ", "))
+	}
+	return keyIDs[0], nil
+}
+
+// checkSigningKey checks that the signing key is a valid key in rpmKeys,
+// which the trust policy allows to sign the package from the source-bundle.
+func checkSigningKey(keyID string, rpmKeys []gpgKey, policy *trustpolicy.TrustPolicy,
+	pkg string, sourceBundle string, errPrefix util.ErrPrefix) (*gpgKey, error) {
+	key := findGpgKey(rpmKeys, keyID)
+	if key == nil {
+		return nil, fmt.Errorf("%sSigning key %s not found in %s",
+			errPrefix, keyID, getRpmKeysDir())
+	}
+	if status := key.status(time.Now()); status != "valid" {
+		return nil, fmt.Errorf("%sSigning key %s(%s) is %s",
+			errPrefix, keyID, key.file, status)
+	}
+	if policy != nil {
+		allowedKeys := policy.AllowedKeys(pkg, sourceBundle)
+		if allowedKeys != nil && !trustpolicy.KeyAllowed(allowedKeys, key.fingerprint) {
+			return nil, fmt.Errorf("%sSigning key %s(%s) isn't allowed by %s for package %s, allowed keys: %s",
+				errPrefix, keyID, key.file, trustpolicy.TrustPolicyName, pkg,
+				strings.Join(allowedKeys, ", "))
+		}
+	}
+	return key, nil
+}
+
+// verifySrpmSigningKey checks the key which signed the SRPM against the
+// keys in the rpmkeys dir and the trust policy.
+// It returns a description of the signing key.
+func verifySrpmSigningKey(srpmPath string, pkg string, sourceBundle string,
+	errPrefix util.ErrPrefix) (string, error) {
+	keyID, err := getRpmSigningKeyID(srpmPath, errPrefix)
+	if err != nil {
+		return "", err
+	}
+	rpmKeys, err := readGpgKeysInDir(getRpmKeysDir(), errPrefix)
+	if err != nil {
+		return "", err
+	}
+	policy, err := trustpolicy.LoadTrustPolicy()
+	if err != nil {
+		return "", err
+	}
+	key, err := checkSigningKey(keyID, rpmKeys, policy, pkg, sourceBundle, errPrefix)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("rpm key %s(%s)", key.keyID, key.file), nil
+}
+
+func formatKeyTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Format("2006-01-02")
+}
+
+// KeysList lists the keys in the rpmkeys and trusted detached signers
+// dirs in PkiPath, with their expiry and revocation status.
+func KeysList() error {
+	errPrefix := util.ErrPrefix("impl.KeysList: ")
+	now := time.Now()
+	for _, dir := range []string{getRpmKeysDir(), getDetachedSigDir()} {
+		keys, err := readGpgKeysInDir(dir, errPrefix)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s:\n", dir)
+		for _, key := range keys {
+			fmt.Printf("  %s %s %-7s created:%s expires:%s %s %s\n",
+				key.keyID, key.fingerprint, key.status(now),
+				formatKeyTime(key.created), formatKeyTime(key.expires),
+				key.file, key.userID)
+		}
+	}
+	return nil
+}
+
+// checkKeys returns the problems found with the rpm keys
+// and the key IDs in the trust policy.
+func checkKeys(rpmKeys []gpgKey, policy *trustpolicy.TrustPolicy, now time.Time) []string {
+	var problems []string
+	for _, key := range rpmKeys {
+		if status := key.status(now); status != "valid" {
+			problems = append(problems, fmt.Sprintf("key %s in %s is %s",
+				key.keyID, key.file, status))
+		}
+	}
+	if policy != nil {
+		checked := make(map[string]bool)
+		for _, keyID := range policy.KeyIDs() {
+			if checked[keyID] {
+				continue
+			}
+			checked[keyID] = true
+			key := findGpgKey(rpmKeys, keyID)
+			if key == nil {
+				problems = append(problems, fmt.Sprintf("key %s in %s not found in rpmkeys",
+					keyID, trustpolicy.TrustPolicyName))
+			} else if status := key.status(now); status != "valid" {
+				problems = append(problems, fmt.Sprintf("key %s in %s is %s",
+					keyID, trustpolicy.TrustPolicyName, status))
+			}
+		}
+	}
+	return problems
+}
+
+// KeysCheck audits the PKI dir. It checks that the public-keys are readable,
+// that the rpm keys aren't expired or revoked and that the keys in the
+// trust policy are valid rpm keys.
+func KeysCheck() error {
+	errPrefix := util.ErrPrefix("impl.KeysCheck: ")
+	rpmKeys, err := readGpgKeysInDir(getRpmKeysDir(), errPrefix)
+	if err != nil {
+		return err
+	}
+	if _, err := readGpgKeysInDir(getDetachedSigDir(), errPrefix); err != nil {
+		return err
+	}
+	policy, err := trustpolicy.LoadTrustPolicy()
+	if err != nil {
+		return err
+	}
+
+	problems := checkKeys(rpmKeys, policy, time.Now())
+	if len(problems) != 0 {
+		return fmt.Errorf("%sFound problems in %s:\n%s",
+			errPrefix, filepath.Dir(getRpmKeysDir()), strings.Join(problems, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+//go:build containerized
+
+package impl
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/util"
+)
+
+func TestReadGpgKeysInDir(t *testing.T) {
+	keys, err := readGpgKeysInDir("testData/keys", "")
+	require.NoError(t, err)
+
+	now := time.Now()
+	expected := map[string]struct {
+		fingerprint string
+		status      string
+	}{
+		"valid.pem":   {"A7EE3F9C60A33B058DF467CFF28B30B46597A703", "valid"},
+		"expired.pem": {"0E514248DE0BFFE0EA640F0D540EBE3F1EF403B5", "expired"},
+		"revoked.pem": {"84D62C83B5805728AD89D8DFB871B1C592C94402", "revoked"},
+		// Signing subkey of an expired primary
+		"expired-primary.pem": {"26E1E984C16399B0B87BB0B44A5900B5A351959E", "expired"},
+	}
+	for file, exp := range expected {
+		t.Logf("Checking %s", file)
+		key := findGpgKey(keys, exp.fingerprint)
+		require.NotNil(t, key)
+		require.Equal(t, file, key.file)
+		require.Equal(t, exp.fingerprint[24:], key.keyID)
+		require.Equal(t, exp.status, key.status(now))
+	}
+}
+
+func TestNewRpmKeyring(t *testing.T) {
+	viper.Set("PkiPath", "../pki")
+	defer viper.Reset()
+
+	hostKeysBefore, _ := util.CheckOutput("rpm", "-q", "gpg-pubkey")
+	dbPath, err := newRpmKeyring("")
+	require.NoError(t, err)
+	defer os.RemoveAll(dbPath)
+
+	t.Log("Checking keys imported to the private rpmdb")
+	output, err := util.CheckOutput("rpm", "--dbpath", dbPath, "-q", "gpg-pubkey")
+	require.NoError(t, err)
+	// alma9-b86b3716-gpg-pubkey.pem
+	require.Contains(t, output, "gpg-pubkey-b86b3716")
+
+	t.Log("Checking host rpmdb is untouched")
+	hostKeysAfter, _ := util.CheckOutput("rpm", "-q", "gpg-pubkey")
+	require.Equal(t, hostKeysBefore, hostKeysAfter)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/rpmfile"
+	"code.arista.io/eos/tools/eext/trustpolicy"
+)
+
+func TestParseGpgColons(t *testing.T) {
+	output := `pub:-:4096:1:D36CB86CB86B3716:1642516812:0::-:::scSC::::::23::0:
+fpr:::::::::BF18AC2876178908D6E71267D36CB86CB86B3716:
+uid:-::::1642516812::D0A1A3C2A2D7D6B1::AlmaLinux OS 9 <packager@almalinux.org>::::::::::0:
+sub:-:4096:1:B7BB94F0C9BA6CAA:1642516812:1705588812:::::e::::::23:
+fpr:::::::::C96ED6211012570AB3836457B7BB94F0C9BA6CAA:
+pub:r:255:22:B871B1C592C94402:1600000000:::-:::scSC::::::23::0:
+fpr:::::::::84D62C83B5805728AD89D8DFB871B1C592C94402:
+uid:r::::1600000000::0000000000000000::Revoked <revoked@example.com>::::::::::0:
+sub:-:255:18:0123456789ABCDEF:1600000000::::::e::::::23:
+fpr:::::::::AAAAAAAAAAAAAAAAAAAAAAAA0123456789ABCDEF:
+pub:e:255:22:C774B1F8212EEA99:1704067200:1704196800::-:::c:::::ed25519:::0:
+fpr:::::::::5494343FA51BFDFB0C69AE4DC774B1F8212EEA99:
+uid:e::::1704114000::4A51F8CF689588029881197A7886400994719D98::Expired Primary <expired-primary@example.com>::::::::::0:
+sub:e:255:22:4A5900B5A351959E:1704067200::::::s:::::ed25519::
+fpr:::::::::26E1E984C16399B0B87BB0B44A5900B5A351959E:
+`
+	keys := parseGpgColons(output, "keys.pem")
+	require.Len(t, keys, 6)
+
+	require.Equal(t, gpgKey{
+		file:        "keys.pem",
+		keyID:       "D36CB86CB86B3716",
+		fingerprint: "BF18AC2876178908D6E71267D36CB86CB86B3716",
+		userID:      "AlmaLinux OS 9 <packager@almalinux.org>",
+		created:     time.Unix(1642516812, 0).UTC(),
+	}, keys[0])
+	require.Equal(t, "C96ED6211012570AB3836457B7BB94F0C9BA6CAA", keys[1].fingerprint)
+	require.Equal(t, keys[0].userID, keys[1].userID)
+	require.Equal(t, time.Unix(1705588812, 0).UTC(), keys[1].expires)
+
+	now := time.Unix(1700000000, 0)
+	require.Equal(t, "valid", keys[0].status(now))
+	require.Equal(t, "valid", keys[1].status(now))
+	require.Equal(t, "expired", keys[1].status(now.AddDate(1, 0, 0)))
+	require.Equal(t, "revoked", keys[2].status(now))
+	require.Equal(t, "Revoked <revoked@example.com>", keys[3].userID)
+	require.Equal(t, "revoked", keys[3].status(now))
+	// The subkey of an expired primary has no expiry of its own
+	require.Equal(t, time.Unix(1704196800, 0).UTC(), keys[5].expires)
+	require.Equal(t, "expired", keys[5].status(now.AddDate(1, 0, 0)))
+}
+
+func TestCheckSigningKey(t *testing.T) {
+	now := time.Now()
+	rpmKeys := []gpgKey{
+		{
+			file:        "f37.pem",
+			keyID:       "F55AD3FB5323552A",
+			fingerprint: "ACB5EE4E831C74BB7C168D27F55AD3FB5323552A",
+		},
+		{
+			file:        "rocky9.pem",
+			keyID:       "702D426D350D275D",
+			fingerprint: "21CB256AE16FC54C6E652949702D426D350D275D",
+		},
+		{
+			file:        "expired.pem",
+			keyID:       "540EBE3F1EF403B5",
+			fingerprint: "0E514248DE0BFFE0EA640F0D540EBE3F1EF403B5",
+			expires:     now.AddDate(0, 0, -1),
+		},
+		{
+			file:        "revoked.pem",
+			keyID:       "B871B1C592C94402",
+			fingerprint: "84D62C83B5805728AD89D8DFB871B1C592C94402",
+			revoked:     true,
+		},
+	}
+	policy := &trustpolicy.TrustPolicy{
+		Package: map[string][]string{
+			"libfoo": {"702D426D350D275D"},
+		},
+		SourceBundle: map[string][]string{
+			"fedora": {"F55AD3FB5323552A", "540EBE3F1EF403B5"},
+		},
+	}
+
+	t.Log("Testing keys allowed by the trust policy")
+	key, err := checkSigningKey("702D426D350D275D", rpmKeys, policy, "libfoo", "fedora", "")
+	require.NoError(t, err)
+	require.Equal(t, "rocky9.pem", key.file)
+	key, err = checkSigningKey("F55AD3FB5323552A", rpmKeys, policy, "libbar", "fedora", "")
+	require.NoError(t, err)
+	require.Equal(t, "f37.pem", key.file)
+
+	t.Log("Testing any key allowed without a trust policy entry")
+	_, err = checkSigningKey("F55AD3FB5323552A", rpmKeys, policy, "libbar", "", "")
+	require.NoError(t, err)
+	_, err = checkSigningKey("702D426D350D275D", rpmKeys, nil, "libbar", "fedora", "")
+	require.NoError(t, err)
+
+	t.Log("Testing keys disallowed by the trust policy")
+	_, err = checkSigningKey("F55AD3FB5323552A", rpmKeys, policy, "libfoo", "fedora", "")
+	require.ErrorContains(t, err, "isn't allowed")
+	_, err = checkSigningKey("702D426D350D275D", rpmKeys, policy, "libbar", "fedora", "")
+	require.ErrorContains(t, err, "isn't allowed")
+
+	t.Log("Testing expired, revoked and unknown keys")
+	_, err = checkSigningKey("540EBE3F1EF403B5", rpmKeys, policy, "libbar", "fedora", "")
+	require.ErrorContains(t, err, "is expired")
+	_, err = checkSigningKey("B871B1C592C94402", rpmKeys, nil, "libbar", "", "")
+	require.ErrorContains(t, err, "is revoked")
+	_, err = checkSigningKey("0123456789ABCDEF", rpmKeys, nil, "libbar", "", "")
+	require.ErrorContains(t, err, "not found")
+
+	t.Log("Testing checkKeys")
+	problems := checkKeys(rpmKeys, policy, now)
+	require.ElementsMatch(t, []string{
+		"key 540EBE3F1EF403B5 in expired.pem is expired",
+		"key B871B1C592C94402 in revoked.pem is revoked",
+		"key 540EBE3F1EF403B5 in trust-policy.yaml is expired",
+	}, problems)
+	require.Equal(t, []string{"key 540EBE3F1EF403B5 in trust-policy.yaml not found in rpmkeys"},
+		checkKeys(rpmKeys[:2], policy, now))
+	policy.SourceBundle["fedora"] = []string{"F55AD3FB5323552A"}
+	require.Empty(t, checkKeys(rpmKeys[:2], policy, now))
+}
+
+func TestGetRpmSigningKeyID(t *testing.T) {
+	dir := t.TempDir()
+	unsignedRpmPath := writeDummyRpm(t, dir, "unsigned", "src", nil)
+	_, err := getRpmSigningKeyID(unsignedRpmPath, "")
+	require.ErrorContains(t, err, "isn't signed")
+
+	// v3 signature packet made by key 702D426D350D275D
+	sig := []byte{0x88, 0x10, 3, 5, 0, 0x60, 0, 0, 0,
+		0x70, 0x2d, 0x42, 0x6d, 0x35, 0x0d, 0x27, 0x5d, 1, 8}
+	hdr := rpmfile.NewHeader()
+	hdr.SetString(rpmfile.TagName, "signed")
+	rpmPkg := rpmfile.NewPackage(hdr)
+	rpmPkg.Signature.SetBin(rpmfile.SigTagRSA, sig)
+	signedRpmPath := filepath.Join(dir, "signed.src.rpm")
+	file, err := os.Create(signedRpmPath)
+	require.NoError(t, err)
+	require.NoError(t, rpmPkg.Write(file, nil))
+	require.NoError(t, file.Close())
+
+	keyID, err := getRpmSigningKeyID(signedRpmPath, "")
+	require.NoError(t, err)
+	require.Equal(t, "702D426D350D275D", keyID)
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"code.arista.io/eos/tools/eext/dnfconfig"
+	"code.arista.io/eos/tools/eext/lockfile"
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// lockArchs are the target archs for which baseurls are locked.
+var lockArchs = []string{"i686", "x86_64", "aarch64"}
+
+// resolveLock resolves every repo-bundle in the manifest to its concrete
+// version and the baseurl of each of its repos for all lockArchs.
+func resolveLock(repoManifest *manifest.Manifest, dnfConfig *dnfconfig.DnfConfig,
+	errPrefix util.ErrPrefix) (*lockfile.Lockfile, error) {
+	lock := &lockfile.Lockfile{}
+	for _, pkgSpec := range repoManifest.Package {
+		lockedPkg := lockfile.Package{Name: pkgSpec.Name}
+		// Variants of the package share its lock entry, so the
+		// repo-bundles of the build matrix are locked too.
+		bundleSpecs := slices.Clone(pkgSpec.Build.RepoBundle)
+		for _, variant := range pkgSpec.Matrix {
+			bundleSpecs = append(bundleSpecs, variant.RepoBundle...)
+		}
+		for _, bundleSpec := range bundleSpecs {
+			if findLockedBundle(&lockedPkg, bundleSpec.Name, bundleSpec.VersionOverride) != nil {
+				continue
+			}
+			bundleConfig, found := dnfConfig.DnfRepoBundleConfig[bundleSpec.Name]
+			if !found {
+				return nil, fmt.Errorf("%sUnknown repo-bundle name %s in package %s",
+					errPrefix, bundleSpec.Name, pkgSpec.Name)
+			}
+
+			lockedBundle := lockfile.RepoBundle{
+				Name:            bundleSpec.Name,
+				Version:         bundleSpec.VersionOverride,
+				ResolvedVersion: bundleConfig.ResolveVersion(bundleSpec.VersionOverride),
+			}
+
+			reposInBundle := maps.Keys(bundleConfig.DnfRepoConfig)
+			sort.Strings(reposInBundle)
+			for _, repoName := range reposInBundle {
+				lockedRepo := lockfile.Repo{
+					Name:    repoName,
+					BaseURL: make(map[string]string),
+				}
+				for _, arch := range lockArchs {
+					repoParams, err := bundleConfig.GetDnfRepoParams(
+						repoName,
+						arch,
+						bundleSpec.VersionOverride,
+						bundleSpec.DnfRepoParamsOverride,
+						errPrefix)
+					if err != nil {
+						return nil, err
+					}
+					lockedRepo.BaseURL[arch] = repoParams.BaseURL
+				}
+				lockedBundle.Repo = append(lockedBundle.Repo, lockedRepo)
+			}
+			lockedPkg.RepoBundle = append(lockedPkg.RepoBundle, lockedBundle)
+		}
+		lock.Package = append(lock.Package, lockedPkg)
+	}
+	return lock, nil
+}
+
+func bundleDisplayName(bundle *lockfile.RepoBundle) string {
+	version := bundle.Version
+	if version == "" {
+		version = "default"
+	}
+	return fmt.Sprintf("%s(%s)", bundle.Name, version)
+}
+
+// findLockedBundle looks up the locked repo-bundle with the same
+// name and version as specified in the manifest.
+func findLockedBundle(lockedPkg *lockfile.Package, name string, version string) *lockfile.RepoBundle {
+	for i := range lockedPkg.RepoBundle {
+		lockedBundle := &lockedPkg.RepoBundle[i]
+		if lockedBundle.Name == name && lockedBundle.Version == version {
+			return lockedBundle
+		}
+	}
+	return nil
+}
+
+// diffLocks returns a human readable list of changes
+// going from oldLock to newLock.
+func diffLocks(oldLock *lockfile.Lockfile, newLock *lockfile.Lockfile) []string {
+	var changes []string
+	for i := range newLock.Package {
+		newPkg := &newLock.Package[i]
+		oldPkg := oldLock.GetPackage(newPkg.Name)
+		if oldPkg == nil {
+			changes = append(changes, fmt.Sprintf("package %s: added", newPkg.Name))
+			continue
+		}
+		for j := range newPkg.RepoBundle {
+			newBundle := &newPkg.RepoBundle[j]
+			bundleName := bundleDisplayName(newBundle)
+			oldBundle := findLockedBundle(oldPkg, newBundle.Name, newBundle.Version)
+			if oldBundle == nil {
+				changes = append(changes, fmt.Sprintf("package %s: repo-bundle %s: added",
+					newPkg.Name, bundleName))
+				continue
+			}
+			if oldBundle.ResolvedVersion != newBundle.ResolvedVersion {
+				changes = append(changes, fmt.Sprintf("package %s: repo-bundle %s: version %s -> %s",
+					newPkg.Name, bundleName, oldBundle.ResolvedVersion, newBundle.ResolvedVersion))
+			}
+			for k := range newBundle.Repo {
+				newRepo := &newBundle.Repo[k]
+				oldRepo := oldBundle.GetRepo(newRepo.Name)
+				if oldRepo == nil {
+					changes = append(changes, fmt.Sprintf("package %s: repo-bundle %s: repo %s: added",
+						newPkg.Name, bundleName, newRepo.Name))
+					continue
+				}
+				for _, arch := range lockArchs {
+					if oldRepo.BaseURL[arch] != newRepo.BaseURL[arch] {
+						changes = append(changes, fmt.Sprintf(
+							"package %s: repo-bundle %s: repo %s: %s baseurl %s -> %s",
+							newPkg.Name, bundleName, newRepo.Name, arch,
+							oldRepo.BaseURL[arch], newRepo.BaseURL[arch]))
+					}
+				}
+			}
+			for k := range oldBundle.Repo {
+				if newBundle.GetRepo(oldBundle.Repo[k].Name) == nil {
+					changes = append(changes, fmt.Sprintf("package %s: repo-bundle %s: repo %s: removed",
+						newPkg.Name, bundleName, oldBundle.Repo[k].Name))
+				}
+			}
+		}
+		for j := range oldPkg.RepoBundle {
+			oldBundle := &oldPkg.RepoBundle[j]
+			if findLockedBundle(newPkg, oldBundle.Name, oldBundle.Version) == nil {
+				changes = append(changes, fmt.Sprintf("package %s: repo-bundle %s: removed",
+					newPkg.Name, bundleDisplayName(oldBundle)))
+			}
+		}
+	}
+	for i := range oldLock.Package {
+		if newLock.GetPackage(oldLock.Package[i].Name) == nil {
+			changes = append(changes, fmt.Sprintf("package %s: removed", oldLock.Package[i].Name))
+		}
+	}
+	return changes
+}
+
+// getLockedBaseURL returns the baseurl locked for the repo in the
+// repo-bundle for the target arch.
+// If lockedPkg is nil, the package isn't locked and an empty string is returned.
+// An error is returned if the lock doesn't cover the repo, i.e. it is stale.
+func getLockedBaseURL(lockedPkg *lockfile.Package,
+	bundleName string, version string, repoName string, arch string,
+	errPrefix util.ErrPrefix) (string, error) {
+	if lockedPkg == nil {
+		return "", nil
+	}
+
+	staleErr := func(what string) error {
+		return fmt.Errorf("%s%s not found in %s, the lockfile is stale. "+
+			"Run 'eext lock --update' or pass --ignore-lock",
+			errPrefix, what, lockfile.LockfileName)
+	}
+
+	lockedBundle := findLockedBundle(lockedPkg, bundleName, version)
+	if lockedBundle == nil {
+		return "", staleErr(fmt.Sprintf("repo-bundle %s",
+			bundleDisplayName(&lockfile.RepoBundle{Name: bundleName, Version: version})))
+	}
+	lockedRepo := lockedBundle.GetRepo(repoName)
+	if lockedRepo == nil {
+		return "", staleErr(fmt.Sprintf("repo %s of repo-bundle %s",
+			repoName, bundleDisplayName(lockedBundle)))
+	}
+	baseURL, found := lockedRepo.BaseURL[arch]
+	if !found {
+		return "", staleErr(fmt.Sprintf("%s baseurl for repo %s of repo-bundle %s",
+			arch, repoName, bundleDisplayName(lockedBundle)))
+	}
+	return baseURL, nil
+}
+
+// Lock resolves the repo-bundles of all packages in the manifest and
+// writes the results to the eext.lock file in the repo.
+// If a lockfile already exists and is out of date, an error is returned
+// listing the changes, unless update is set, in which case the changes
+// are printed and the lockfile is rewritten.
+func Lock(repo string, update bool) error {
+	errPrefix := util.ErrPrefix("impl.Lock: ")
+
+	if err := checkRepo(repo,
+		"",    // pkg
+		false, // isPkgSubdirInRepo
+		false, // isUnmodified
+		errPrefix); err != nil {
+		return err
+	}
+
+	cfgPath := viper.GetString("DnfConfigFile")
+	dnfConfig, dnfConfigErr := dnfconfig.LoadDnfConfig(cfgPath)
+	if dnfConfigErr != nil {
+		return dnfConfigErr
+	}
+
+	repoManifest, loadManifestErr := manifest.LoadManifest(repo)
+	if loadManifestErr != nil {
+		return loadManifestErr
+	}
+
+	newLock, resolveErr := resolveLock(repoManifest, dnfConfig, errPrefix)
+	if resolveErr != nil {
+		return resolveErr
+	}
+
+	oldLock, loadLockErr := lockfile.LoadLockfile(repo)
+	if loadLockErr != nil {
+		return loadLockErr
+	}
+
+	if oldLock != nil {
+		changes := diffLocks(oldLock, newLock)
+		if len(changes) == 0 {
+			log.Printf("%s is up to date", lockfile.LockfileName)
+			return nil
+		}
+		if !update {
+			return fmt.Errorf("%s%s is out of date, run 'eext lock --update' to apply these changes:\n  %s",
+				errPrefix, lockfile.LockfileName, strings.Join(changes, "\n  "))
+		}
+		for _, change := range changes {
+			log.Println(change)
+		}
+	}
+
+	if err := lockfile.WriteLockfile(repo, newLock); err != nil {
+		return err
+	}
+	log.Println("SUCCESS: lock")
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/dnfconfig"
+	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/lockfile"
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/testutil"
+)
+
+func TestLock(t *testing.T) {
+	testWorkingDir, err := os.MkdirTemp("", "lock-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testWorkingDir)
+
+	srcDir := filepath.Join(testWorkingDir, "src")
+	workDir := filepath.Join(testWorkingDir, "work")
+	for _, subdir := range []string{srcDir, workDir} {
+		os.Mkdir(subdir, 0775)
+	}
+
+	pkg := "pkg1"
+	testutil.SetupManifest(t, srcDir, pkg, "manifest.yaml")
+	testutil.SetupViperConfig(
+		srcDir,
+		workDir,
+		"",                        // destDir
+		"",                        // srpmsDir
+		"",                        // depsDir
+		"https://foo.org",         // repoHost
+		"testData/dnfconfig.yaml", // dnfConfigFile
+		"",                        // srcRepoHost
+		"",                        // srcConfigFile
+		"",                        // srcRepoPathPrefix
+	)
+	defer viper.Reset()
+
+	t.Log("Testing Lock creates the lockfile")
+	require.NoError(t, Lock(pkg, false))
+	lock, loadErr := lockfile.LoadLockfile(pkg)
+	require.NoError(t, loadErr)
+	require.NotNil(t, lock)
+	lockedPkg := lock.GetPackage(pkg)
+	require.NotNil(t, lockedPkg)
+	require.Len(t, lockedPkg.RepoBundle, 3)
+	require.Equal(t, "v1", lockedPkg.RepoBundle[0].ResolvedVersion)
+	require.Equal(t, "v2", lockedPkg.RepoBundle[1].ResolvedVersion)
+	require.Equal(t, "v3", lockedPkg.RepoBundle[2].ResolvedVersion)
+	require.Equal(t, "https://foo.org/boo2-v3/repo-roo21/x86_64/",
+		lockedPkg.RepoBundle[2].Repo[0].BaseURL["i686"])
+	require.Equal(t, "https://foo.org/boo1-v1/repo-roo11/i686/",
+		lockedPkg.RepoBundle[0].Repo[0].BaseURL["i686"])
+
+	t.Log("Testing Lock with an up to date lockfile")
+	require.NoError(t, Lock(pkg, false))
+
+	t.Log("Testing Lock with an out of date lockfile")
+	lockedPkg.RepoBundle[2].ResolvedVersion = "v0"
+	lockedPkg.RepoBundle[2].Repo[0].BaseURL["x86_64"] = "https://foo.org/boo2-v0/repo-roo21/x86_64/"
+	require.NoError(t, lockfile.WriteLockfile(pkg, lock))
+	lockErr := Lock(pkg, false)
+	require.ErrorContains(t, lockErr, "repo-bundle bundle-boo2(latest): version v0 -> v3")
+	require.ErrorContains(t, lockErr,
+		"repo repo-roo21: x86_64 baseurl https://foo.org/boo2-v0/repo-roo21/x86_64/ -> https://foo.org/boo2-v3/repo-roo21/x86_64/")
+
+	t.Log("Testing mock config honours the lockfile")
+	dnfConfig, dnfConfigErr := dnfconfig.LoadDnfConfig(viper.GetString("DnfConfigFile"))
+	require.NoError(t, dnfConfigErr)
+	manifestObj, manifestErr := manifest.LoadManifest(pkg)
+	require.NoError(t, manifestErr)
+	cfgBldr := mockCfgBuilder{
+		builderCommon: &builderCommon{
+			pkg:       pkg,
+			arch:      "x86_64",
+			buildSpec: &manifestObj.Package[0].Build,
+			dnfConfig: dnfConfig,
+			executor:  &executor.OsExecutor{},
+			lockedPkg: lockedPkg,
+		},
+	}
+	require.NoError(t, cfgBldr.populateTemplateData())
+	lastRepo := cfgBldr.templateData.Repo[len(cfgBldr.templateData.Repo)-1]
+	require.Equal(t, "https://foo.org/boo2-v0/repo-roo21/x86_64/", lastRepo.BaseURL)
+
+	t.Log("Testing mock config with a stale lockfile")
+	lockedPkg.RepoBundle = lockedPkg.RepoBundle[:2]
+	require.ErrorContains(t, cfgBldr.populateTemplateData(), "lockfile is stale")
+
+	t.Log("Testing Lock --update")
+	require.NoError(t, Lock(pkg, true))
+	require.NoError(t, Lock(pkg, false))
+	lock, loadErr = lockfile.LoadLockfile(pkg)
+	require.NoError(t, loadErr)
+	require.Equal(t, "v3", lock.GetPackage(pkg).RepoBundle[2].ResolvedVersion)
+}
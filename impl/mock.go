@@ -16,6 +16,7 @@ import (
 
 	"code.arista.io/eos/tools/eext/dnfconfig"
 	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/lockfile"
 	"code.arista.io/eos/tools/eext/manifest"
 	"code.arista.io/eos/tools/eext/util"
 )
@@ -25,15 +26,20 @@ type mockBuilder struct {
 
 	onlyCreateCfg bool
 	noCheck       bool
+	with          []string
+	without       []string
 	errPrefixBase util.ErrPrefix
 
 	srpmPath string
 }
 
 // MockExtraCmdlineArgs is a bundle of extra args for impl.Mock
+// Variant selects the build variant from the package build matrix.
 type MockExtraCmdlineArgs struct {
 	NoCheck       bool
 	OnlyCreateCfg bool
+	IgnoreLock    bool
+	Variant       string
 }
 
 func (bldr *mockBuilder) log(format string, a ...any) {
@@ -116,6 +122,9 @@ func (bldr *mockBuilder) setupDeps() error {
 				panic(fmt.Sprintf("Bad glob pattern %s: %s", pathGlob, globErr))
 			}
 			if paths != nil {
+				if err := checkRpmFiles(pathGlob, arch, bldr.errPrefix); err != nil {
+					return err
+				}
 				depStatisfied = true
 				copyDestDir := filepath.Join(mockDepsDir, arch, dep)
 				pathMap[copyDestDir] = pathGlob
@@ -196,6 +205,18 @@ func (bldr *mockBuilder) printLogFile(filename string) {
 	bldr.log("--- end of %s ---", filename)
 }
 
+// conditionalArgs returns the rpmbuild --with/--without args for mock
+func (bldr *mockBuilder) conditionalArgs() []string {
+	var args []string
+	for _, cond := range bldr.with {
+		args = append(args, "--with="+cond)
+	}
+	for _, cond := range bldr.without {
+		args = append(args, "--without="+cond)
+	}
+	return args
+}
+
 func (bldr *mockBuilder) runMockCmd(extraArgs []string) error {
 	mockArgs := bldr.mockArgs(extraArgs)
 	bldr.log("Running mock %s", strings.Join(mockArgs, " "))
@@ -227,7 +248,8 @@ func (bldr *mockBuilder) runFedoraMockStages() error {
 	if bldr.arch != "i686" {
 		bldr.setupStageErrPrefix("installdeps")
 		bldr.log("starting")
-		if err := bldr.runMockCmd([]string{"--installdeps"}); err != nil {
+		installdepsArgs := append([]string{"--installdeps"}, bldr.conditionalArgs()...)
+		if err := bldr.runMockCmd(installdepsArgs); err != nil {
 			return err
 		}
 		bldr.log("succesful")
@@ -235,6 +257,7 @@ func (bldr *mockBuilder) runFedoraMockStages() error {
 
 	bldr.setupStageErrPrefix("build")
 	buildArgs := []string{"--no-clean", "--rebuild"}
+	buildArgs = append(buildArgs, bldr.conditionalArgs()...)
 	if bldr.noCheck {
 		buildArgs = append(buildArgs, "--nocheck")
 	}
@@ -251,6 +274,36 @@ func (bldr *mockBuilder) runFedoraMockStages() error {
 	return nil
 }
 
+// startFetchProxy starts the fetch proxy which enforces the network policy
+// of the package, the chroot is pointed to it by the mock configuration.
+func (bldr *mockBuilder) startFetchProxy() error {
+	proxy, err := newFetchProxy(bldr.buildSpec.Network)
+	if err != nil {
+		return fmt.Errorf("%sError '%s' creating fetch proxy", bldr.errPrefix, err)
+	}
+	if err := proxy.writeCa(); err != nil {
+		proxy.close()
+		return fmt.Errorf("%sError '%s' writing CA of fetch proxy to %s",
+			bldr.errPrefix, err, proxy.dir)
+	}
+	if err := proxy.writeForwarder(); err != nil {
+		proxy.close()
+		return fmt.Errorf("%sError '%s' writing forwarder of fetch proxy to %s",
+			bldr.errPrefix, err, proxy.dir)
+	}
+	proxy.start()
+	bldr.fetchProxy = proxy
+	bldr.log("fetch proxy listening at %s", proxy.socketPath())
+	return nil
+}
+
+// stopFetchProxy stops the fetch proxy, if any, and removes its dir.
+func (bldr *mockBuilder) stopFetchProxy() {
+	if bldr.fetchProxy != nil {
+		bldr.fetchProxy.close()
+	}
+}
+
 // Copy built RPMs out to DestDir/RPMS/<rpmArch>/<pkg>/foo.<rpmArch>.rpm
 func (bldr *mockBuilder) copyResultsToDestDir() error {
 	arch := bldr.arch
@@ -261,6 +314,9 @@ func (bldr *mockBuilder) copyResultsToDestDir() error {
 		pkgRpmsDestDirForArch := getPkgRpmsDestDir(bldr.pkg, rpmArch)
 		globPattern := filepath.Join(mockResultsDir,
 			fmt.Sprintf("*.%s.rpm", rpmArch))
+		if err := checkRpmFiles(globPattern, rpmArch, bldr.errPrefix); err != nil {
+			return err
+		}
 		pathMap[pkgRpmsDestDirForArch] = globPattern
 	}
 	copyErr := filterAndCopy(pathMap, bldr.executor, bldr.errPrefix)
@@ -273,9 +329,11 @@ func (bldr *mockBuilder) copyResultsToDestDir() error {
 // This is the entry point to mockBuilder
 // It runs the stages to build the RPMS from a modified SRPM built previously.
 // It expects the SRPM to be already present in <DestDir>/SRPMS/<package>/
-// Stages: Fetch SRPM, Clean, Create Mock Configuration,
-// Run Fedora Mock(has substages),
+// Stages: Fetch SRPM, Clean, Start Fetch Proxy, Create Mock Configuration,
+// Run Fedora Mock(has substages), Write Report,
 // CopyResultsToDestDir
+// The report is written even if mock fails, to show what the build
+// fetched/was blocked from fetching.
 func (bldr *mockBuilder) runStages() error {
 	bldr.setupStageErrPrefix("fetchSrpm")
 	if err := bldr.fetchSrpm(); err != nil {
@@ -296,20 +354,40 @@ func (bldr *mockBuilder) runStages() error {
 		}
 	}
 
+	// The mock configuration generated with only-create-cfg doesn't
+	// point to a fetch proxy, as eext doesn't run the build.
+	if bldr.buildSpec.Network != nil && !bldr.onlyCreateCfg {
+		bldr.setupStageErrPrefix("startFetchProxy")
+		if err := bldr.startFetchProxy(); err != nil {
+			return err
+		}
+		defer bldr.stopFetchProxy()
+	}
+
 	bldr.setupStageErrPrefix("createCfg")
 	if err := bldr.createCfg(); err != nil {
 		return err
 	}
 	if bldr.onlyCreateCfg {
 		bldr.setupStageErrPrefix("")
+		if bldr.buildSpec.Network != nil {
+			bldr.log("The network policy isn't applied to the mock config, builds with it have no network access")
+		}
 		mockArgs := bldr.mockArgs([]string{"[<extra-args>] [<sub-cmd>]"})
 		bldr.log("Mock config has been created. If you want to run mock natively use: 'mock %s'",
 			strings.Join(mockArgs, " "))
 		return nil
 	}
 
-	if err := bldr.runFedoraMockStages(); err != nil {
-		return err
+	mockErr := bldr.runFedoraMockStages()
+	bldr.stopFetchProxy()
+	bldr.setupStageErrPrefix("writeReport")
+	reportErr := bldr.writeReport()
+	if mockErr != nil {
+		return mockErr
+	}
+	if reportErr != nil {
+		return reportErr
 	}
 
 	bldr.setupStageErrPrefix("copyResultsToDestDir")
@@ -325,7 +403,7 @@ func (bldr *mockBuilder) runStages() error {
 // <DestDir>/RPMS/<rpmArch>/<package>/
 // 'arch' cannot be empty, needs to be a valid architecture.
 func Mock(repo string, pkg string, arch string, extraArgs MockExtraCmdlineArgs, executor executor.Executor) error {
-	if err := setup(executor); err != nil {
+	if err := setup(); err != nil {
 		return err
 	}
 
@@ -359,6 +437,16 @@ func Mock(repo string, pkg string, arch string, extraArgs MockExtraCmdlineArgs,
 		return loadManifestErr
 	}
 
+	// Repo bundles are pinned to the lockfile, if the repo has one.
+	var lock *lockfile.Lockfile
+	if !extraArgs.IgnoreLock {
+		var loadLockErr error
+		lock, loadLockErr = lockfile.LoadLockfile(repo)
+		if loadLockErr != nil {
+			return loadLockErr
+		}
+	}
+
 	var pkgSpecified bool = (pkg != "")
 	found := !pkgSpecified
 	for _, pkgSpec := range repoManifest.Package {
@@ -368,9 +456,29 @@ func Mock(repo string, pkg string, arch string, extraArgs MockExtraCmdlineArgs,
 		}
 		found = true
 
+		buildSpec := &pkgSpec.Build
+		var with, without []string
+		pkgNameWithVariant := thisPkgName
+		if extraArgs.Variant != "" {
+			variant := pkgSpec.GetVariant(extraArgs.Variant)
+			if variant == nil {
+				return fmt.Errorf("impl.Mock: Variant %s not found in matrix of package %s",
+					extraArgs.Variant, thisPkgName)
+			}
+			if variant.Arches != nil && !slices.Contains(variant.Arches, arch) {
+				log.Printf("impl.Mock: Skipping variant %s of package %s, not built for %s",
+					variant.Name, thisPkgName, arch)
+				continue
+			}
+			buildSpec = pkgSpec.GetVariantBuild(variant)
+			with = variant.With
+			without = variant.Without
+			pkgNameWithVariant = fmt.Sprintf("%s-%s", thisPkgName, variant.Name)
+		}
+
 		errPrefixBase := util.ErrPrefix(fmt.Sprintf(
 			"mockBuilder(%s-%s)",
-			thisPkgName, arch))
+			pkgNameWithVariant, arch))
 		errPrefix := util.ErrPrefix(fmt.Sprintf(
 			"%s: ", errPrefixBase))
 
@@ -384,7 +492,17 @@ func Mock(repo string, pkg string, arch string, extraArgs MockExtraCmdlineArgs,
 			return err
 		}
 
-		dependencyMap := pkgSpec.Build.Dependencies
+		var lockedPkg *lockfile.Package
+		if lock != nil {
+			lockedPkg = lock.GetPackage(thisPkgName)
+			if lockedPkg == nil {
+				return fmt.Errorf("%sPackage not found in %s, the lockfile is stale. "+
+					"Run 'eext lock --update' or pass --ignore-lock",
+					errPrefix, lockfile.LockfileName)
+			}
+		}
+
+		dependencyMap := buildSpec.Dependencies
 		// golang allows accessing keys of an empty/nil map, without throwing an error.
 		// If a key is not present in the map, it returns an empty instance of the value.
 		dependencyList := append(dependencyMap["all"], dependencyMap[arch]...)
@@ -392,20 +510,24 @@ func Mock(repo string, pkg string, arch string, extraArgs MockExtraCmdlineArgs,
 		bldr := &mockBuilder{
 			builderCommon: &builderCommon{
 				pkg:               thisPkgName,
+				variant:           extraArgs.Variant,
 				repo:              repo,
 				isPkgSubdirInRepo: pkgSpec.Subdir,
 				arch:              arch,
 				rpmReleaseMacro:   rpmReleaseMacro,
 				eextSignature:     eextSignature,
-				buildSpec:         &pkgSpec.Build,
+				buildSpec:         buildSpec,
 				dnfConfig:         dnfConfig,
 				errPrefix:         errPrefix,
 				dependencyList:    dependencyList,
-				enableNetwork:     pkgSpec.Build.EnableNetwork,
+				enableNetwork:     buildSpec.EnableNetwork,
 				executor:          executor,
+				lockedPkg:         lockedPkg,
 			},
 			onlyCreateCfg: extraArgs.OnlyCreateCfg,
 			noCheck:       extraArgs.NoCheck,
+			with:          with,
+			without:       without,
 			errPrefixBase: errPrefixBase,
 			srpmPath:      "",
 		}
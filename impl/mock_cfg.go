@@ -7,27 +7,83 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strings"
 
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 
 	"code.arista.io/eos/tools/eext/dnfconfig"
 	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/lockfile"
 	"code.arista.io/eos/tools/eext/manifest"
 	"code.arista.io/eos/tools/eext/util"
 )
 
 // MockCfgTemplateData is used to execute the mock config template
 type MockCfgTemplateData struct {
-	DefaultCommonCfg map[string]string
-	Macros           map[string]string
-	Repo             []*dnfconfig.DnfRepoParams
-	Includes         []string
+	Releasever         string
+	PlatformID         string
+	DefaultCommonCfg   map[string]string
+	Macros             map[string]string
+	ChrootSetupPkgs    []string
+	RpmbuildNetworking bool
+	RpmbuildCommand    string
+	Environment        map[string]string
+	BindMounts         []manifest.BindMount
+	Tmpfs              []manifest.TmpfsMount
+	Plugins            map[string]bool
+	Repo               []*dnfconfig.DnfRepoParams
+	ModuleSetupCmds    []dnfconfig.DnfModuleCmd
+	Includes           []string
 }
 
+// defaultDistro is the distro profile used if the manifest doesn't specify one
+const defaultDistro = "el9"
+
+// eextOwnedMacros are the macros set by eext in the mock configuration,
+// these can't be set from the manifest.
+var eextOwnedMacros = []string{
+	"eext_release",
+	"distribution",
+	"_buildhost",
+	"_preprocessor_defines",
+	"__brp_strip_static_archive",
+	"source_date_epoch_from_changelog",
+	"use_source_date_epoch_as_buildtime",
+	"clamp_mtime_to_source_date_epoch",
+}
+
+// mockPlugins are the mock plugins which can be toggled from the manifest.
+// bind_mount and mount are left out as they are
+// configured from bind-mounts and tmpfs in the manifest.
+var mockPlugins = []string{
+	"ccache",
+	"chroot_scan",
+	"compress_logs",
+	"hw_info",
+	"package_state",
+	"pm_request",
+	"procenv",
+	"root_cache",
+	"selinux",
+	"showrc",
+	"tmpfs",
+	"yum_cache",
+}
+
+var (
+	mockCfgNameRegex  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	mockCfgValueRegex = regexp.MustCompile(`^[^'\\\n]*$`)
+	chrootPkgRegex    = regexp.MustCompile(`^[^\s'"\\]+$`)
+	tmpfsSizeRegex    = regexp.MustCompile(`^[0-9]+[kmgKMG%]?$`)
+)
+
 // Common config used by both mockBuilder and mockCfgBuilder
 type builderCommon struct {
 	pkg               string
+	variant           string
 	repo              string
 	isPkgSubdirInRepo bool
 	arch              string
@@ -39,6 +95,10 @@ type builderCommon struct {
 	dependencyList    []string
 	enableNetwork     bool
 	executor          executor.Executor
+	// lockedPkg is nil if the package isn't to be built from a lockfile
+	lockedPkg *lockfile.Package
+	// fetchProxy is nil if the package doesn't have a network policy
+	fetchProxy *fetchProxy
 }
 
 type mockCfgBuilder struct {
@@ -56,7 +116,7 @@ func (cfgBldr *mockCfgBuilder) populateTemplateData() error {
 	cfgBldr.templateData = &MockCfgTemplateData{}
 	cfgBldr.templateData.DefaultCommonCfg = map[string]string{
 		"target_arch": arch,
-		"root":        getMockChrootDirName(pkg, arch),
+		"root":        getMockChrootDirName(pkg, cfgBldr.variant, arch),
 		"resultdir":   getMockResultsDir(pkg, arch),
 	}
 
@@ -71,6 +131,30 @@ func (cfgBldr *mockCfgBuilder) populateTemplateData() error {
 		cfgBldr.templateData.Macros["distribution"] = eextsigTag
 	}
 
+	distroName := cfgBldr.buildSpec.Distro
+	if distroName == "" {
+		distroName = defaultDistro
+	}
+	distroConfig, found := cfgBldr.dnfConfig.GetDistro(distroName)
+	if !found {
+		return fmt.Errorf("%sUnknown distro %s", cfgBldr.errPrefix, distroName)
+	}
+	cfgBldr.templateData.Releasever = distroConfig.Releasever
+	cfgBldr.templateData.PlatformID = distroConfig.PlatformID
+	cfgBldr.templateData.ChrootSetupPkgs = distroConfig.ChrootSetupPkgs
+	for macro, value := range distroConfig.Macros {
+		if slices.Contains(eextOwnedMacros, macro) {
+			return fmt.Errorf("%sMacro %s in distro %s conflicts with a macro set by eext",
+				cfgBldr.errPrefix, macro, distroName)
+		}
+		cfgBldr.templateData.Macros[macro] = value
+	}
+
+	if err := cfgBldr.populateCustomizations(); err != nil {
+		return err
+	}
+
+	var modules []dnfconfig.DnfModules
 	for _, repoBundleSpecifiedInManifest := range cfgBldr.buildSpec.RepoBundle {
 		bundleName := repoBundleSpecifiedInManifest.Name
 		bundleVersionOverride := repoBundleSpecifiedInManifest.VersionOverride
@@ -81,6 +165,12 @@ func (cfgBldr *mockCfgBuilder) populateTemplateData() error {
 			return fmt.Errorf("%sUnknown repo-bundle name %s",
 				cfgBldr.errPrefix, bundleName)
 		}
+		if !bundleConfig.InDistro(distroName) {
+			return fmt.Errorf("%srepo-bundle %s doesn't belong to distro %s",
+				cfgBldr.errPrefix, bundleName, distroName)
+		}
+
+		modules = append(modules, bundleConfig.Modules, repoBundleSpecifiedInManifest.Modules)
 
 		for overrideRepo, _ := range bundleRepoOverrides {
 			_, isValidRepo := bundleConfig.DnfRepoConfig[overrideRepo]
@@ -104,10 +194,26 @@ func (cfgBldr *mockCfgBuilder) populateTemplateData() error {
 				return err
 			}
 
+			lockedBaseURL, lockErr := getLockedBaseURL(cfgBldr.lockedPkg,
+				bundleName, bundleVersionOverride, repoName, arch,
+				cfgBldr.errPrefix)
+			if lockErr != nil {
+				return lockErr
+			}
+			if lockedBaseURL != "" {
+				repoParams.BaseURL = lockedBaseURL
+			}
+
 			cfgBldr.templateData.Repo = append(cfgBldr.templateData.Repo, repoParams)
 		}
 	}
 
+	moduleSetupCmds, modulesErr := dnfconfig.MergeDnfModules(modules...)
+	if modulesErr != nil {
+		return fmt.Errorf("%sBad modules specified: %s", cfgBldr.errPrefix, modulesErr)
+	}
+	cfgBldr.templateData.ModuleSetupCmds = moduleSetupCmds
+
 	if len(cfgBldr.dependencyList) != 0 {
 		localRepo := &dnfconfig.DnfRepoParams{
 			Name:     "local-deps",
@@ -135,6 +241,129 @@ func (cfgBldr *mockCfgBuilder) populateTemplateData() error {
 	return nil
 }
 
+// populateCustomizations validates the mock customizations in the
+// manifest Build spec and sets them up in templateData.
+// Anything conflicting with what eext sets up is rejected.
+func (cfgBldr *mockCfgBuilder) populateCustomizations() error {
+	buildSpec := cfgBldr.buildSpec
+	templateData := cfgBldr.templateData
+
+	checkValue := func(what string, value string) error {
+		if !mockCfgValueRegex.MatchString(value) {
+			return fmt.Errorf("%sBad value '%s' for %s in manifest, single quotes, backslashes and newlines aren't allowed",
+				cfgBldr.errPrefix, value, what)
+		}
+		return nil
+	}
+	checkChrootPath := func(what string, path string) error {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("%s%s %s in manifest should be an absolute path in the chroot",
+				cfgBldr.errPrefix, what, path)
+		}
+		return checkValue(what, path)
+	}
+
+	for macro, value := range buildSpec.Macros {
+		if slices.Contains(eextOwnedMacros, macro) {
+			return fmt.Errorf("%sMacro %s in manifest conflicts with a macro set by eext",
+				cfgBldr.errPrefix, macro)
+		}
+		if !mockCfgNameRegex.MatchString(macro) {
+			return fmt.Errorf("%sBad macro name '%s' in manifest", cfgBldr.errPrefix, macro)
+		}
+		if err := checkValue("macro "+macro, value); err != nil {
+			return err
+		}
+		templateData.Macros[macro] = value
+	}
+
+	for _, pkg := range buildSpec.ChrootSetupPkgs {
+		if !chrootPkgRegex.MatchString(pkg) {
+			return fmt.Errorf("%sBad package '%s' in chroot-setup-pkgs in manifest",
+				cfgBldr.errPrefix, pkg)
+		}
+	}
+	templateData.ChrootSetupPkgs = append(
+		slices.Clone(templateData.ChrootSetupPkgs), buildSpec.ChrootSetupPkgs...)
+	templateData.RpmbuildNetworking = buildSpec.RpmbuildNetworking
+
+	for envVar, value := range buildSpec.Environment {
+		if !mockCfgNameRegex.MatchString(envVar) {
+			return fmt.Errorf("%sBad environment variable name '%s' in manifest",
+				cfgBldr.errPrefix, envVar)
+		}
+		if err := checkValue("environment variable "+envVar, value); err != nil {
+			return err
+		}
+	}
+	templateData.Environment = buildSpec.Environment
+	if cfgBldr.fetchProxy != nil {
+		templateData.Environment = make(map[string]string)
+		for envVar, value := range buildSpec.Environment {
+			templateData.Environment[envVar] = value
+		}
+		for envVar, value := range cfgBldr.fetchProxy.env() {
+			if _, found := buildSpec.Environment[envVar]; found {
+				return fmt.Errorf("%sEnvironment variable %s in manifest conflicts with the network policy",
+					cfgBldr.errPrefix, envVar)
+			}
+			templateData.Environment[envVar] = value
+		}
+	}
+
+	pkgDirInRepo := getPkgDirInRepo(cfgBldr.repo, cfgBldr.pkg, cfgBldr.isPkgSubdirInRepo)
+	for _, bindMount := range buildSpec.BindMounts {
+		if bindMount.Source == "" {
+			return fmt.Errorf("%sNo source specified for bind-mount %s in manifest",
+				cfgBldr.errPrefix, bindMount.Target)
+		}
+		if err := checkChrootPath("bind-mount target", bindMount.Target); err != nil {
+			return err
+		}
+		source := bindMount.Source
+		if !filepath.IsAbs(source) {
+			var absErr error
+			if source, absErr = filepath.Abs(filepath.Join(pkgDirInRepo, source)); absErr != nil {
+				return fmt.Errorf("%sError '%s' resolving bind-mount source %s",
+					cfgBldr.errPrefix, absErr, bindMount.Source)
+			}
+		}
+		if err := checkValue("bind-mount source", source); err != nil {
+			return err
+		}
+		templateData.BindMounts = append(templateData.BindMounts,
+			manifest.BindMount{Source: source, Target: bindMount.Target})
+	}
+
+	if cfgBldr.fetchProxy != nil {
+		templateData.BindMounts = append(templateData.BindMounts, manifest.BindMount{
+			Source: cfgBldr.fetchProxy.dir,
+			Target: fetchProxyChrootDir,
+		})
+		templateData.RpmbuildCommand = filepath.Join(fetchProxyChrootDir, fetchProxyRpmbuildFilename)
+	}
+
+	for _, tmpfs := range buildSpec.Tmpfs {
+		if err := checkChrootPath("tmpfs target", tmpfs.Target); err != nil {
+			return err
+		}
+		if tmpfs.Size != "" && !tmpfsSizeRegex.MatchString(tmpfs.Size) {
+			return fmt.Errorf("%sBad size '%s' for tmpfs %s in manifest",
+				cfgBldr.errPrefix, tmpfs.Size, tmpfs.Target)
+		}
+	}
+	templateData.Tmpfs = buildSpec.Tmpfs
+
+	for plugin := range buildSpec.Plugins {
+		if !slices.Contains(mockPlugins, plugin) {
+			return fmt.Errorf("%sUnsupported mock plugin %s in manifest, must be one of %s",
+				cfgBldr.errPrefix, plugin, strings.Join(mockPlugins, ", "))
+		}
+	}
+	templateData.Plugins = buildSpec.Plugins
+	return nil
+}
+
 // Create mock configuration directory
 // Copy over any include files from source repo to mock configuration directory.
 func (cfgBldr *mockCfgBuilder) prep() error {
@@ -4,6 +4,7 @@
 package impl
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -141,3 +142,280 @@ func TestMockConfigUnchained(t *testing.T) {
 func TestMockConfigChained(t *testing.T) {
 	testMockConfig(t, true)
 }
+
+func TestMockConfigCustomizations(t *testing.T) {
+	workDir, err := os.MkdirTemp("", "mock-cfg-customizations-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	viper.Set("WorkingDir", workDir)
+	viper.Set("SrcDir", "/src")
+	defer viper.Reset()
+
+	buildSpec := manifest.Build{
+		Macros:             map[string]string{"_with_foo": "1", "foo_flags": `-DFOO="bar"`},
+		ChrootSetupPkgs:    []string{"perl(Foo::Bar)", "git"},
+		RpmbuildNetworking: true,
+		Environment:        map[string]string{"GOFLAGS": "-mod=vendor"},
+		BindMounts: []manifest.BindMount{
+			{Source: "/var/cache/foo", Target: "/var/cache/foo"},
+			{Source: "data", Target: "/opt/data"},
+		},
+		Tmpfs:   []manifest.TmpfsMount{{Target: "/var/tmp", Size: "2g"}},
+		Plugins: map[string]bool{"ccache": true, "root_cache": false},
+	}
+	cfgBldr := mockCfgBuilder{
+		builderCommon: &builderCommon{
+			pkg:               "pkg1",
+			repo:              "repo1",
+			isPkgSubdirInRepo: true,
+			arch:              "x86_64",
+			buildSpec:         &buildSpec,
+			dnfConfig: &dnfconfig.DnfConfig{
+				Distro: map[string]*dnfconfig.DistroConfig{
+					"el9": {
+						Releasever:      "9",
+						PlatformID:      "platform:el9",
+						ChrootSetupPkgs: []string{"bash", "rpm-build"},
+					},
+				},
+			},
+			errPrefix: "TestMockConfigCustomizations: ",
+			executor:  &executor.OsExecutor{},
+		},
+	}
+	require.NoError(t, cfgBldr.populateTemplateData())
+
+	mockCfgTemplate, parseErr := template.ParseFiles("../configfiles/mock.cfg.template")
+	require.NoError(t, parseErr)
+	var mockCfg bytes.Buffer
+	require.NoError(t, mockCfgTemplate.Execute(&mockCfg, cfgBldr.templateData))
+	for _, expectedLine := range []string{
+		`config_opts['chroot_setup_cmd'] = "install bash rpm-build perl(Foo::Bar) git"`,
+		`config_opts['macros']['_with_foo'] = '1'`,
+		`config_opts['macros']['foo_flags'] = '-DFOO="bar"'`,
+		`config_opts['rpmbuild_networking'] = True`,
+		`config_opts['environment']['GOFLAGS'] = '-mod=vendor'`,
+		`config_opts['plugin_conf']['bind_mount_enable'] = True`,
+		`config_opts['plugin_conf']['bind_mount_opts']['dirs'].append(('/var/cache/foo', '/var/cache/foo'))`,
+		`config_opts['plugin_conf']['bind_mount_opts']['dirs'].append(('/src/repo1/pkg1/data', '/opt/data'))`,
+		`config_opts['plugin_conf']['mount_opts']['dirs'].append(('tmpfs', '/var/tmp', 'tmpfs', 'size=2g'))`,
+		`config_opts['plugin_conf']['ccache_enable'] = True`,
+		`config_opts['plugin_conf']['root_cache_enable'] = False`,
+	} {
+		require.Contains(t, mockCfg.String(), expectedLine+"\n")
+	}
+
+	badBuildSpecs := map[string]manifest.Build{
+		"conflicts with a macro set by eext": {Macros: map[string]string{"_buildhost": "myhost"}},
+		"Bad macro name":                     {Macros: map[string]string{"foo bar": "1"}},
+		"single quotes":                      {Environment: map[string]string{"FOO": "it's"}},
+		"Bad environment variable name":      {Environment: map[string]string{"1FOO": "bar"}},
+		"Bad package":                        {ChrootSetupPkgs: []string{"foo bar"}},
+		"should be an absolute path":         {Tmpfs: []manifest.TmpfsMount{{Target: "tmp"}}},
+		"Bad size":                           {Tmpfs: []manifest.TmpfsMount{{Target: "/tmp", Size: "lots"}}},
+		"No source specified":                {BindMounts: []manifest.BindMount{{Target: "/opt"}}},
+		"Unsupported mock plugin":            {Plugins: map[string]bool{"bind_mount": false}},
+		"conflicts with the network policy": {
+			Environment: map[string]string{"https_proxy": "http://proxy.example.org:3128"},
+		},
+	}
+	policy := &manifest.NetworkPolicy{Allow: []string{"proxy.golang.org"}}
+	proxy, err := newFetchProxy(policy)
+	require.NoError(t, err)
+	defer proxy.close()
+	cfgBldr.fetchProxy = proxy
+	networkBuildSpec := buildSpec
+	networkBuildSpec.Network = policy
+	cfgBldr.buildSpec = &networkBuildSpec
+	require.NoError(t, cfgBldr.populateTemplateData())
+	mockCfg.Reset()
+	require.NoError(t, mockCfgTemplate.Execute(&mockCfg, cfgBldr.templateData))
+	for _, expectedLine := range []string{
+		`config_opts['environment']['GOFLAGS'] = '-mod=vendor'`,
+		`config_opts['environment']['https_proxy'] = 'http://127.0.0.1:3128'`,
+		`config_opts['environment']['SSL_CERT_FILE'] = '/etc/pki/eext-fetch-proxy/ca.pem'`,
+		fmt.Sprintf(`config_opts['plugin_conf']['bind_mount_opts']['dirs'].append(('%s', '/etc/pki/eext-fetch-proxy'))`,
+			proxy.dir),
+		`config_opts['rpmbuild_command'] = '/etc/pki/eext-fetch-proxy/rpmbuild'`,
+	} {
+		require.Contains(t, mockCfg.String(), expectedLine+"\n")
+	}
+	// The manifest's environment is left untouched
+	require.Equal(t, map[string]string{"GOFLAGS": "-mod=vendor"}, buildSpec.Environment)
+
+	for expectedErr, badBuildSpec := range badBuildSpecs {
+		t.Logf("Testing bad customization: %s", expectedErr)
+		badBuildSpec := badBuildSpec
+		cfgBldr.buildSpec = &badBuildSpec
+		require.ErrorContains(t, cfgBldr.populateTemplateData(), expectedErr)
+	}
+}
+
+func TestMockConfigDistro(t *testing.T) {
+	workDir, err := os.MkdirTemp("", "mock-cfg-distro-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	viper.Set("WorkingDir", workDir)
+	viper.Set("DnfRepoHost", "https://foo.org")
+	defer viper.Reset()
+
+	dnfConfig, loadErr := dnfconfig.LoadDnfConfig("testData/dnfconfig.yaml")
+	require.NoError(t, loadErr)
+
+	buildSpec := manifest.Build{
+		Distro:     "fc40",
+		RepoBundle: []manifest.RepoBundle{{Name: "bundle-boo3"}},
+	}
+	cfgBldr := mockCfgBuilder{
+		builderCommon: &builderCommon{
+			pkg:       "pkg1",
+			arch:      "x86_64",
+			buildSpec: &buildSpec,
+			dnfConfig: dnfConfig,
+			errPrefix: "TestMockConfigDistro: ",
+			executor:  &executor.OsExecutor{},
+		},
+	}
+	require.NoError(t, cfgBldr.populateTemplateData())
+
+	mockCfgTemplate, parseErr := template.ParseFiles("../configfiles/mock.cfg.template")
+	require.NoError(t, parseErr)
+	var mockCfg bytes.Buffer
+	require.NoError(t, mockCfgTemplate.Execute(&mockCfg, cfgBldr.templateData))
+	for _, expectedLine := range []string{
+		`config_opts['chroot_setup_cmd'] = "install bash fedora-release-common rpm-build"`,
+		`config_opts['releasever'] = "40"`,
+		`config_opts['macros']['_fc40_test'] = '1'`,
+		`module_platform_id=platform:f40`,
+		`baseurl = https://foo.org/boo3-v1/repo-roo31/x86_64/`,
+	} {
+		require.Contains(t, mockCfg.String(), expectedLine+"\n")
+	}
+
+	t.Log("Testing repo-bundle from another distro")
+	buildSpec.RepoBundle = append(buildSpec.RepoBundle, manifest.RepoBundle{Name: "bundle-boo1"})
+	require.ErrorContains(t, cfgBldr.populateTemplateData(),
+		"repo-bundle bundle-boo1 doesn't belong to distro fc40")
+
+	t.Log("Testing unknown distro")
+	buildSpec.Distro = "el10"
+	require.ErrorContains(t, cfgBldr.populateTemplateData(), "Unknown distro el10")
+}
+
+func TestMockConfigLegacyDnfConfig(t *testing.T) {
+	workDir, err := os.MkdirTemp("", "mock-cfg-legacy-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	viper.Set("WorkingDir", workDir)
+	viper.Set("DnfRepoHost", "https://foo.org")
+	defer viper.Reset()
+
+	dnfConfig, loadErr := dnfconfig.LoadDnfConfig("testData/legacy-dnfconfig.yaml")
+	require.NoError(t, loadErr)
+
+	buildSpec := manifest.Build{
+		RepoBundle: []manifest.RepoBundle{{Name: "bundle-boo1"}},
+	}
+	cfgBldr := mockCfgBuilder{
+		builderCommon: &builderCommon{
+			pkg:       "pkg1",
+			arch:      "x86_64",
+			buildSpec: &buildSpec,
+			dnfConfig: dnfConfig,
+			errPrefix: "TestMockConfigLegacyDnfConfig: ",
+			executor:  &executor.OsExecutor{},
+		},
+	}
+	require.NoError(t, cfgBldr.populateTemplateData())
+
+	mockCfgTemplate, parseErr := template.ParseFiles("../configfiles/mock.cfg.template")
+	require.NoError(t, parseErr)
+	var mockCfg bytes.Buffer
+	require.NoError(t, mockCfgTemplate.Execute(&mockCfg, cfgBldr.templateData))
+	for _, expectedLine := range []string{
+		`config_opts['chroot_setup_cmd'] = "install bash bzip2 coreutils cpio diffutils ` +
+			`findutils gawk glibc-minimal-langpack grep gzip info patch redhat-release ` +
+			`redhat-rpm-config rpm-build sed shadow-utils tar unzip util-linux which xz"`,
+		`config_opts['releasever'] = "9"`,
+		`module_platform_id=platform:el9`,
+		`baseurl = https://foo.org/boo1-v1/repo-roo11/x86_64/`,
+	} {
+		require.Contains(t, mockCfg.String(), expectedLine+"\n")
+	}
+
+	t.Log("Testing distro missing from the legacy dnfconfig")
+	buildSpec.Distro = "fc40"
+	require.ErrorContains(t, cfgBldr.populateTemplateData(), "Unknown distro fc40")
+}
+
+func TestMockConfigVariant(t *testing.T) {
+	workDir, err := os.MkdirTemp("", "mock-cfg-variant-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	viper.Set("WorkingDir", workDir)
+	viper.Set("DestDir", "/dest")
+	viper.Set("SrpmsDir", "/dest/SRPMS:/SRPMS")
+	viper.Set("DnfRepoHost", "https://foo.org")
+	defer viper.Reset()
+
+	dnfConfig, loadErr := dnfconfig.LoadDnfConfig("testData/dnfconfig.yaml")
+	require.NoError(t, loadErr)
+
+	pkgSpec := manifest.Package{
+		Name: "pkg1",
+		Build: manifest.Build{
+			RepoBundle: []manifest.RepoBundle{{Name: "bundle-boo1"}},
+		},
+		Matrix: []manifest.Variant{
+			{
+				Name:       "fc40",
+				Distro:     "fc40",
+				RepoBundle: []manifest.RepoBundle{{Name: "bundle-boo3"}},
+				Macros:     map[string]string{"_variant": "fc40"},
+			},
+		},
+	}
+	variant := pkgSpec.GetVariant("fc40")
+	cfgBldr := mockCfgBuilder{
+		builderCommon: &builderCommon{
+			pkg:       "pkg1",
+			variant:   variant.Name,
+			arch:      "x86_64",
+			buildSpec: pkgSpec.GetVariantBuild(variant),
+			dnfConfig: dnfConfig,
+			errPrefix: "TestMockConfigVariant: ",
+			executor:  &executor.OsExecutor{},
+		},
+	}
+
+	require.NoError(t, withVariantDirs(variant.Name, func() error {
+		require.Equal(t, filepath.Join(workDir, "variants/fc40"), viper.GetString("WorkingDir"))
+		require.Equal(t, "/dest/variants/fc40", viper.GetString("DestDir"))
+		require.Equal(t, "/dest/variants/fc40/SRPMS", viper.GetString("SrpmsDir"))
+		return cfgBldr.populateTemplateData()
+	}))
+	require.Equal(t, workDir, viper.GetString("WorkingDir"))
+	require.Equal(t, "/dest", viper.GetString("DestDir"))
+	require.Equal(t, "/dest/SRPMS:/SRPMS", viper.GetString("SrpmsDir"))
+
+	mockCfgTemplate, parseErr := template.ParseFiles("../configfiles/mock.cfg.template")
+	require.NoError(t, parseErr)
+	var mockCfg bytes.Buffer
+	require.NoError(t, mockCfgTemplate.Execute(&mockCfg, cfgBldr.templateData))
+	for _, expectedLine := range []string{
+		fmt.Sprintf(`config_opts['resultdir'] = "%s/variants/fc40/pkg1/mock-x86_64/mock-results"`, workDir),
+		`config_opts['root'] = "pkg1-fc40-x86_64"`,
+		`config_opts['macros']['_variant'] = 'fc40'`,
+		`baseurl = https://foo.org/boo3-v1/repo-roo31/x86_64/`,
+	} {
+		require.Contains(t, mockCfg.String(), expectedLine+"\n")
+	}
+}
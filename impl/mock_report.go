@@ -0,0 +1,74 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// mockNetworkEntry records the network access of a build
+// with a network policy, through the fetch proxy.
+// Blocked lists what the build tried to fetch and wasn't allowed to.
+type mockNetworkEntry struct {
+	Allow   []string      `yaml:"allow"`
+	Fetched []fetchRecord `yaml:"fetched"`
+	Blocked []string      `yaml:"blocked,omitempty"`
+}
+
+// mockReport is written for each mock build of a package.
+type mockReport struct {
+	Package string            `yaml:"package"`
+	Variant string            `yaml:"variant,omitempty"`
+	Arch    string            `yaml:"arch"`
+	Network *mockNetworkEntry `yaml:"network,omitempty"`
+}
+
+// getMockReportFilename returns the name of the report of the mock build,
+// which is distinct for each variant and arch.
+func getMockReportFilename(variant string, arch string) string {
+	if variant == "" {
+		return fmt.Sprintf("mock-report-%s.yaml", arch)
+	}
+	return fmt.Sprintf("mock-report-%s-%s.yaml", variant, arch)
+}
+
+// writeReport records the build in
+// <DestDir>/reports/<package>/mock-report[-<variant>]-<arch>.yaml
+func (bldr *mockBuilder) writeReport() error {
+	report := mockReport{
+		Package: bldr.pkg,
+		Variant: bldr.variant,
+		Arch:    bldr.arch,
+	}
+	if bldr.fetchProxy != nil {
+		fetched, blocked := bldr.fetchProxy.records()
+		report.Network = &mockNetworkEntry{
+			Allow:   bldr.buildSpec.Network.Allow,
+			Fetched: fetched,
+			Blocked: blocked,
+		}
+	}
+
+	yamlContents, err := yaml.Marshal(&report)
+	if err != nil {
+		return fmt.Errorf("%sError '%s' marshaling mock report",
+			bldr.errPrefix, err)
+	}
+	reportsDir := getPkgReportsDir(bldr.pkg)
+	if err := util.MaybeCreateDirWithParents(reportsDir, bldr.executor, bldr.errPrefix); err != nil {
+		return err
+	}
+	reportPath := filepath.Join(reportsDir, getMockReportFilename(bldr.variant, bldr.arch))
+	if err := os.WriteFile(reportPath, yamlContents, 0644); err != nil {
+		return fmt.Errorf("%sError '%s' writing %s",
+			bldr.errPrefix, err, reportPath)
+	}
+	return nil
+}
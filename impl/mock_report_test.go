@@ -0,0 +1,64 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/manifest"
+)
+
+func TestWriteMockReport(t *testing.T) {
+	destDir := t.TempDir()
+	viper.Set("DestDir", destDir)
+	defer viper.Reset()
+
+	policy := &manifest.NetworkPolicy{Allow: []string{"proxy.golang.org"}}
+	proxy, err := newFetchProxy(policy)
+	require.NoError(t, err)
+	defer proxy.close()
+	proxy.fetched = []fetchRecord{
+		{Method: "GET", URL: "https://proxy.golang.org/b", Status: 200, Size: 1, Sha256: "bb"},
+		{Method: "GET", URL: "https://proxy.golang.org/a", Status: 200, Size: 1, Sha256: "aa"},
+		{Method: "GET", URL: "https://proxy.golang.org/b", Status: 200, Size: 1, Sha256: "bb"},
+	}
+	proxy.blocked = []string{"https://pypi.org", "https://pypi.org"}
+
+	bldr := &mockBuilder{
+		builderCommon: &builderCommon{
+			pkg:        "pkg1",
+			variant:    "debug",
+			arch:       "x86_64",
+			buildSpec:  &manifest.Build{Network: policy},
+			executor:   &executor.OsExecutor{},
+			fetchProxy: proxy,
+		},
+	}
+	require.NoError(t, bldr.writeReport())
+
+	contents, err := os.ReadFile(filepath.Join(destDir, "reports/pkg1", "mock-report-debug-x86_64.yaml"))
+	require.NoError(t, err)
+	var report mockReport
+	require.NoError(t, yaml.UnmarshalStrict(contents, &report))
+	require.Equal(t, mockReport{
+		Package: "pkg1",
+		Variant: "debug",
+		Arch:    "x86_64",
+		Network: &mockNetworkEntry{
+			Allow: []string{"proxy.golang.org"},
+			Fetched: []fetchRecord{
+				{Method: "GET", URL: "https://proxy.golang.org/a", Status: 200, Size: 1, Sha256: "aa"},
+				{Method: "GET", URL: "https://proxy.golang.org/b", Status: 200, Size: 1, Sha256: "bb"},
+			},
+			Blocked: []string{"https://pypi.org"},
+		},
+	}, report)
+}
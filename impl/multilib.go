@@ -0,0 +1,369 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v2"
+
+	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/rpmfile"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// Multilib images combine the RPMs built for the native arch with
+// RPMs built for the other arch.
+var multilibOtherArch = map[string]string{
+	"x86_64": "i686",
+	"i686":   "x86_64",
+}
+
+const multilibManifestFilename = "multilib-manifest.yaml"
+
+// multilibRpmEntry records whether an RPM made it into the multilib set and why.
+type multilibRpmEntry struct {
+	Filename string `yaml:"filename"`
+	Arch     string `yaml:"arch"`
+	Kept     bool   `yaml:"kept"`
+	Reason   string `yaml:"reason"`
+}
+
+// multilibManifest is written alongside the composed multilib RPM set.
+type multilibManifest struct {
+	Package    string             `yaml:"package"`
+	NativeArch string             `yaml:"native-arch"`
+	OtherArch  string             `yaml:"other-arch"`
+	Rpms       []multilibRpmEntry `yaml:"rpms"`
+}
+
+// rpmFileInfo is a single file entry in an RPM payload
+type rpmFileInfo struct {
+	path   string
+	mode   uint32
+	digest string
+	color  uint32
+}
+
+// multilibConflict is a file installed by both arches with different contents
+type multilibConflict struct {
+	path      string
+	nativeRpm string
+	otherRpm  string
+}
+
+type multilibBuilder struct {
+	pkg        string
+	nativeArch string
+	otherArch  string
+	pattern    manifest.Multilib
+	errPrefix  util.ErrPrefix
+	executor   executor.Executor
+	listFiles  func(rpmPath string) ([]rpmFileInfo, error)
+}
+
+// applyMultilibPattern decides which of the rpms are kept.
+// If no patterns are specified, all RPMs are kept.
+// If Remove is set, RPMs matching any of the patterns are removed,
+// otherwise only the RPMs matching one of the patterns are kept.
+func applyMultilibPattern(rpmFilenames []string, arch string,
+	pattern manifest.MultilibRpmFilenamePattern,
+	errPrefix util.ErrPrefix) ([]multilibRpmEntry, error) {
+	var entries []multilibRpmEntry
+	for _, filename := range rpmFilenames {
+		entry := multilibRpmEntry{
+			Filename: filename,
+			Arch:     arch,
+		}
+		if len(pattern.Patterns) == 0 {
+			entry.Kept = true
+			entry.Reason = "no patterns specified"
+			entries = append(entries, entry)
+			continue
+		}
+
+		matchedPattern := ""
+		for _, p := range pattern.Patterns {
+			matched, err := filepath.Match(p, filename)
+			if err != nil {
+				return nil, fmt.Errorf("%sBad multilib pattern '%s': %s",
+					errPrefix, p, err)
+			}
+			if matched {
+				matchedPattern = p
+				break
+			}
+		}
+
+		switch {
+		case pattern.Remove && matchedPattern != "":
+			entry.Kept = false
+			entry.Reason = fmt.Sprintf("matches remove pattern '%s'", matchedPattern)
+		case pattern.Remove:
+			entry.Kept = true
+			entry.Reason = "doesn't match any remove pattern"
+		case matchedPattern != "":
+			entry.Kept = true
+			entry.Reason = fmt.Sprintf("matches keep pattern '%s'", matchedPattern)
+		default:
+			entry.Kept = false
+			entry.Reason = "doesn't match any keep pattern"
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// findMultilibConflicts returns files which are present in both the native and the other
+// arch RPMs, but can't be shared between them.
+// Directories and identical files can be shared, so can files colored by rpm (ELF objects),
+// since rpm resolves those in favour of the native arch on install.
+func findMultilibConflicts(nativeFiles map[string][]rpmFileInfo,
+	otherFiles map[string][]rpmFileInfo) []multilibConflict {
+	type owner struct {
+		rpm  string
+		info rpmFileInfo
+	}
+	nativeOwners := make(map[string]owner)
+	for rpm, files := range nativeFiles {
+		for _, file := range files {
+			nativeOwners[file.path] = owner{rpm, file}
+		}
+	}
+
+	var conflicts []multilibConflict
+	for rpm, files := range otherFiles {
+		for _, file := range files {
+			native, found := nativeOwners[file.path]
+			if !found {
+				continue
+			}
+			isDir := (file.mode&0170000 == 0040000) && (native.info.mode&0170000 == 0040000)
+			identical := file.digest == native.info.digest && file.mode == native.info.mode
+			colored := file.color != 0 && native.info.color != 0
+			if isDir || identical || colored {
+				continue
+			}
+			conflicts = append(conflicts, multilibConflict{
+				path:      file.path,
+				nativeRpm: native.rpm,
+				otherRpm:  rpm,
+			})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].path < conflicts[j].path
+	})
+	return conflicts
+}
+
+// listRpmFiles reads the files in the RPM at rpmPath from its header.
+func listRpmFiles(rpmPath string) ([]rpmFileInfo, error) {
+	rpmPkg, err := rpmfile.Open(rpmPath)
+	if err != nil {
+		return nil, err
+	}
+	rpmFiles, err := rpmPkg.Files()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", rpmPath, err)
+	}
+
+	var files []rpmFileInfo
+	for _, file := range rpmFiles {
+		files = append(files, rpmFileInfo{
+			path:   file.Path,
+			mode:   file.Mode,
+			digest: file.Digest,
+			color:  file.Color,
+		})
+	}
+	return files, nil
+}
+
+func (bldr *multilibBuilder) log(format string, a ...any) {
+	newformat := fmt.Sprintf("%s%s", bldr.errPrefix, format)
+	log.Printf(newformat, a...)
+}
+
+// collectRpms returns the filenames of the RPMs built for arch.
+func (bldr *multilibBuilder) collectRpms(arch string) []string {
+	rpmsDir := getPkgRpmsDestDir(bldr.pkg, arch)
+	paths, _ := filepath.Glob(filepath.Join(rpmsDir, fmt.Sprintf("*.%s.rpm", arch)))
+	var filenames []string
+	for _, path := range paths {
+		filenames = append(filenames, filepath.Base(path))
+	}
+	return filenames
+}
+
+// keptRpmFiles lists the files in each of the kept RPMs in entries
+func (bldr *multilibBuilder) keptRpmFiles(entries []multilibRpmEntry) (
+	map[string][]rpmFileInfo, error) {
+	files := make(map[string][]rpmFileInfo)
+	for _, entry := range entries {
+		if !entry.Kept {
+			continue
+		}
+		rpmPath := filepath.Join(getPkgRpmsDestDir(bldr.pkg, entry.Arch), entry.Filename)
+		rpmFiles, err := bldr.listFiles(rpmPath)
+		if err != nil {
+			return nil, fmt.Errorf("%sError '%s' listing files in %s",
+				bldr.errPrefix, err, rpmPath)
+		}
+		files[entry.Filename] = rpmFiles
+	}
+	return files, nil
+}
+
+func (bldr *multilibBuilder) writeManifest(destDir string, entries []multilibRpmEntry) error {
+	multilibManifest := multilibManifest{
+		Package:    bldr.pkg,
+		NativeArch: bldr.nativeArch,
+		OtherArch:  bldr.otherArch,
+		Rpms:       entries,
+	}
+	yamlContents, err := yaml.Marshal(&multilibManifest)
+	if err != nil {
+		return fmt.Errorf("%sError '%s' marshaling multilib manifest",
+			bldr.errPrefix, err)
+	}
+	manifestPath := filepath.Join(destDir, multilibManifestFilename)
+	if err := os.WriteFile(manifestPath, yamlContents, 0644); err != nil {
+		return fmt.Errorf("%sError '%s' writing %s",
+			bldr.errPrefix, err, manifestPath)
+	}
+	return nil
+}
+
+// compose filters the native and other arch RPMs, checks for file conflicts
+// and copies the resulting RPM set to <DestDir>/multilib/<native-arch>/<package>/
+func (bldr *multilibBuilder) compose() error {
+	nativeRpms := bldr.collectRpms(bldr.nativeArch)
+	if len(nativeRpms) == 0 {
+		return fmt.Errorf("%sNo %s RPMs found in %s",
+			bldr.errPrefix, bldr.nativeArch, getPkgRpmsDestDir(bldr.pkg, bldr.nativeArch))
+	}
+	otherRpms := bldr.collectRpms(bldr.otherArch)
+	if len(otherRpms) == 0 {
+		return fmt.Errorf("%sNo %s RPMs found in %s",
+			bldr.errPrefix, bldr.otherArch, getPkgRpmsDestDir(bldr.pkg, bldr.otherArch))
+	}
+
+	nativeEntries, err := applyMultilibPattern(nativeRpms, bldr.nativeArch,
+		bldr.pattern.NativeArchPattern, bldr.errPrefix)
+	if err != nil {
+		return err
+	}
+	otherEntries, err := applyMultilibPattern(otherRpms, bldr.otherArch,
+		bldr.pattern.OtherArchPattern, bldr.errPrefix)
+	if err != nil {
+		return err
+	}
+
+	nativeFiles, err := bldr.keptRpmFiles(nativeEntries)
+	if err != nil {
+		return err
+	}
+	otherFiles, err := bldr.keptRpmFiles(otherEntries)
+	if err != nil {
+		return err
+	}
+	if conflicts := findMultilibConflicts(nativeFiles, otherFiles); len(conflicts) != 0 {
+		var conflictStrs []string
+		for _, conflict := range conflicts {
+			conflictStrs = append(conflictStrs, fmt.Sprintf("%s (%s, %s)",
+				conflict.path, conflict.nativeRpm, conflict.otherRpm))
+		}
+		return fmt.Errorf("%sFile conflicts between %s and %s RPMs:\n%s",
+			bldr.errPrefix, bldr.nativeArch, bldr.otherArch,
+			strings.Join(conflictStrs, "\n"))
+	}
+
+	destDir := getPkgMultilibDestDir(bldr.pkg, bldr.nativeArch)
+	if err := util.RemoveDirs([]string{destDir}, bldr.errPrefix); err != nil {
+		return err
+	}
+	if err := util.MaybeCreateDirWithParents(destDir, bldr.executor, bldr.errPrefix); err != nil {
+		return err
+	}
+
+	entries := append(nativeEntries, otherEntries...)
+	for _, entry := range entries {
+		if !entry.Kept {
+			bldr.log("removing %s: %s", entry.Filename, entry.Reason)
+			continue
+		}
+		bldr.log("keeping %s: %s", entry.Filename, entry.Reason)
+		rpmPath := filepath.Join(getPkgRpmsDestDir(bldr.pkg, entry.Arch), entry.Filename)
+		if err := util.CopyToDestDir(rpmPath, destDir, bldr.errPrefix); err != nil {
+			return err
+		}
+	}
+
+	return bldr.writeManifest(destDir, entries)
+}
+
+// Multilib composes the multilib RPM set for a package from the RPMs
+// already built for the native and other arch in <DestDir>/RPMS/<arch>/<package>/,
+// according to the multilib spec for the native arch in the manifest.
+// The results are placed in <DestDir>/multilib/<native-arch>/<package>/
+// along with a manifest of which RPMs were kept and why.
+func Multilib(repo string, pkg string, nativeArch string, executor executor.Executor) error {
+	if err := CheckEnv(); err != nil {
+		return err
+	}
+
+	otherArch, ok := multilibOtherArch[nativeArch]
+	if !ok {
+		allowedArchs := maps.Keys(multilibOtherArch)
+		sort.Strings(allowedArchs)
+		return fmt.Errorf("impl.Multilib: '%s' is not a valid native arch, must be one of %s",
+			nativeArch, strings.Join(allowedArchs, ", "))
+	}
+
+	repoManifest, loadManifestErr := manifest.LoadManifest(repo)
+	if loadManifestErr != nil {
+		return loadManifestErr
+	}
+
+	var pkgSpec *manifest.Package
+	for i := range repoManifest.Package {
+		if repoManifest.Package[i].Name == pkg {
+			pkgSpec = &repoManifest.Package[i]
+			break
+		}
+	}
+	if pkgSpec == nil {
+		return fmt.Errorf("impl.Multilib: Invalid package name %s specified", pkg)
+	}
+
+	pattern, found := pkgSpec.Build.Generator.Multilib[nativeArch]
+	if !found {
+		return fmt.Errorf("impl.Multilib: No multilib spec for native arch %s in package %s",
+			nativeArch, pkg)
+	}
+
+	bldr := &multilibBuilder{
+		pkg:        pkg,
+		nativeArch: nativeArch,
+		otherArch:  otherArch,
+		pattern:    pattern,
+		errPrefix:  util.ErrPrefix(fmt.Sprintf("multilibBuilder(%s-%s): ", pkg, nativeArch)),
+		executor:   executor,
+		listFiles:  listRpmFiles,
+	}
+	if err := bldr.compose(); err != nil {
+		return err
+	}
+
+	log.Println("SUCCESS: multilib")
+	return nil
+}
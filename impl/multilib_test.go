@@ -0,0 +1,186 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/rpmfile"
+)
+
+func TestApplyMultilibPattern(t *testing.T) {
+	rpms := []string{
+		"iptables-1.8-1.i686.rpm",
+		"iptables-devel-1.8-1.i686.rpm",
+		"iptables-libs-1.8-1.i686.rpm",
+	}
+
+	testCases := map[string]struct {
+		pattern      manifest.MultilibRpmFilenamePattern
+		expectedKept []bool
+	}{
+		"noPatterns": {
+			pattern:      manifest.MultilibRpmFilenamePattern{},
+			expectedKept: []bool{true, true, true},
+		},
+		"keep": {
+			pattern: manifest.MultilibRpmFilenamePattern{
+				Patterns: []string{"iptables-devel*", "iptables-libs*"},
+			},
+			expectedKept: []bool{false, true, true},
+		},
+		"remove": {
+			pattern: manifest.MultilibRpmFilenamePattern{
+				Patterns: []string{"iptables-devel*"},
+				Remove:   true,
+			},
+			expectedKept: []bool{true, false, true},
+		},
+	}
+
+	for testName, tc := range testCases {
+		t.Logf("%s: Testing applyMultilibPattern", testName)
+		entries, err := applyMultilibPattern(rpms, "i686", tc.pattern, "")
+		require.NoError(t, err)
+		require.Len(t, entries, len(rpms))
+		for i, entry := range entries {
+			require.Equal(t, rpms[i], entry.Filename)
+			require.Equal(t, "i686", entry.Arch)
+			require.Equal(t, tc.expectedKept[i], entry.Kept, entry.Filename)
+			require.NotEmpty(t, entry.Reason)
+		}
+	}
+
+	_, err := applyMultilibPattern(rpms, "i686",
+		manifest.MultilibRpmFilenamePattern{Patterns: []string{"["}}, "")
+	require.Error(t, err)
+}
+
+func TestFindMultilibConflicts(t *testing.T) {
+	nativeFiles := map[string][]rpmFileInfo{
+		"foo.x86_64.rpm": {
+			{path: "/usr/include/foo", mode: 040755},
+			{path: "/usr/include/foo/foo.h", mode: 0100644, digest: "aaaa"},
+			{path: "/usr/include/foo/config.h", mode: 0100644, digest: "bbbb"},
+			{path: "/usr/bin/foo", mode: 0100755, digest: "cccc", color: 2},
+		},
+	}
+	otherFiles := map[string][]rpmFileInfo{
+		"foo.i686.rpm": {
+			{path: "/usr/include/foo", mode: 040755},
+			{path: "/usr/include/foo/foo.h", mode: 0100644, digest: "aaaa"},
+			{path: "/usr/include/foo/config.h", mode: 0100644, digest: "dddd"},
+			{path: "/usr/bin/foo", mode: 0100755, digest: "eeee", color: 1},
+			{path: "/usr/lib/libfoo.so", mode: 0100755, digest: "ffff", color: 1},
+		},
+	}
+
+	conflicts := findMultilibConflicts(nativeFiles, otherFiles)
+	require.Equal(t, []multilibConflict{
+		{path: "/usr/include/foo/config.h", nativeRpm: "foo.x86_64.rpm", otherRpm: "foo.i686.rpm"},
+	}, conflicts)
+}
+
+func TestMultilibCompose(t *testing.T) {
+	destDir, err := os.MkdirTemp("", "multilib-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(destDir)
+	viper.Set("DestDir", destDir)
+	defer viper.Reset()
+
+	pkg := "iptables"
+	rpmsByArch := map[string][]string{
+		"x86_64": {"iptables-1.8-1.x86_64.rpm", "iptables-libs-1.8-1.x86_64.rpm"},
+		"i686":   {"iptables-1.8-1.i686.rpm", "iptables-libs-1.8-1.i686.rpm"},
+	}
+	for arch, rpms := range rpmsByArch {
+		rpmsDir := getPkgRpmsDestDir(pkg, arch)
+		require.NoError(t, os.MkdirAll(rpmsDir, 0755))
+		for _, rpm := range rpms {
+			require.NoError(t, os.WriteFile(filepath.Join(rpmsDir, rpm), []byte(rpm), 0644))
+		}
+	}
+
+	// i686 RPMs install a config file with conflictingDigest
+	var conflictingDigest string
+	bldr := &multilibBuilder{
+		pkg:        pkg,
+		nativeArch: "x86_64",
+		otherArch:  "i686",
+		pattern: manifest.Multilib{
+			OtherArchPattern: manifest.MultilibRpmFilenamePattern{
+				Patterns: []string{"iptables-libs*"},
+			},
+		},
+		executor: &executor.OsExecutor{},
+		listFiles: func(rpmPath string) ([]rpmFileInfo, error) {
+			digest := "aaaa"
+			if strings.HasSuffix(rpmPath, ".i686.rpm") {
+				digest = conflictingDigest
+			}
+			return []rpmFileInfo{
+				{path: "/etc/iptables.conf", mode: 0100644, digest: digest},
+			}, nil
+		},
+	}
+
+	t.Log("Testing compose with conflicting files")
+	conflictingDigest = "bbbb"
+	require.ErrorContains(t, bldr.compose(), "/etc/iptables.conf")
+
+	t.Log("Testing compose without conflicting files")
+	conflictingDigest = "aaaa"
+	require.NoError(t, bldr.compose())
+
+	multilibDir := filepath.Join(destDir, "multilib", "x86_64", pkg)
+	for _, rpm := range []string{
+		"iptables-1.8-1.x86_64.rpm",
+		"iptables-libs-1.8-1.x86_64.rpm",
+		"iptables-libs-1.8-1.i686.rpm",
+	} {
+		require.FileExists(t, filepath.Join(multilibDir, rpm))
+	}
+	require.NoFileExists(t, filepath.Join(multilibDir, "iptables-1.8-1.i686.rpm"))
+
+	yamlContents, err := os.ReadFile(filepath.Join(multilibDir, multilibManifestFilename))
+	require.NoError(t, err)
+	var generated multilibManifest
+	require.NoError(t, yaml.Unmarshal(yamlContents, &generated))
+	require.Equal(t, pkg, generated.Package)
+	require.Equal(t, "i686", generated.OtherArch)
+	require.Len(t, generated.Rpms, 4)
+	for _, entry := range generated.Rpms {
+		require.Equal(t, entry.Filename != "iptables-1.8-1.i686.rpm", entry.Kept, entry.Filename)
+	}
+}
+
+func TestListRpmFiles(t *testing.T) {
+	rpmPath := writeDummyRpm(t, t.TempDir(), "foo", "x86_64", func(hdr *rpmfile.Header) {
+		hdr.SetStringArray(rpmfile.TagDirNames, []string{"/usr/include/", "/usr/lib64/"})
+		hdr.SetStringArray(rpmfile.TagBaseNames, []string{"foo", "foo.h", "libfoo.so.1"})
+		hdr.SetInt32Array(rpmfile.TagDirIndexes, []uint32{0, 0, 1})
+		hdr.SetInt16Array(rpmfile.TagFileModes, []uint16{040755, 0100644, 0100755})
+		hdr.SetStringArray(rpmfile.TagFileDigests, []string{"", "aa", "bb"})
+		hdr.SetInt32Array(rpmfile.TagFileColors, []uint32{0, 0, 2})
+	})
+
+	files, err := listRpmFiles(rpmPath)
+	require.NoError(t, err)
+	require.Equal(t, []rpmFileInfo{
+		{path: "/usr/include/foo", mode: 040755},
+		{path: "/usr/include/foo.h", mode: 0100644, digest: "aa"},
+		{path: "/usr/lib64/libfoo.so.1", mode: 0100755, digest: "bb", color: 2},
+	}, files)
+}
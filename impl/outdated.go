@@ -0,0 +1,625 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/viper"
+	"golang.org/x/exp/maps"
+
+	"code.arista.io/eos/tools/eext/decompress"
+	"code.arista.io/eos/tools/eext/dnfconfig"
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/rpmfile"
+	"code.arista.io/eos/tools/eext/srcconfig"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// The arch the baseurl of the repos in the dnf repo-bundles is
+// formatted with to get the SRPM repos.
+const outdatedSrpmRepoArch = "source"
+
+var (
+	outdatedVersionRegex = regexp.MustCompile(`^[0-9][A-Za-z0-9._+~^]*$`)
+	hrefRegex            = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+)
+
+// outdatedEntry is the result of the check of an upstream source of a package.
+// Status is outdated, up-to-date, unknown if the current version couldn't be
+// told, skipped or the error of the check.
+type outdatedEntry struct {
+	repo    string
+	pkg     string
+	source  string
+	current string
+	latest  string
+	status  string
+}
+
+// srpmVersion is the epoch:version-release of an SRPM.
+// The epoch is only known for SRPMs in dnf repos, it's empty otherwise.
+type srpmVersion struct {
+	epoch   string
+	version string
+	release string
+}
+
+func (v srpmVersion) String() string {
+	if v.epoch != "" && v.epoch != "0" {
+		return v.epoch + ":" + v.version + "-" + v.release
+	}
+	return v.version + "-" + v.release
+}
+
+func (v srpmVersion) compare(other srpmVersion) int {
+	return rpmfile.CompareEVR(v.epoch, v.version, v.release,
+		other.epoch, other.version, other.release)
+}
+
+// withRepoEpoch returns v with the epoch of the same version-release in
+// repoVersions, SRPM filenames don't have the epoch.
+func (v srpmVersion) withRepoEpoch(repoVersions []srpmVersion) srpmVersion {
+	for _, repoVersion := range repoVersions {
+		if repoVersion.version == v.version && repoVersion.release == v.release {
+			v.epoch = repoVersion.epoch
+		}
+	}
+	return v
+}
+
+// outdatedChecker looks up the versions available upstream.
+type outdatedChecker struct {
+	srcConfig *srcconfig.SrcConfig
+	dnfConfig *dnfconfig.DnfConfig
+	client    *http.Client
+}
+
+// parseSrpmFilename splits <name>-<version>-<release>.src.rpm
+func parseSrpmFilename(filename string) (string, srpmVersion, bool) {
+	if !strings.HasSuffix(filename, ".src.rpm") {
+		return "", srpmVersion{}, false
+	}
+	nvr := strings.Split(strings.TrimSuffix(filename, ".src.rpm"), "-")
+	if len(nvr) < 3 {
+		return "", srpmVersion{}, false
+	}
+	return strings.Join(nvr[:len(nvr)-2], "-"),
+		srpmVersion{version: nvr[len(nvr)-2], release: nvr[len(nvr)-1]}, true
+}
+
+func (checker *outdatedChecker) get(rawURL string) (io.ReadCloser, error) {
+	response, err := checker.client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("GET %s returned %d %s", rawURL,
+			response.StatusCode, http.StatusText(response.StatusCode))
+	}
+	return response.Body, nil
+}
+
+// listIndex returns the entries of the directory index page at dirURL,
+// which are the links to the files and subdirs, with a trailing /, of the dir.
+func (checker *outdatedChecker) listIndex(dirURL *url.URL) ([]string, error) {
+	body, err := checker.get(dirURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	page, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	dirPath := strings.TrimSuffix(dirURL.Path, "/") + "/"
+	var entries []string
+	for _, match := range hrefRegex.FindAllStringSubmatch(string(page), -1) {
+		link, err := dirURL.Parse(match[1])
+		if err != nil || link.Host != dirURL.Host || link.RawQuery != "" {
+			continue
+		}
+		name := strings.TrimPrefix(link.Path, dirPath)
+		if name == link.Path || name == "" || strings.Contains(strings.TrimSuffix(name, "/"), "/") {
+			continue
+		}
+		entries = append(entries, name)
+	}
+	return entries, nil
+}
+
+// versionDirURL returns the URL of the dir of the upstream source at
+// srcURL, or of its parent if the dir is named after the version, like
+// <name>/<version>/<name>-<version>.tar.gz. Newer versions are looked up
+// in the directory index of the dir.
+func versionDirURL(srcURL *url.URL, version string) (*url.URL, bool) {
+	dirURL := *srcURL
+	dirURL.RawQuery = ""
+	dir := path.Dir(srcURL.Path)
+	if path.Base(dir) == version {
+		dirURL.Path = path.Dir(dir) + "/"
+		return &dirURL, true
+	}
+	dirURL.Path = dir + "/"
+	return &dirURL, false
+}
+
+// latestSrpmInIndex returns the current version of the SRPM at srcURL, and
+// the newest version of the SRPM in the directory index of its dir.
+func (checker *outdatedChecker) latestSrpmInIndex(srcURL *url.URL) (
+	srpmVersion, srpmVersion, error) {
+	filename := path.Base(srcURL.Path)
+	name, current, ok := parseSrpmFilename(filename)
+	if !ok {
+		return current, current, fmt.Errorf("can't tell the version of %s", filename)
+	}
+	// SRPMs of all the versions are in the same dir
+	dirURL := *srcURL
+	dirURL.Path = path.Dir(srcURL.Path) + "/"
+	dirURL.RawQuery = ""
+	entries, err := checker.listIndex(&dirURL)
+	if err != nil {
+		return current, current, err
+	}
+	latest := current
+	for _, entry := range entries {
+		if entryName, version, ok := parseSrpmFilename(entry); ok &&
+			entryName == name && version.compare(latest) > 0 {
+			latest = version
+		}
+	}
+	return current, latest, nil
+}
+
+// latestTarballInIndex returns the current version of the tarball at srcURL,
+// and the newest version of the tarball in the directory index of its dir,
+// named like it, or the newest version dir.
+func (checker *outdatedChecker) latestTarballInIndex(srcURL *url.URL) (
+	string, string, error) {
+	filename := path.Base(srcURL.Path)
+	name, current := guessNameVersion("tarball", srcURL.String(), "")
+	if current == "" {
+		return "", "", fmt.Errorf("can't tell the version of %s", filename)
+	}
+	dirURL, isVersionDir := versionDirURL(srcURL, current)
+	entries, err := checker.listIndex(dirURL)
+	if err != nil {
+		return current, current, err
+	}
+
+	// <name>-[v]<version><suffix>
+	versionStart := strings.Index(filename[len(name):], current) + len(name)
+	prefix := filename[:versionStart]
+	suffix := filename[versionStart+len(current):]
+	latest := current
+	for _, entry := range entries {
+		var version string
+		if isVersionDir {
+			version = strings.TrimSuffix(entry, "/")
+			if version == entry {
+				continue
+			}
+		} else {
+			if !strings.HasPrefix(entry, prefix) || !strings.HasSuffix(entry, suffix) ||
+				len(entry) <= len(prefix)+len(suffix) {
+				continue
+			}
+			version = entry[len(prefix) : len(entry)-len(suffix)]
+		}
+		if outdatedVersionRegex.MatchString(version) && rpmfile.VerCmp(version, latest) > 0 {
+			latest = version
+		}
+	}
+	return current, latest, nil
+}
+
+type repomdXML struct {
+	Data []struct {
+		Type     string `xml:"type,attr"`
+		Location struct {
+			Href string `xml:"href,attr"`
+		} `xml:"location"`
+	} `xml:"data"`
+}
+
+type primaryPackageXML struct {
+	Name    string `xml:"name"`
+	Arch    string `xml:"arch"`
+	Version struct {
+		Epoch string `xml:"epoch,attr"`
+		Ver   string `xml:"ver,attr"`
+		Rel   string `xml:"rel,attr"`
+	} `xml:"version"`
+}
+
+// srpmsInRepo returns the versions of the SRPM name in the dnf repo at
+// baseURL, from its primary metadata.
+func (checker *outdatedChecker) srpmsInRepo(baseURL string, name string) (
+	[]srpmVersion, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/") + "/"
+	repomdBody, err := checker.get(baseURL + "repodata/repomd.xml")
+	if err != nil {
+		return nil, err
+	}
+	var repomd repomdXML
+	err = xml.NewDecoder(repomdBody).Decode(&repomd)
+	repomdBody.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing repomd.xml of %s: %s", baseURL, err)
+	}
+	primaryHref := ""
+	for _, data := range repomd.Data {
+		if data.Type == "primary" {
+			primaryHref = data.Location.Href
+		}
+	}
+	if primaryHref == "" {
+		return nil, fmt.Errorf("no primary metadata in repomd.xml of %s", baseURL)
+	}
+
+	primaryBody, err := checker.get(baseURL + primaryHref)
+	if err != nil {
+		return nil, err
+	}
+	defer primaryBody.Close()
+	bufReader := bufio.NewReader(primaryBody)
+	format, err := decompress.Detect(bufReader)
+	if err != nil {
+		return nil, err
+	}
+	var primary io.Reader = bufReader
+	if format != "" {
+		decompressor, err := decompress.NewReader(bufReader, format)
+		if err != nil {
+			return nil, err
+		}
+		defer decompressor.Close()
+		primary = decompressor
+	}
+
+	var versions []srpmVersion
+	decoder := xml.NewDecoder(primary)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return versions, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %s", primaryHref, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "package" {
+			continue
+		}
+		var pkg primaryPackageXML
+		if err := decoder.DecodeElement(&pkg, &start); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %s", primaryHref, err)
+		}
+		if pkg.Name == name && pkg.Arch == "src" {
+			versions = append(versions,
+				srpmVersion{pkg.Version.Epoch, pkg.Version.Ver, pkg.Version.Rel})
+		}
+	}
+}
+
+// srpmsInRepoBundles returns the versions of the SRPM name in the
+// SRPM repos of the repo-bundles the package is built with.
+func (checker *outdatedChecker) srpmsInRepoBundles(pkgSpec *manifest.Package, name string,
+	errPrefix util.ErrPrefix) ([]srpmVersion, error) {
+	var versions []srpmVersion
+	for _, repoBundle := range pkgSpec.Build.RepoBundle {
+		bundleConfig, found := checker.dnfConfig.DnfRepoBundleConfig[repoBundle.Name]
+		if !found {
+			return nil, fmt.Errorf("unknown repo-bundle %s", repoBundle.Name)
+		}
+		repoNames := maps.Keys(bundleConfig.DnfRepoConfig)
+		sort.Strings(repoNames)
+		for _, repoName := range repoNames {
+			repoParams, err := bundleConfig.GetDnfRepoParams(repoName, outdatedSrpmRepoArch,
+				repoBundle.VersionOverride, repoBundle.DnfRepoParamsOverride, errPrefix)
+			if err != nil {
+				return nil, err
+			}
+			if !repoParams.Enabled {
+				continue
+			}
+			repoVersions, err := checker.srpmsInRepo(repoParams.BaseURL, name)
+			if err != nil {
+				return nil, err
+			}
+			versions = append(versions, repoVersions...)
+		}
+	}
+	return versions, nil
+}
+
+// parseGitTags returns the tags in the output of git ls-remote --tags
+func parseGitTags(output string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "refs/tags/") {
+			continue
+		}
+		tag := strings.TrimSuffix(strings.TrimPrefix(fields[1], "refs/tags/"), "^{}")
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// latestGitTag returns the version of the revision and the newest version
+// tag of the git repo, with its version. Only the tags with the same prefix
+// as the revision, like v or <name>-, are considered. The current version
+// is empty if the revision isn't a version tag, all the version tags are
+// considered then.
+func latestGitTag(repoURL string, revision string, tags []string) (
+	string, string, string) {
+	_, current := guessNameVersion("git-upstream", repoURL, revision)
+	prefix := ""
+	if current != "" {
+		prefix = revision[:strings.LastIndex(revision, current)]
+	}
+	latest, latestTag := current, revision
+	for _, tag := range tags {
+		var version string
+		if current != "" {
+			version = strings.TrimPrefix(tag, prefix)
+			if !strings.HasPrefix(tag, prefix) || !outdatedVersionRegex.MatchString(version) {
+				continue
+			}
+		} else if match := initVersionTagRegex.FindStringSubmatch(tag); match != nil {
+			version = match[1]
+		} else {
+			continue
+		}
+		if latest == "" || rpmfile.VerCmp(version, latest) > 0 {
+			latest, latestTag = version, tag
+		}
+	}
+	return current, latest, latestTag
+}
+
+// statusOf returns the status of the upstream source
+// from the comparison of the latest and current versions.
+func statusOf(currentKnown bool, cmp int) string {
+	if !currentKnown {
+		return "unknown"
+	}
+	if cmp > 0 {
+		return "outdated"
+	}
+	return "up-to-date"
+}
+
+// checkUpstreamSrc checks the upstream source of the package for newer versions.
+// It returns an error if the check failed, which is also its status.
+func (checker *outdatedChecker) checkUpstreamSrc(pkgSpec *manifest.Package,
+	upstreamSrc manifest.UpstreamSrc, entry *outdatedEntry, errPrefix util.ErrPrefix) error {
+	fail := func(err error) error {
+		entry.status = err.Error()
+		return err
+	}
+
+	if pkgSpec.Type == "git-upstream" {
+		gitBundle := upstreamSrc.GitBundle
+		entry.source = gitBundle.Url
+		entry.current = gitBundle.Revision
+		output, err := util.CheckOutput("git", "ls-remote", "--tags", gitBundle.Url)
+		if err != nil {
+			return fail(err)
+		}
+		current, latest, latestTag := latestGitTag(gitBundle.Url, gitBundle.Revision,
+			parseGitTags(output))
+		entry.latest = latestTag
+		entry.status = statusOf(current != "", rpmfile.VerCmp(latest, current))
+		return nil
+	}
+
+	srcParams, err := srcconfig.GetSrcParams(
+		pkgSpec.Name,
+		upstreamSrc.FullURL,
+		upstreamSrc.SourceBundle.Name,
+		upstreamSrc.Signature.DetachedSignature.FullURL,
+		upstreamSrc.SourceBundle.SrcRepoParamsOverride,
+		upstreamSrc.Signature.DetachedSignature.OnUncompressed,
+		checker.srcConfig,
+		errPrefix)
+	if err != nil {
+		return fail(err)
+	}
+	srcURL, err := url.Parse(srcParams.SrcURL)
+	if err != nil {
+		return fail(err)
+	}
+	entry.source = path.Base(srcURL.Path)
+	if srcURL.Scheme != "http" && srcURL.Scheme != "https" {
+		// Sources in the repo have no upstream to check
+		entry.status = "skipped"
+		return nil
+	}
+
+	if pkgSpec.Type != "srpm" && pkgSpec.Type != "unmodified-srpm" {
+		current, latest, err := checker.latestTarballInIndex(srcURL)
+		entry.current = current
+		if err != nil {
+			return fail(err)
+		}
+		entry.latest = latest
+		entry.status = statusOf(true, rpmfile.VerCmp(latest, current))
+		return nil
+	}
+
+	// SRPMs are also looked up in the SRPM repos of the repo-bundles,
+	// the check fails only if neither lookup finds the SRPM.
+	current, latest, indexErr := checker.latestSrpmInIndex(srcURL)
+	if current.version == "" {
+		return fail(indexErr)
+	}
+	entry.current = current.String()
+	name, _, _ := parseSrpmFilename(entry.source)
+	repoVersions, repoErr := checker.srpmsInRepoBundles(pkgSpec, name, errPrefix)
+	if indexErr != nil && len(repoVersions) == 0 {
+		if repoErr != nil {
+			return fail(fmt.Errorf("%s, %s", indexErr, repoErr))
+		}
+		return fail(indexErr)
+	}
+	for _, err := range []error{indexErr, repoErr} {
+		if err != nil {
+			log.Printf("%s%s", errPrefix, err)
+		}
+	}
+	current = current.withRepoEpoch(repoVersions)
+	latest = latest.withRepoEpoch(repoVersions)
+	for _, version := range repoVersions {
+		if version.compare(latest) > 0 {
+			latest = version
+		}
+	}
+	entry.current = current.String()
+	entry.latest = latest.String()
+	entry.status = statusOf(true, latest.compare(current))
+	return nil
+}
+
+// checkPackages checks all the upstream sources of the packages in the repo,
+// or only of the package pkg if specified. It returns the number of checks
+// which failed.
+func (checker *outdatedChecker) checkPackages(repo string, pkg string) (
+	[]outdatedEntry, int, error) {
+	repoManifest, err := manifest.LoadManifest(repo)
+	if err != nil {
+		return nil, 0, err
+	}
+	var entries []outdatedEntry
+	failed := 0
+	for i := range repoManifest.Package {
+		pkgSpec := &repoManifest.Package[i]
+		if (pkg != "" && pkgSpec.Name != pkg) || pkgSpec.Type == "standalone" {
+			continue
+		}
+		errPrefix := util.ErrPrefix(fmt.Sprintf("impl.Outdated(%s): ", pkgSpec.Name))
+		for _, upstreamSrc := range pkgSpec.UpstreamSrc {
+			entry := outdatedEntry{repo: repo, pkg: pkgSpec.Name}
+			if err := checker.checkUpstreamSrc(pkgSpec, upstreamSrc, &entry, errPrefix); err != nil {
+				failed++
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, failed, nil
+}
+
+// outdatedRepos returns the repos to check, which are all the repos in
+// SrcDir unless a repo is specified.
+func outdatedRepos(repo string) ([]string, error) {
+	srcDir := viper.GetString("SrcDir")
+	if repo != "" || srcDir == "" {
+		return []string{repo}, nil
+	}
+	dirEntries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return nil, err
+	}
+	var repos []string
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(srcDir, dirEntry.Name(), "eext.yaml")); err == nil {
+			repos = append(repos, dirEntry.Name())
+		}
+	}
+	return repos, nil
+}
+
+func writeOutdated(w io.Writer, entries []outdatedEntry) error {
+	tabWriter := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tabWriter, "REPO\tPACKAGE\tSOURCE\tCURRENT\tLATEST\tSTATUS")
+	for _, entry := range entries {
+		repo := entry.repo
+		if repo == "" {
+			repo = "."
+		}
+		fields := []string{repo, entry.pkg, entry.source, entry.current, entry.latest,
+			strings.ReplaceAll(entry.status, "\n", " ")}
+		for i, field := range fields {
+			if field == "" {
+				fields[i] = "-"
+			}
+		}
+		fmt.Fprintln(tabWriter, strings.Join(fields, "\t"))
+	}
+	return tabWriter.Flush()
+}
+
+// Outdated checks the upstream sources of the packages for newer versions,
+// in all the repos in SrcDir unless a repo is specified, and prints the
+// current and latest versions. Newer versions of tarballs and SRPMs are
+// looked up in the directory index of their source-bundle or full-url, and
+// also in the SRPM repos of the repo-bundles for SRPMs. Newer versions of
+// git-upstream sources are looked up in the tags of the git repo.
+func Outdated(repo string, pkg string) error {
+	if err := setup(); err != nil {
+		return err
+	}
+	srcConfig, err := srcconfig.LoadSrcConfig()
+	if err != nil {
+		return err
+	}
+	dnfConfig, err := dnfconfig.LoadDnfConfig(viper.GetString("DnfConfigFile"))
+	if err != nil {
+		return err
+	}
+	checker := &outdatedChecker{
+		srcConfig: srcConfig,
+		dnfConfig: dnfConfig,
+		client:    &http.Client{Timeout: time.Minute},
+	}
+
+	repos, err := outdatedRepos(repo)
+	if err != nil {
+		return fmt.Errorf("impl.Outdated: Error listing repos in SrcDir: %s", err)
+	}
+	var entries []outdatedEntry
+	failed := 0
+	for _, thisRepo := range repos {
+		repoEntries, repoFailed, err := checker.checkPackages(thisRepo, pkg)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, repoEntries...)
+		failed += repoFailed
+	}
+	if pkg != "" && len(entries) == 0 {
+		return fmt.Errorf("impl.Outdated: Invalid package name %s specified", pkg)
+	}
+	if err := writeOutdated(os.Stdout, entries); err != nil {
+		return err
+	}
+	if failed != 0 {
+		return fmt.Errorf("impl.Outdated: Checking %d upstream sources failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/dnfconfig"
+	"code.arista.io/eos/tools/eext/srcconfig"
+	"code.arista.io/eos/tools/eext/testutil"
+)
+
+const outdatedTestDnfConfig = `---
+distro:
+  el9:
+    releasever: 9
+    platform-id: platform:el9
+    chroot-setup-pkgs: [bash]
+repo-bundle:
+  el9:
+    distro: el9
+    baseurl: "{{.Host}}/repos/{{.Version}}/{{.RepoName}}/{{.Arch}}/"
+    repo:
+      BaseOS:
+        enabled: true
+      devel:
+        enabled: false
+    version-labels:
+      default: 9.4
+    priority: 2
+`
+
+const outdatedTestTarballsManifest = `---
+package:
+  - name: foo
+    upstream-sources:
+      - source-bundle:
+          name: tarball
+          override:
+            version: 1.0
+        signature:
+          skip-check: true
+    type: tarball
+    build:
+      repo-bundle:
+        - name: el9
+  - name: bar
+    upstream-sources:
+      - full-url: "{{.Host}}/dl/bar-2.0.tar.gz"
+        signature:
+          skip-check: true
+      - full-url: "{{.Host}}/dl/qux-v3.0.tar.xz"
+        signature:
+          skip-check: true
+      - full-url: "file:///local-1.0.tar.gz"
+        signature:
+          skip-check: true
+    type: tarball
+    build:
+      repo-bundle:
+        - name: el9
+`
+
+const outdatedTestSrpmManifest = `---
+package:
+  - name: baz
+    upstream-sources:
+      - source-bundle:
+          name: srpm
+          override:
+            version: 1.0-1.el9
+    type: srpm
+    build:
+      repo-bundle:
+        - name: el9
+  - name: missing
+    upstream-sources:
+      - full-url: "{{.Host}}/missing/missing-1.0-1.src.rpm"
+    type: unmodified-srpm
+    build:
+      repo-bundle:
+        - name: el9
+`
+
+func gzipped(t *testing.T, data string) string {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	_, err := io.WriteString(gzipWriter, data)
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	return buf.String()
+}
+
+func newOutdatedTestServer(t *testing.T) *httptest.Server {
+	indexPage := func(links ...string) string {
+		page := "<html><body>\n"
+		for _, link := range links {
+			page += fmt.Sprintf("<a href=\"%s\">%s</a>\n", link, link)
+		}
+		return page + "</body></html>\n"
+	}
+	pages := map[string]string{
+		"/prefix/source-bundles/tarball/foo/": indexPage(
+			"?C=N;O=D", "../", "1.0/", "1.2/", "1.10/", "latest", "README"),
+		"/dl/": indexPage("bar-2.0.tar.gz", "bar-2.0.tar.gz.sig", "bar-2.1.tar.gz",
+			"/dl/bar-2.0.1.tar.gz", "bar-2.2.tar.gz.sig", "bar-baz-3.0.tar.gz",
+			"bar-2.10.zip", "qux-v3.0.tar.xz", "qux-v2.9.tar.xz", "https://example.com/dl/bar-9.0.tar.gz"),
+		"/prefix/source-bundles/srpm/baz/": indexPage(
+			"baz-1.0-1.el9.src.rpm", "baz-1.0-2.el9.src.rpm", "bazz-2.0-1.el9.src.rpm"),
+		"/repos/9.4/BaseOS/source/repodata/repomd.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<repomd xmlns="http://linux.duke.edu/metadata/repo">
+  <data type="filelists"><location href="repodata/filelists.xml.gz"/></data>
+  <data type="primary"><location href="repodata/primary.xml.gz"/></data>
+</repomd>
+`,
+		"/repos/9.4/BaseOS/source/repodata/primary.xml.gz": gzipped(t, `<?xml version="1.0" encoding="UTF-8"?>
+<metadata xmlns="http://linux.duke.edu/metadata/common" packages="5">
+<package type="rpm"><name>baz</name><arch>src</arch><version epoch="0" ver="1.1" rel="1.el9"/></package>
+<package type="rpm"><name>baz</name><arch>src</arch><version epoch="1" ver="1.0" rel="1.el9"/></package>
+<package type="rpm"><name>baz</name><arch>src</arch><version epoch="1" ver="1.0" rel="3.el9"/></package>
+<package type="rpm"><name>baz</name><arch>x86_64</arch><version epoch="0" ver="9.0" rel="1.el9"/></package>
+<package type="rpm"><name>bazz</name><arch>src</arch><version epoch="0" ver="5.0" rel="1.el9"/></package>
+</metadata>
+`),
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, found := pages[r.URL.Path]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, page)
+	}))
+}
+
+func setupOutdatedTest(t *testing.T) (*outdatedChecker, string) {
+	server := newOutdatedTestServer(t)
+	t.Cleanup(server.Close)
+
+	srcDir := t.TempDir()
+	dnfConfigFile := filepath.Join(t.TempDir(), "dnfconfig.yaml")
+	require.NoError(t, os.WriteFile(dnfConfigFile, []byte(outdatedTestDnfConfig), 0644))
+	testutil.SetupViperConfig(srcDir, t.TempDir(), t.TempDir(), "", "",
+		server.URL, dnfConfigFile, server.URL, "", "prefix")
+	t.Cleanup(viper.Reset)
+
+	for repo, manifest := range map[string]string{
+		"tarballs": outdatedTestTarballsManifest,
+		"srpms":    outdatedTestSrpmManifest,
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(srcDir, repo), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, repo, "eext.yaml"),
+			[]byte(manifest), 0644))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "not-a-repo"), 0755))
+
+	srcConfig, err := srcconfig.LoadSrcConfig()
+	require.NoError(t, err)
+	dnfConfig, err := dnfconfig.LoadDnfConfig(dnfConfigFile)
+	require.NoError(t, err)
+	return &outdatedChecker{
+		srcConfig: srcConfig,
+		dnfConfig: dnfConfig,
+		client:    server.Client(),
+	}, srcDir
+}
+
+func TestOutdated(t *testing.T) {
+	checker, _ := setupOutdatedTest(t)
+
+	repos, err := outdatedRepos("")
+	require.NoError(t, err)
+	require.Equal(t, []string{"srpms", "tarballs"}, repos)
+	repos, err = outdatedRepos("tarballs")
+	require.NoError(t, err)
+	require.Equal(t, []string{"tarballs"}, repos)
+
+	entries, failed, err := checker.checkPackages("tarballs", "")
+	require.NoError(t, err)
+	require.Equal(t, 0, failed)
+	require.Equal(t, []outdatedEntry{
+		{"tarballs", "foo", "foo-1.0.tar.gz", "1.0", "1.10", "outdated"},
+		{"tarballs", "bar", "bar-2.0.tar.gz", "2.0", "2.1", "outdated"},
+		{"tarballs", "bar", "qux-v3.0.tar.xz", "3.0", "3.0", "up-to-date"},
+		{"tarballs", "bar", "local-1.0.tar.gz", "", "", "skipped"},
+	}, entries)
+
+	entries, failed, err = checker.checkPackages("srpms", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, failed)
+	require.Len(t, entries, 2)
+	// The epoch bump makes 1.0 newer than 1.1, the epoch of the current
+	// SRPM is the one in the repo
+	require.Equal(t, outdatedEntry{"srpms", "baz", "baz-1.0-1.el9.src.rpm",
+		"1:1.0-1.el9", "1:1.0-3.el9", "outdated"}, entries[0])
+	require.Equal(t, "missing", entries[1].pkg)
+	require.Contains(t, entries[1].status, "404 Not Found")
+
+	// The index is enough if the SRPM repos can't be read
+	checker.dnfConfig.DnfRepoBundleConfig["el9"].DnfRepoConfig["devel"].Enabled = true
+	entries, _, err = checker.checkPackages("srpms", "baz")
+	require.NoError(t, err)
+	require.Equal(t, outdatedEntry{"srpms", "baz", "baz-1.0-1.el9.src.rpm",
+		"1.0-1.el9", "1.0-2.el9", "outdated"}, entries[0])
+}
+
+func TestLatestGitTag(t *testing.T) {
+	tags := parseGitTags(strings.Join([]string{
+		"1111111111111111111111111111111111111111\trefs/tags/v1.7.13",
+		"2222222222222222222222222222222222222222\trefs/tags/v1.7.13^{}",
+		"3333333333333333333333333333333333333333\trefs/tags/v1.10.0",
+		"4444444444444444444444444444444444444444\trefs/tags/v1.9.2",
+		"5555555555555555555555555555555555555555\trefs/tags/libfoo-2.0",
+		"6666666666666666666666666666666666666666\trefs/tags/nightly",
+		"",
+	}, "\n"))
+	require.Equal(t, []string{"v1.7.13", "v1.10.0", "v1.9.2", "libfoo-2.0", "nightly"}, tags)
+
+	for _, testCase := range []struct {
+		revision  string
+		current   string
+		latestTag string
+	}{
+		{"v1.7.13", "1.7.13", "v1.10.0"},
+		{"v1.10.0", "1.10.0", "v1.10.0"},
+		{"libfoo-1.0", "1.0", "libfoo-2.0"},
+		// All the version tags are considered for revisions which aren't one
+		{"1234abcd", "", "libfoo-2.0"},
+	} {
+		current, _, latestTag := latestGitTag("https://github.com/foo/foo.git",
+			testCase.revision, tags)
+		require.Equal(t, testCase.current, current, testCase.revision)
+		require.Equal(t, testCase.latestTag, latestTag, testCase.revision)
+	}
+}
+
+func TestWriteOutdated(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeOutdated(&out, []outdatedEntry{
+		{"", "foo", "foo-1.0.tar.gz", "1.0", "1.10", "outdated"},
+		{"repo", "bar", "bar-2.0.tar.gz", "", "", "GET failed\nwith 404"},
+	}))
+	require.Equal(t, `REPO  PACKAGE  SOURCE          CURRENT  LATEST  STATUS
+.     foo      foo-1.0.tar.gz  1.0      1.10    outdated
+repo  bar      bar-2.0.tar.gz  -        -       GET failed with 404
+`, out.String())
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"code.arista.io/eos/tools/eext/util"
+)
+
+const srpmReportFilename = "create-srpm-report.yaml"
+
+// srpmSubmoduleEntry records a git submodule included in a source tarball,
+// at the commit pinned in the parent revision.
+type srpmSubmoduleEntry struct {
+	Path   string `yaml:"path"`
+	Source string `yaml:"source"`
+	Commit string `yaml:"commit"`
+}
+
+// srpmSourceEntry records where an upstream source came from and how it was verified.
+type srpmSourceEntry struct {
+	Source     string               `yaml:"source"`
+	Revision   string               `yaml:"revision,omitempty"`
+	File       string               `yaml:"file,omitempty"`
+	Sha256     string               `yaml:"sha256,omitempty"`
+	VerifiedBy string               `yaml:"verified-by,omitempty"`
+	SignedAt   string               `yaml:"signed-at,omitempty"`
+	SkipCheck  bool                 `yaml:"skip-sig-check,omitempty"`
+	Submodules []srpmSubmoduleEntry `yaml:"submodules,omitempty"`
+}
+
+// srpmVendoredEntry records a tarball of vendored dependencies
+// of an upstream source.
+type srpmVendoredEntry struct {
+	Tool      string `yaml:"tool"`
+	Source    string `yaml:"source"`
+	File      string `yaml:"file"`
+	Sha256    string `yaml:"sha256"`
+	CheckedIn bool   `yaml:"checked-in,omitempty"`
+}
+
+// srpmReport is written for each SRPM built by create-srpm.
+type srpmReport struct {
+	Package  string              `yaml:"package"`
+	Type     string              `yaml:"type"`
+	Sources  []srpmSourceEntry   `yaml:"sources"`
+	Vendored []srpmVendoredEntry `yaml:"vendored,omitempty"`
+}
+
+// writeReport records the provenance of the upstream sources
+// in <DestDir>/reports/<package>/create-srpm-report.yaml
+func (bldr *srpmBuilder) writeReport() error {
+	report := srpmReport{
+		Package: bldr.pkgSpec.Name,
+		Type:    bldr.pkgSpec.Type,
+	}
+	for _, upstreamSrc := range bldr.upstreamSrc {
+		entry := srpmSourceEntry{
+			Source:     upstreamSrc.srcURL,
+			File:       upstreamSrc.sourceFile,
+			VerifiedBy: upstreamSrc.verifiedBy,
+			SkipCheck:  upstreamSrc.skipSigCheck,
+		}
+		if !upstreamSrc.signedAt.IsZero() {
+			entry.SignedAt = upstreamSrc.signedAt.UTC().Format(time.RFC3339)
+		}
+		if bldr.pkgSpec.Type == "git-upstream" {
+			entry.Source = upstreamSrc.gitSpec.SrcUrl
+			entry.Revision = upstreamSrc.gitSpec.Revision
+			entry.Sha256 = upstreamSrc.sha256
+			for _, submodule := range upstreamSrc.submodules {
+				entry.Submodules = append(entry.Submodules, srpmSubmoduleEntry{
+					Path:   submodule.Path,
+					Source: submodule.URL,
+					Commit: submodule.Commit,
+				})
+			}
+		}
+		report.Sources = append(report.Sources, entry)
+	}
+	for _, vendored := range bldr.vendored {
+		report.Vendored = append(report.Vendored, srpmVendoredEntry{
+			Tool:      vendored.tool,
+			Source:    vendored.srcURL,
+			File:      vendored.file,
+			Sha256:    vendored.sha256,
+			CheckedIn: vendored.checkedIn,
+		})
+	}
+
+	yamlContents, err := yaml.Marshal(&report)
+	if err != nil {
+		return fmt.Errorf("%sError '%s' marshaling create-srpm report",
+			bldr.errPrefix, err)
+	}
+	reportsDir := getPkgReportsDir(bldr.pkgSpec.Name)
+	if err := util.MaybeCreateDirWithParents(reportsDir, bldr.executor, bldr.errPrefix); err != nil {
+		return err
+	}
+	reportPath := filepath.Join(reportsDir, srpmReportFilename)
+	if err := os.WriteFile(reportPath, yamlContents, 0644); err != nil {
+		return fmt.Errorf("%sError '%s' writing %s",
+			bldr.errPrefix, err, reportPath)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/manifest"
+)
+
+func TestWriteSrpmReport(t *testing.T) {
+	destDir := t.TempDir()
+	viper.Set("DestDir", destDir)
+	defer viper.Reset()
+
+	bldr := srpmBuilder{
+		pkgSpec: &manifest.Package{Name: "pkg1", Type: "git-upstream"},
+		upstreamSrc: []upstreamSrcSpec{
+			{
+				sourceFile: "Source0.tar.gz",
+				gitSpec: gitSpec{
+					SrcUrl:   "https://foo.org/pkg1.git",
+					Revision: "v1.0",
+				},
+				submodules: []gitSubmodule{
+					{Path: "lib", URL: "https://foo.org/lib.git", Commit: "0123abcd"},
+				},
+				sha256:     "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+				verifiedBy: "ssh ED25519 key SHA256:abc(maintainer@example.com)",
+			},
+			{
+				sourceFile:   "Source1.tar.gz",
+				gitSpec:      gitSpec{SrcUrl: "https://foo.org/pkg1-extras.git", Revision: "main"},
+				skipSigCheck: true,
+			},
+			{
+				sourceFile: "Source2.tar.gz",
+				gitSpec:    gitSpec{SrcUrl: "https://foo.org/pkg1-docs.git", Revision: "v2.0"},
+				verifiedBy: "gpg key 0BEBC75C7C49267869FD4EAC1129C5AC00C745C3",
+				signedAt:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+		},
+		vendored: []vendoredSrcSpec{
+			{
+				tool:      "go",
+				srcURL:    "https://foo.org/pkg1.git",
+				file:      "pkg1-vendor.tar.gz",
+				sha256:    "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752",
+				checkedIn: true,
+			},
+		},
+		executor: &executor.OsExecutor{},
+	}
+	require.NoError(t, bldr.writeReport())
+
+	contents, err := os.ReadFile(filepath.Join(destDir, "reports/pkg1", srpmReportFilename))
+	require.NoError(t, err)
+	var report srpmReport
+	require.NoError(t, yaml.UnmarshalStrict(contents, &report))
+	require.Equal(t, srpmReport{
+		Package: "pkg1",
+		Type:    "git-upstream",
+		Sources: []srpmSourceEntry{
+			{
+				Source:     "https://foo.org/pkg1.git",
+				Revision:   "v1.0",
+				File:       "Source0.tar.gz",
+				Sha256:     "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+				VerifiedBy: "ssh ED25519 key SHA256:abc(maintainer@example.com)",
+				Submodules: []srpmSubmoduleEntry{
+					{Path: "lib", Source: "https://foo.org/lib.git", Commit: "0123abcd"},
+				},
+			},
+			{
+				Source:    "https://foo.org/pkg1-extras.git",
+				Revision:  "main",
+				File:      "Source1.tar.gz",
+				SkipCheck: true,
+			},
+			{
+				Source:     "https://foo.org/pkg1-docs.git",
+				Revision:   "v2.0",
+				File:       "Source2.tar.gz",
+				VerifiedBy: "gpg key 0BEBC75C7C49267869FD4EAC1129C5AC00C745C3",
+				SignedAt:   "2024-01-02T03:04:05Z",
+			},
+		},
+		Vendored: []srpmVendoredEntry{
+			{
+				Tool:      "go",
+				Source:    "https://foo.org/pkg1.git",
+				File:      "pkg1-vendor.tar.gz",
+				Sha256:    "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752",
+				CheckedIn: true,
+			},
+		},
+	}, report)
+}
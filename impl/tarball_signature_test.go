@@ -0,0 +1,62 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+//go:build containerized
+
+package impl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyTarballSignature(t *testing.T) {
+	gnupgHome := t.TempDir()
+	gpgEnv := []string{"GNUPGHOME=" + gnupgHome}
+	runInDir(t, gnupgHome, gpgEnv, "gpg", "--batch", "--passphrase", "",
+		"--quick-gen-key", "Tarball Signer <tarball@example.com>", "ed25519", "sign", "never")
+	dir := t.TempDir()
+	pubKeyPath := filepath.Join(dir, "signer.pem")
+	require.NoError(t, os.WriteFile(pubKeyPath, []byte(runInDir(t, gnupgHome, gpgEnv,
+		"gpg", "--armor", "--export", "tarball@example.com")), 0644))
+
+	// Only the uncompressed tarball is signed
+	tarPath := filepath.Join(dir, "foo-1.0.tar")
+	require.NoError(t, os.WriteFile(tarPath, bytes.Repeat([]byte("foo"), 4096), 0644))
+	runInDir(t, dir, gpgEnv, "gpg", "--batch", "--armor", "--detach-sign",
+		"-o", "foo-1.0.tar.asc", tarPath)
+	var compressed bytes.Buffer
+	zstdWriter, err := zstd.NewWriter(&compressed)
+	require.NoError(t, err)
+	tarContents, err := os.ReadFile(tarPath)
+	require.NoError(t, err)
+	_, err = zstdWriter.Write(tarContents)
+	require.NoError(t, err)
+	require.NoError(t, zstdWriter.Close())
+	tarballPath := filepath.Join(dir, "foo-1.0.tar.zst")
+	require.NoError(t, os.WriteFile(tarballPath, compressed.Bytes(), 0644))
+	require.NoError(t, os.Remove(tarPath))
+
+	signer, err := verifyTarballSignature(tarballPath,
+		filepath.Join(dir, "foo-1.0.tar.asc"), pubKeyPath, "")
+	require.NoError(t, err)
+	require.Regexp(t, "^gpg key [0-9A-F]{40}$", signerDescription(signer))
+	require.NoFileExists(t, tarPath)
+
+	t.Log("Testing tampered tarball")
+	tarContents[0] = 'g'
+	compressed.Reset()
+	zstdWriter.Reset(&compressed)
+	_, err = zstdWriter.Write(tarContents)
+	require.NoError(t, err)
+	require.NoError(t, zstdWriter.Close())
+	require.NoError(t, os.WriteFile(tarballPath, compressed.Bytes(), 0644))
+	_, err = verifyTarballSignature(tarballPath,
+		filepath.Join(dir, "foo-1.0.tar.asc"), pubKeyPath, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Lines of context around the changes in unified diffs
+const diffContextLines = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// splitLines splits text into lines, keeping the line terminators,
+// so that a missing newline at the end is a difference.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// maxDiffEdits bounds the edit distance diffLines searches for. Its memory
+// grows with the square of the distance and its time with the distance times
+// the number of lines, files further apart are only reported to differ.
+const maxDiffEdits = 2000
+
+// diffLines returns the shortest edit script from a to b, with the Myers
+// diff algorithm. It returns false if the edit distance exceeds maxDiffEdits.
+func diffLines(a []string, b []string) ([]diffOp, bool) {
+	n, m := len(a), len(b)
+	max := n + m
+	if max > maxDiffEdits {
+		max = maxDiffEdits
+	}
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d] is v on the diagonals -d-1 to d+1 before the edits of
+	// distance d are explored, the only ones the edit script is rebuilt from
+	var trace [][]int
+	found := false
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int{}, v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break search
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		// Index of diagonal k in v
+		vOffset := d + 1
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[vOffset+k-1] < v[vOffset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[vOffset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffOp{'+', b[y-1]})
+				y--
+			} else {
+				ops = append(ops, diffOp{'-', a[x-1]})
+				x--
+			}
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops, true
+}
+
+// hunkRange formats the start and count of the lines of a hunk
+// in one of the files.
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// unifiedDiff returns the unified diff of the contents a and b, of the files
+// named aName and bName. It is empty if the contents are the same, and only
+// says that the files differ if they are too far apart to diff.
+func unifiedDiff(aName string, bName string, a string, b string) string {
+	if a == b {
+		return ""
+	}
+	ops, ok := diffLines(splitLines(a), splitLines(b))
+	if !ok {
+		return fmt.Sprintf("Files %s and %s differ\n", aName, bName)
+	}
+
+	// Line numbers in a and b before each op
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != '+' {
+			aPos[i+1]++
+		}
+		if op.kind != '-' {
+			bPos[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		// Changes closer than twice the context are in the same hunk
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+			nextChange := end
+			for nextChange < len(ops) && ops[nextChange].kind == ' ' {
+				nextChange++
+			}
+			if nextChange == len(ops) || nextChange-end > 2*diffContextLines {
+				break
+			}
+			end = nextChange
+		}
+		end += diffContextLines
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(aPos[start], aPos[end]-aPos[start]),
+			hunkRange(bPos[start], bPos[end]-bPos[start]))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// isBinary guesses if contents aren't text, like diff does.
+func isBinary(contents []byte) bool {
+	if len(contents) > 8000 {
+		contents = contents[:8000]
+	}
+	return bytes.IndexByte(contents, 0) != -1
+}
+
+// listTreeFiles lists the files and symlinks in dir, relative to dir.
+// It is empty if dir doesn't exist.
+func listTreeFiles(dir string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return files, nil
+	}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			relPath, _ := filepath.Rel(dir, path)
+			files[relPath] = true
+		}
+		return nil
+	})
+	return files, err
+}
+
+// readTreeFile reads the file in a tree for diffing, symlinks are
+// compared by their target.
+func readTreeFile(path string) ([]byte, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		return []byte("symlink to " + target + "\n"), err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil
+	}
+	return os.ReadFile(path)
+}
+
+// diffTrees returns the unified diff of all the files in the dirs aDir and
+// bDir, like diff -ruN. The files are named with the aLabel and bLabel
+// prefixes in the diff.
+func diffTrees(aDir string, bDir string, aLabel string, bLabel string) (string, error) {
+	aFiles, err := listTreeFiles(aDir)
+	if err != nil {
+		return "", err
+	}
+	bFiles, err := listTreeFiles(bDir)
+	if err != nil {
+		return "", err
+	}
+	var relPaths []string
+	for relPath := range aFiles {
+		relPaths = append(relPaths, relPath)
+	}
+	for relPath := range bFiles {
+		if !aFiles[relPath] {
+			relPaths = append(relPaths, relPath)
+		}
+	}
+	sort.Strings(relPaths)
+
+	var out strings.Builder
+	for _, relPath := range relPaths {
+		aContents, err := readTreeFile(filepath.Join(aDir, relPath))
+		if err != nil {
+			return "", err
+		}
+		bContents, err := readTreeFile(filepath.Join(bDir, relPath))
+		if err != nil {
+			return "", err
+		}
+		if bytes.Equal(aContents, bContents) {
+			continue
+		}
+		aName := filepath.ToSlash(filepath.Join(aLabel, relPath))
+		bName := filepath.ToSlash(filepath.Join(bLabel, relPath))
+		if !aFiles[relPath] {
+			aName = "/dev/null"
+		}
+		if !bFiles[relPath] {
+			bName = "/dev/null"
+		}
+		if isBinary(aContents) || isBinary(bContents) {
+			fmt.Fprintf(&out, "Binary files %s and %s differ\n", aName, bName)
+			continue
+		}
+		out.WriteString(unifiedDiff(aName, bName, string(aContents), string(bContents)))
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	require.Equal(t, "", unifiedDiff("a", "b", "foo\n", "foo\n"))
+
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n15\n16\n"
+	require.Equal(t, `--- a
++++ b
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -11,5 +11,5 @@
+ 11
+ 12
+ 13
+-14
+ 15
++16
+`, unifiedDiff("a", "b", a, b))
+
+	// Changes closer than twice the context are in one hunk
+	require.Equal(t, `--- a
++++ b
+@@ -1,11 +1,11 @@
+-1
++one
+ 2
+ 3
+ 4
+ 5
+ 6
+ 7
+-8
++eight
+ 9
+ 10
+ 11
+`, unifiedDiff("a", "b", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+		"one\n2\n3\n4\n5\n6\n7\neight\n9\n10\n11\n"))
+
+	require.Equal(t, `--- /dev/null
++++ b
+@@ -0,0 +1,2 @@
++foo
++bar
+\ No newline at end of file
+`, unifiedDiff("/dev/null", "b", "", "foo\nbar"))
+
+	// Large files are diffed if they are close
+	var large, largeChanged, unrelated strings.Builder
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&large, "%d\n", i)
+		if i == 50000 {
+			largeChanged.WriteString("changed\n")
+		} else {
+			fmt.Fprintf(&largeChanged, "%d\n", i)
+		}
+		fmt.Fprintf(&unrelated, "unrelated %d\n", i)
+	}
+	require.Equal(t, `--- a
++++ b
+@@ -49998,7 +49998,7 @@
+ 49997
+ 49998
+ 49999
+-50000
++changed
+ 50001
+ 50002
+ 50003
+`, unifiedDiff("a", "b", large.String(), largeChanged.String()))
+	// and only reported to differ otherwise
+	require.Equal(t, "Files a and b differ\n",
+		unifiedDiff("a", "b", large.String(), unrelated.String()))
+}
+
+func TestDiffTrees(t *testing.T) {
+	aDir := t.TempDir()
+	bDir := t.TempDir()
+	for dir, files := range map[string]map[string]string{
+		aDir: {
+			"foo-1.0/same.c":    "same\n",
+			"foo-1.0/changed.c": "int a;\n",
+			"foo-1.0/removed.c": "gone\n",
+			"foo-1.0/blob":      "\x00\x01",
+		},
+		bDir: {
+			"foo-1.0/same.c":    "same\n",
+			"foo-1.0/changed.c": "int b;\n",
+			"foo-1.0/added.c":   "new\n",
+			"foo-1.0/blob":      "\x00\x02",
+		},
+	} {
+		for name, contents := range files {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+		}
+	}
+	require.NoError(t, os.Symlink("same.c", filepath.Join(bDir, "foo-1.0/link")))
+
+	treeDiff, err := diffTrees(aDir, bDir, "upstream", "eext")
+	require.NoError(t, err)
+	require.Equal(t, `--- /dev/null
++++ eext/foo-1.0/added.c
+@@ -0,0 +1 @@
++new
+Binary files upstream/foo-1.0/blob and eext/foo-1.0/blob differ
+--- upstream/foo-1.0/changed.c
++++ eext/foo-1.0/changed.c
+@@ -1 +1 @@
+-int a;
++int b;
+--- /dev/null
++++ eext/foo-1.0/link
+@@ -0,0 +1 @@
++symlink to same.c
+--- upstream/foo-1.0/removed.c
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+`, treeDiff)
+
+	treeDiff, err = diffTrees(aDir, aDir, "upstream", "eext")
+	require.NoError(t, err)
+	require.Equal(t, "", treeDiff)
+}
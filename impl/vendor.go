@@ -0,0 +1,381 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"archive/tar"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.arista.io/eos/tools/eext/decompress"
+	"code.arista.io/eos/tools/eext/manifest"
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// vendoredSrcSpec is a tarball of vendored dependencies of an upstream source
+type vendoredSrcSpec struct {
+	tool string
+	// URL of the upstream source whose dependencies are vendored
+	srcURL    string
+	file      string
+	sha256    string
+	checkedIn bool
+}
+
+// Files in vendored tarballs all have this mtime, so that the tarballs
+// only depend on the vendored files.
+var vendorArchiveModTime = time.Unix(0, 0).UTC()
+
+// Commands run in the dir of the upstream source tree with go.mod,
+// Cargo.toml or requirements.txt to vendor the dependencies to vendor/
+// pip only downloads wheels, it would run the build backends of sdists
+// to get their metadata.
+var vendorCmds = map[string][]string{
+	"go":    {"go", "mod", "vendor"},
+	"cargo": {"cargo", "vendor", "--locked", "--versioned-dirs", "vendor"},
+	"pip": {"python3", "-m", "pip", "download", "--no-deps", "--no-cache-dir",
+		"--only-binary=:all:", "--disable-pip-version-check",
+		"-r", "requirements.txt", "-d", "vendor"},
+}
+
+// Environment variables passed from eext's environment to the vendoring
+// tools. These configure proxies and package mirrors, everything else
+// comes from vendorEnv.
+var vendorPassEnv = []string{
+	"PATH",
+	"http_proxy", "https_proxy", "no_proxy", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+	"SSL_CERT_FILE", "SSL_CERT_DIR",
+	"GOPROXY", "GOSUMDB", "GOPRIVATE", "GONOPROXY", "GONOSUMDB",
+	"PIP_INDEX_URL", "PIP_EXTRA_INDEX_URL",
+}
+
+// vendorEnv returns the environment the vendoring tool runs in, which
+// doesn't depend on the user's config and caches. homeDir is a new dir
+// for the tool's config and caches.
+func vendorEnv(tool string, homeDir string) []string {
+	env := []string{"HOME=" + homeDir, "LANG=C.UTF-8"}
+	for _, name := range vendorPassEnv {
+		if value, found := os.LookupEnv(name); found {
+			env = append(env, name+"="+value)
+		}
+	}
+	switch tool {
+	case "go":
+		env = append(env,
+			"GOPATH="+filepath.Join(homeDir, "go"),
+			"GOMODCACHE="+filepath.Join(homeDir, "go/pkg/mod"),
+			"GOCACHE="+filepath.Join(homeDir, "go-build"),
+			// Lets the work dir be removed
+			"GOFLAGS=-modcacherw",
+			"GOTOOLCHAIN=local",
+			"GOWORK=off",
+			"GO111MODULE=on")
+	case "cargo":
+		env = append(env, "CARGO_HOME="+filepath.Join(homeDir, "cargo"))
+	case "pip":
+		env = append(env, "PIP_CONFIG_FILE=/dev/null", "PIP_NO_INPUT=1")
+	}
+	return env
+}
+
+// throughSymlink checks if name, or one of its parent dirs,
+// is one of the symlinks extracted
+func throughSymlink(name string, symlinks map[string]bool) bool {
+	for ; name != "."; name = path.Dir(name) {
+		if symlinks[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// openTarball opens the tarball, which can be compressed, for reading.
+func openTarball(tarballPath string) (*tar.Reader, io.Closer, error) {
+	tarball, err := os.Open(tarballPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	bufReader := bufio.NewReader(tarball)
+	format, err := decompress.Detect(bufReader)
+	if err != nil {
+		tarball.Close()
+		return nil, nil, err
+	}
+	stream := &multiCloser{Reader: bufReader, closers: []io.Closer{tarball}}
+	if format != "" {
+		decompressor, err := decompress.NewReader(bufReader, format)
+		if err != nil {
+			tarball.Close()
+			return nil, nil, err
+		}
+		stream.Reader = decompressor
+		stream.closers = append(stream.closers, decompressor)
+	}
+	return tar.NewReader(stream), stream, nil
+}
+
+// extractTarball extracts the tarball, which can be compressed,
+// to destDir. Entries which would be extracted outside destDir,
+// directly or through symlinks, are errors.
+func extractTarball(tarballPath string, destDir string) error {
+	tarReader, closer, err := openTarball(tarballPath)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	symlinks := make(map[string]bool)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading %s: %s", tarballPath, err)
+		}
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("%s in %s is outside the source tree", header.Name, tarballPath)
+		}
+		if throughSymlink(name, symlinks) {
+			return fmt.Errorf("%s in %s is a symlink, or under one", header.Name, tarballPath)
+		}
+		target := filepath.Join(destDir, filepath.FromSlash(name))
+		if header.Typeflag != tar.TypeXGlobalHeader {
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			mode := os.FileMode(0644)
+			if header.Mode&0111 != 0 {
+				mode = 0755
+			}
+			file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, tarReader)
+			file.Close()
+			if err != nil {
+				return fmt.Errorf("error extracting %s from %s: %s",
+					header.Name, tarballPath, err)
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+			symlinks[name] = true
+		case tar.TypeLink:
+			linkName := path.Clean(header.Linkname)
+			if path.IsAbs(linkName) || linkName == ".." || strings.HasPrefix(linkName, "../") ||
+				throughSymlink(linkName, symlinks) {
+				return fmt.Errorf("bad hard link %s to %s in %s",
+					header.Name, header.Linkname, tarballPath)
+			}
+			if err := os.Link(filepath.Join(destDir, filepath.FromSlash(linkName)),
+				target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sourceTopDir returns the top dir of the source tree extracted in dir,
+// which is the only dir in it for tarballs with a top level dir.
+func sourceTopDir(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+	return dir, nil
+}
+
+// appendDirToTarball appends the files in dir to the tarball under name,
+// in lexical order and with normalized headers.
+func appendDirToTarball(tarWriter *tar.Writer, dir string, name string,
+	modTime time.Time) error {
+	return filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		header := &tar.Header{
+			Name: path.Join(name, filepath.ToSlash(relPath)),
+			Mode: int64(info.Mode().Perm()),
+		}
+		switch {
+		case info.IsDir():
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+		case info.Mode().IsRegular():
+			header.Typeflag = tar.TypeReg
+			header.Size = info.Size()
+		case info.Mode()&os.ModeSymlink != 0:
+			header.Typeflag = tar.TypeSymlink
+			if header.Linkname, err = os.Readlink(filePath); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected file type of %s", filePath)
+		}
+		normalized, err := normalizeTarHeader(header, modTime)
+		if err != nil {
+			return err
+		}
+		if err := tarWriter.WriteHeader(normalized); err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+}
+
+// generateVendorTarball extracts the upstream source tarball in a new dir
+// under workDir, vendors its dependencies with the vendor tool and writes
+// the vendor dir to the tarball at archivePath. The tarball has
+// <vendor.Dir>/vendor, to unpack in the top dir of the source tree.
+func generateVendorTarball(archivePath string, srcTarballPath string,
+	vendor manifest.Vendor, workDir string) error {
+	vendorWorkDir, err := os.MkdirTemp(workDir, "vendor")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(vendorWorkDir)
+
+	srcDir := filepath.Join(vendorWorkDir, "src")
+	homeDir := filepath.Join(vendorWorkDir, "home")
+	for _, dir := range []string{srcDir, homeDir} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := extractTarball(srcTarballPath, srcDir); err != nil {
+		return err
+	}
+	topDir, err := sourceTopDir(srcDir)
+	if err != nil {
+		return err
+	}
+	vendorDir := path.Clean(vendor.Dir)
+	moduleDir := filepath.Join(topDir, filepath.FromSlash(vendorDir))
+	if _, err := os.Stat(moduleDir); err != nil {
+		return fmt.Errorf("dir %s not found in %s", vendorDir, filepath.Base(srcTarballPath))
+	}
+
+	vendorCmd := vendorCmds[vendor.Tool]
+	cmd := exec.Command(vendorCmd[0], vendorCmd[1:]...)
+	cmd.Dir = moduleDir
+	cmd.Env = vendorEnv(vendor.Tool, homeDir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("'%s' failed: %s\n%s", strings.Join(vendorCmd, " "), err, output)
+	}
+	if _, err := os.Stat(filepath.Join(moduleDir, "vendor")); err != nil {
+		return fmt.Errorf("'%s' didn't vendor any dependencies", strings.Join(vendorCmd, " "))
+	}
+
+	return writeTarball(archivePath, func(tarWriter *tar.Writer) error {
+		return appendDirToTarball(tarWriter, filepath.Join(moduleDir, "vendor"),
+			path.Join(vendorDir, "vendor"), vendorArchiveModTime)
+	})
+}
+
+// vendorUpstream vendors the dependencies of the upstream sources
+// listed in the manifest, and populates bldr.vendored
+// Expects fetchUpstream and verifyUpstream to have been called before,
+// so that only verified sources are vendored.
+// The vendoring tools are run in-process rather than through the executor,
+// so on dry runs this only prints the tarballs which would be generated.
+func (bldr *srpmBuilder) vendorUpstream() error {
+	bldr.log("starting")
+
+	pkg := bldr.pkgSpec.Name
+	downloadDir := getDownloadDir(pkg)
+	repoSourcesDir := getPkgSourcesDirInRepo(bldr.repo, pkg, bldr.pkgSpec.Subdir)
+	for _, vendor := range bldr.pkgSpec.Vendor {
+		upstreamSrc := bldr.upstreamSrc[vendor.Source]
+		srcURL := upstreamSrc.srcURL
+		if bldr.pkgSpec.Type == "git-upstream" {
+			srcURL = upstreamSrc.gitSpec.SrcUrl
+		}
+
+		archiveDir := downloadDir
+		if vendor.CheckedIn {
+			archiveDir = repoSourcesDir
+		}
+		archivePath := filepath.Join(archiveDir, vendor.Archive)
+		if _, err := os.Stat(archivePath); vendor.CheckedIn && err == nil {
+			bldr.log("using checked in %s", archivePath)
+		} else if isDryRun(bldr.executor) {
+			fmt.Printf("Would vendor %s dependencies of %s to %s\n",
+				vendor.Tool, upstreamSrc.sourceFile, archivePath)
+			continue
+		} else {
+			bldr.log("vendoring %s dependencies of %s to %s",
+				vendor.Tool, upstreamSrc.sourceFile, vendor.Archive)
+			if err := util.MaybeCreateDirWithParents(archiveDir, bldr.executor,
+				bldr.errPrefix); err != nil {
+				return err
+			}
+			if err := generateVendorTarball(archivePath,
+				filepath.Join(downloadDir, upstreamSrc.sourceFile),
+				vendor, downloadDir); err != nil {
+				return fmt.Errorf("%sError vendoring %s dependencies of %s: %s",
+					bldr.errPrefix, vendor.Tool, upstreamSrc.sourceFile, err)
+			}
+			if vendor.CheckedIn {
+				bldr.log("generated %s, check it in to the repo", archivePath)
+			}
+		}
+
+		archiveSha256, err := util.GenerateHash(archivePath, "sha256")
+		if err != nil {
+			return fmt.Errorf("%s%s", bldr.errPrefix, err)
+		}
+		bldr.log("%s has sha256 %s", vendor.Archive, archiveSha256)
+		if vendor.Sha256 != "" {
+			if err := checkHash(archivePath, "sha256", vendor.Sha256,
+				bldr.errPrefix); err != nil {
+				return err
+			}
+		}
+
+		bldr.vendored = append(bldr.vendored, vendoredSrcSpec{
+			tool:      vendor.Tool,
+			srcURL:    srcURL,
+			file:      vendor.Archive,
+			sha256:    archiveSha256,
+			checkedIn: vendor.CheckedIn,
+		})
+	}
+
+	bldr.log("successful")
+	return nil
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package impl
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/executor"
+	"code.arista.io/eos/tools/eext/manifest"
+)
+
+type testTarEntry struct {
+	header   tar.Header
+	contents string
+}
+
+// writeTestTarball writes a tar.gz with the entries to a new file
+func writeTestTarball(t *testing.T, entries []testTarEntry) string {
+	tarballPath := filepath.Join(t.TempDir(), "test.tar.gz")
+	require.NoError(t, writeTarball(tarballPath, func(tarWriter *tar.Writer) error {
+		for _, entry := range entries {
+			header := entry.header
+			header.Size = int64(len(entry.contents))
+			if err := tarWriter.WriteHeader(&header); err != nil {
+				return err
+			}
+			if _, err := tarWriter.Write([]byte(entry.contents)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	return tarballPath
+}
+
+func TestExtractTarball(t *testing.T) {
+	tarballPath := writeTestTarball(t, []testTarEntry{
+		{header: tar.Header{Typeflag: tar.TypeDir, Name: "foo-1.0/", Mode: 0755}},
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "foo-1.0/go.mod", Mode: 0644},
+			contents: "module foo\n"},
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "foo-1.0/tools/gen.sh", Mode: 0775},
+			contents: "#!/bin/sh\n"},
+		{header: tar.Header{Typeflag: tar.TypeSymlink, Name: "foo-1.0/gen.sh",
+			Linkname: "tools/gen.sh"}},
+		{header: tar.Header{Typeflag: tar.TypeLink, Name: "foo-1.0/go.mod.orig",
+			Linkname: "foo-1.0/go.mod"}},
+	})
+	destDir := t.TempDir()
+	require.NoError(t, extractTarball(tarballPath, destDir))
+	topDir, err := sourceTopDir(destDir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(destDir, "foo-1.0"), topDir)
+	contents, err := os.ReadFile(filepath.Join(topDir, "go.mod.orig"))
+	require.NoError(t, err)
+	require.Equal(t, "module foo\n", string(contents))
+	info, err := os.Stat(filepath.Join(topDir, "gen.sh"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	for name, entries := range map[string][]testTarEntry{
+		"outside": {
+			{header: tar.Header{Typeflag: tar.TypeReg, Name: "foo/../../passwd", Mode: 0644}},
+		},
+		"absolute": {
+			{header: tar.Header{Typeflag: tar.TypeReg, Name: "/etc/passwd", Mode: 0644}},
+		},
+		"under symlink": {
+			{header: tar.Header{Typeflag: tar.TypeSymlink, Name: "foo/etc", Linkname: "/etc"}},
+			{header: tar.Header{Typeflag: tar.TypeReg, Name: "foo/etc/passwd", Mode: 0644}},
+		},
+		"over symlink": {
+			{header: tar.Header{Typeflag: tar.TypeSymlink, Name: "foo/passwd",
+				Linkname: "/etc/passwd"}},
+			{header: tar.Header{Typeflag: tar.TypeReg, Name: "foo/passwd", Mode: 0644}},
+		},
+		"hard link through symlink": {
+			{header: tar.Header{Typeflag: tar.TypeSymlink, Name: "foo/etc", Linkname: "/etc"}},
+			{header: tar.Header{Typeflag: tar.TypeLink, Name: "foo/passwd",
+				Linkname: "foo/etc/passwd"}},
+		},
+	} {
+		t.Logf("Testing %s", name)
+		err := extractTarball(writeTestTarball(t, entries), t.TempDir())
+		require.Error(t, err, name)
+	}
+}
+
+func TestAppendDirToTarball(t *testing.T) {
+	vendorDir := filepath.Join(t.TempDir(), "vendor")
+	require.NoError(t, os.MkdirAll(filepath.Join(vendorDir, "github.com/foo/bar"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(vendorDir, "modules.txt"),
+		[]byte("# github.com/foo/bar v1.0.0\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(vendorDir, "github.com/foo/bar/bar.go"),
+		[]byte("package bar\n"), 0600))
+
+	var tarballPaths []string
+	var tarballs [][]byte
+	for i := 0; i < 2; i++ {
+		tarballPath := filepath.Join(t.TempDir(), "vendor.tar.gz")
+		tarballPaths = append(tarballPaths, tarballPath)
+		require.NoError(t, writeTarball(tarballPath, func(tarWriter *tar.Writer) error {
+			return appendDirToTarball(tarWriter, vendorDir, "src/vendor", vendorArchiveModTime)
+		}))
+		contents, err := os.ReadFile(tarballPath)
+		require.NoError(t, err)
+		tarballs = append(tarballs, contents)
+		// The tarball doesn't depend on the mtimes and modes of the files
+		require.NoError(t, os.Chmod(filepath.Join(vendorDir, "modules.txt"), 0640))
+	}
+	require.Equal(t, tarballs[0], tarballs[1])
+
+	destDir := t.TempDir()
+	require.NoError(t, extractTarball(tarballPaths[0], destDir))
+	contents, err := os.ReadFile(filepath.Join(destDir, "src/vendor/github.com/foo/bar/bar.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package bar\n", string(contents))
+}
+
+func TestVendorEnv(t *testing.T) {
+	t.Setenv("GOPROXY", "https://proxy.example.com")
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("CARGO_HOME", "/home/user/.cargo")
+
+	env := vendorEnv("go", "/tmp/home")
+	require.Contains(t, env, "HOME=/tmp/home")
+	require.Contains(t, env, "GOPROXY=https://proxy.example.com")
+	require.Contains(t, env, "GOMODCACHE=/tmp/home/go/pkg/mod")
+	require.Contains(t, env, "GOFLAGS=-modcacherw")
+	require.NotContains(t, env, "GOFLAGS=-mod=mod")
+
+	env = vendorEnv("cargo", "/tmp/home")
+	require.Contains(t, env, "CARGO_HOME=/tmp/home/cargo")
+	require.NotContains(t, env, "CARGO_HOME=/home/user/.cargo")
+}
+
+func TestVendorUpstreamDryRun(t *testing.T) {
+	viper.Set("WorkingDir", t.TempDir())
+	viper.Set("SrcDir", t.TempDir())
+	defer viper.Reset()
+
+	bldr := &srpmBuilder{
+		pkgSpec: &manifest.Package{
+			Name: "foo",
+			Type: "tarball",
+			Vendor: []manifest.Vendor{
+				{Tool: "go", Archive: "foo-vendor.tar.gz"},
+			},
+		},
+		repo: "foo",
+		upstreamSrc: []upstreamSrcSpec{
+			{srcURL: "https://example.com/foo-1.0.tar.gz", sourceFile: "foo-1.0.tar.gz"},
+		},
+		executor: &executor.DryRunExecutor{},
+	}
+	require.NoError(t, bldr.vendorUpstream())
+	require.Empty(t, bldr.vendored)
+	require.NoFileExists(t, filepath.Join(getDownloadDir("foo"), "foo-vendor.tar.gz"))
+}
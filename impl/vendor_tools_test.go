@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+//go:build containerized
+
+package impl
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"code.arista.io/eos/tools/eext/manifest"
+)
+
+func TestGenerateVendorTarballGo(t *testing.T) {
+	// A module whose dependency is replaced by a local dir,
+	// so that it's vendored without network access.
+	srcTarballPath := writeTestTarball(t, []testTarEntry{
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "foo-1.0/cmd/go.mod", Mode: 0644},
+			contents: "module example.com/foo\n\ngo 1.18\n\n" +
+				"require example.com/dep v0.0.0\n\n" +
+				"replace example.com/dep => ../dep\n"},
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "foo-1.0/cmd/main.go", Mode: 0644},
+			contents: "package main\n\nimport \"example.com/dep\"\n\n" +
+				"func main() { dep.Run() }\n"},
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "foo-1.0/dep/go.mod", Mode: 0644},
+			contents: "module example.com/dep\n\ngo 1.18\n"},
+		{header: tar.Header{Typeflag: tar.TypeReg, Name: "foo-1.0/dep/dep.go", Mode: 0644},
+			contents: "package dep\n\nfunc Run() {}\n"},
+	})
+	t.Setenv("GOPROXY", "off")
+
+	vendor := manifest.Vendor{Tool: "go", Dir: "cmd", Archive: "foo-vendor.tar.gz"}
+	var tarballs [][]byte
+	for i := 0; i < 2; i++ {
+		workDir := t.TempDir()
+		archivePath := filepath.Join(workDir, vendor.Archive)
+		require.NoError(t, generateVendorTarball(archivePath, srcTarballPath, vendor, workDir))
+		contents, err := os.ReadFile(archivePath)
+		require.NoError(t, err)
+		tarballs = append(tarballs, contents)
+
+		if i == 0 {
+			destDir := t.TempDir()
+			require.NoError(t, extractTarball(archivePath, destDir))
+			require.FileExists(t, filepath.Join(destDir, "cmd/vendor/modules.txt"))
+			require.FileExists(t, filepath.Join(destDir, "cmd/vendor/example.com/dep/dep.go"))
+		}
+	}
+	require.Equal(t, tarballs[0], tarballs[1])
+
+	t.Log("Testing missing dir")
+	vendor.Dir = "missing"
+	err := generateVendorTarball(filepath.Join(t.TempDir(), vendor.Archive), srcTarballPath,
+		vendor, t.TempDir())
+	require.ErrorContains(t, err, "dir missing not found")
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package lockfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"code.arista.io/eos/tools/eext/util"
+)
+
+// LockfileName is the name of the lockfile, which is checked in
+// next to the manifest.
+const LockfileName = "eext.lock"
+
+// Repo spec
+// BaseURL is the resolved baseurl of the dnf repo, indexed by target arch.
+type Repo struct {
+	Name    string            `yaml:"name"`
+	BaseURL map[string]string `yaml:"baseurl"`
+}
+
+// RepoBundle spec
+// Name and Version are as specified in the manifest,
+// ResolvedVersion is the concrete version that Version resolved to.
+type RepoBundle struct {
+	Name            string `yaml:"name"`
+	Version         string `yaml:"version"`
+	ResolvedVersion string `yaml:"resolved-version"`
+	Repo            []Repo `yaml:"repo"`
+}
+
+// Package spec
+// RepoBundle is in the same order as the repo-bundles in the manifest.
+type Package struct {
+	Name       string       `yaml:"name"`
+	RepoBundle []RepoBundle `yaml:"repo-bundle"`
+}
+
+// Lockfile spec
+// This is loaded from eext.lock
+type Lockfile struct {
+	Package []Package `yaml:"package"`
+}
+
+// GetPackage returns the lock for the package named pkg,
+// or nil if the package isn't locked.
+func (l *Lockfile) GetPackage(pkg string) *Package {
+	for i := range l.Package {
+		if l.Package[i].Name == pkg {
+			return &l.Package[i]
+		}
+	}
+	return nil
+}
+
+// GetRepo returns the lock for the repo named repoName in the bundle,
+// or nil if the repo isn't locked.
+func (b *RepoBundle) GetRepo(repoName string) *Repo {
+	for i := range b.Repo {
+		if b.Repo[i].Name == repoName {
+			return &b.Repo[i]
+		}
+	}
+	return nil
+}
+
+func getLockfilePath(repo string) string {
+	return filepath.Join(util.GetRepoDir(repo), LockfileName)
+}
+
+// LoadLockfile loads the lockfile for the repo to memory and
+// returns the data structure.
+// If the repo doesn't have a lockfile, nil is returned.
+func LoadLockfile(repo string) (*Lockfile, error) {
+	lockfilePath := getLockfilePath(repo)
+	yamlContents, readErr := os.ReadFile(lockfilePath)
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("lockfile.LoadLockfile: os.ReadFile on %s returned %s",
+			lockfilePath, readErr)
+	}
+
+	var lockfile Lockfile
+	if parseErr := yaml.UnmarshalStrict(yamlContents, &lockfile); parseErr != nil {
+		return nil, fmt.Errorf("lockfile.LoadLockfile: Error parsing yaml file %s: %s",
+			lockfilePath, parseErr)
+	}
+	return &lockfile, nil
+}
+
+// WriteLockfile writes the lockfile to the repo
+func WriteLockfile(repo string, lockfile *Lockfile) error {
+	lockfilePath := getLockfilePath(repo)
+	yamlContents, marshalErr := yaml.Marshal(lockfile)
+	if marshalErr != nil {
+		return fmt.Errorf("lockfile.WriteLockfile: Error marshaling lockfile: %s",
+			marshalErr)
+	}
+
+	header := "# Generated by 'eext lock'. DO NOT EDIT.\n---\n"
+	contents := append([]byte(header), yamlContents...)
+	if writeErr := os.WriteFile(lockfilePath, contents, 0644); writeErr != nil {
+		return fmt.Errorf("lockfile.WriteLockfile: os.WriteFile on %s returned %s",
+			lockfilePath, writeErr)
+	}
+	return nil
+}
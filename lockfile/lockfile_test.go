@@ -0,0 +1,74 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockfile(t *testing.T) {
+	srcDir, err := os.MkdirTemp("", "lockfile-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(srcDir)
+	viper.Set("SrcDir", srcDir)
+	defer viper.Reset()
+
+	repo := "pkg1"
+	require.NoError(t, os.Mkdir(filepath.Join(srcDir, repo), 0775))
+
+	t.Log("Testing LoadLockfile without a lockfile")
+	lock, loadErr := LoadLockfile(repo)
+	require.NoError(t, loadErr)
+	require.Nil(t, lock)
+
+	expected := &Lockfile{
+		Package: []Package{
+			{
+				Name: "pkg1",
+				RepoBundle: []RepoBundle{
+					{
+						Name:            "el9",
+						ResolvedVersion: "9.3",
+						Repo: []Repo{
+							{
+								Name: "BaseOS",
+								BaseURL: map[string]string{
+									"x86_64":  "https://foo.org/9.3/BaseOS/x86_64/os",
+									"aarch64": "https://foo.org/9.3/BaseOS/aarch64/os",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Log("Testing WriteLockfile/LoadLockfile")
+	require.NoError(t, WriteLockfile(repo, expected))
+	require.FileExists(t, filepath.Join(srcDir, repo, LockfileName))
+	lock, loadErr = LoadLockfile(repo)
+	require.NoError(t, loadErr)
+	require.Equal(t, expected, lock)
+
+	t.Log("Testing lookups")
+	require.Nil(t, lock.GetPackage("pkg2"))
+	lockedPkg := lock.GetPackage("pkg1")
+	require.NotNil(t, lockedPkg)
+	require.NotNil(t, lockedPkg.RepoBundle[0].GetRepo("BaseOS"))
+	require.Nil(t, lockedPkg.RepoBundle[0].GetRepo("AppStream"))
+
+	t.Log("Testing LoadLockfile with a bad lockfile")
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, repo, LockfileName),
+		[]byte("package:\n  - name: pkg1\n    bad-key: foo\n"), 0644))
+	_, loadErr = LoadLockfile(repo)
+	require.Error(t, loadErr)
+}
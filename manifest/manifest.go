@@ -5,8 +5,12 @@ package manifest
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v2"
@@ -18,10 +22,12 @@ import (
 
 // Repo spec
 // mock cfg dnf.conf is generated from this
+// Modules take precedence over the modules of the bundle in the dnf config file.
 type RepoBundle struct {
 	Name                  string                                     `yaml:"name"`
 	VersionOverride       string                                     `yaml:"version"`
 	DnfRepoParamsOverride map[string]dnfconfig.DnfRepoParamsOverride `yaml:"override"`
+	Modules               dnfconfig.DnfModules                       `yaml:"modules"`
 }
 
 // MultilibRpmFilenamePattern spec
@@ -55,6 +61,7 @@ type Multilib struct {
 //	Valid options for create-srpm are [ --do-build-prep ]
 //
 // MultiLib specifies MultiLib spec to generate multilib. It's indexed by native-arch (i686/x86_64).
+// 'eext multilib' also uses this to compose the multilib RPM set for a native-arch.
 //
 // ExternalDependencies is indexed by the dependency name and the value is the barney repo
 // this dependency needs to fetched from
@@ -64,6 +71,23 @@ type Generator struct {
 	ExternalDependencies map[string]string   `yaml:"external-dependencies"`
 }
 
+// BindMount spec
+// Source is the path on the host, relative paths are relative to the package
+// directory in the repo.
+// Target is the absolute path in the mock chroot.
+type BindMount struct {
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+}
+
+// TmpfsMount spec
+// Target is the absolute path in the mock chroot.
+// Size is the optional size of the tmpfs(Eg: 2g).
+type TmpfsMount struct {
+	Target string `yaml:"target"`
+	Size   string `yaml:"size"`
+}
+
 // Build spec
 // mock cfg is generated for each target depending on this
 //
@@ -71,6 +95,10 @@ type Generator struct {
 // to download required upstream dependencies. (Eg: el9, epel9)
 // Defined in config/dnfconfig.yaml.
 //
+// Distro selects the distro profile defined in config/dnfconfig.yaml (Eg: el9, fc40),
+// which determines how the mock chroot is setup. It defaults to el9.
+// All repo-bundles should belong to the selected distro.
+//
 // Dependencies helps eext determine, based on the target arch, which package dependencies are required.
 // Archs can be of type ['all', 'i686', 'x86_64', 'aarch64'].
 // The map specifies the list of package dependencies eext should build locally, based on the current build arch.
@@ -88,20 +116,169 @@ type Generator struct {
 //
 // Generator specifies commands for eext generator
 // Refer to Generator struct denifition above.
+//
+// The following customize the mock configuration, prefer these over Include:
+// Macros specifies extra rpm macros, macros set by eext can't be overridden.
+// ChrootSetupPkgs specifies packages to install in the chroot in addition to the defaults.
+// RpmbuildNetworking allows network access for rpmbuild in the chroot.
+// Environment specifies extra environment variables for the build.
+// BindMounts and Tmpfs specify mounts in the chroot.
+// Plugins enables/disables mock plugins, indexed by plugin name(Eg: ccache).
+//
+// Network restricts the network access of the build to allowlisted hosts and
+// URL prefixes, instead of the unrestricted access of EnableNetwork and
+// RpmbuildNetworking.
+// Refer to NetworkPolicy struct definition below.
 type Build struct {
-	Include       []string            `yaml:"include"`
-	RepoBundle    []RepoBundle        `yaml:"repo-bundle"`
-	Dependencies  map[string][]string `yaml:"dependencies"`
-	Generator     Generator           `yaml:"eextgen"`
-	EnableNetwork bool                `yaml:"enable-network"`
+	Include            []string            `yaml:"include"`
+	RepoBundle         []RepoBundle        `yaml:"repo-bundle"`
+	Dependencies       map[string][]string `yaml:"dependencies"`
+	Generator          Generator           `yaml:"eextgen"`
+	EnableNetwork      bool                `yaml:"enable-network"`
+	Distro             string              `yaml:"distro"`
+	Macros             map[string]string   `yaml:"macros"`
+	ChrootSetupPkgs    []string            `yaml:"chroot-setup-pkgs"`
+	RpmbuildNetworking bool                `yaml:"rpmbuild-networking"`
+	Environment        map[string]string   `yaml:"environment"`
+	BindMounts         []BindMount         `yaml:"bind-mounts"`
+	Tmpfs              []TmpfsMount        `yaml:"tmpfs"`
+	Plugins            map[string]bool     `yaml:"plugins"`
+	Network            *NetworkPolicy      `yaml:"network,omitempty"`
+}
+
+// NetworkPolicy spec
+// Allow lists what the build may fetch, each entry is either a host(Eg:
+// proxy.golang.org, or *.crates.io for its subdomains) or a URL prefix
+// (Eg: https://github.com/containerd/). eext runs the build with a recording
+// proxy in front of the network, which blocks the requests not allowed here.
+// Redirects are separate requests, their targets must be allowed too.
+type NetworkPolicy struct {
+	Allow []string `yaml:"allow"`
+}
+
+var allowedHostRegex = regexp.MustCompile(
+	`^(\*\.)?[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*$`)
+
+func (n *NetworkPolicy) check() error {
+	if len(n.Allow) == 0 {
+		return fmt.Errorf("no hosts or URL prefixes to allow")
+	}
+	for _, entry := range n.Allow {
+		if !strings.Contains(entry, "://") {
+			if !allowedHostRegex.MatchString(entry) {
+				return fmt.Errorf("bad host '%s' in allow", entry)
+			}
+			continue
+		}
+		prefix, err := url.Parse(entry)
+		if err != nil {
+			return fmt.Errorf("bad URL prefix '%s' in allow: %s", entry, err)
+		}
+		if prefix.Scheme != "http" && prefix.Scheme != "https" {
+			return fmt.Errorf("URL prefix '%s' in allow must be http or https", entry)
+		}
+		if prefix.Hostname() == "" || prefix.User != nil ||
+			prefix.RawQuery != "" || prefix.Fragment != "" {
+			return fmt.Errorf("URL prefix '%s' in allow must only have a host and a path", entry)
+		}
+	}
+	return nil
+}
+
+// defaultPort returns the port of the URL, or the default port of its scheme.
+func defaultPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// matchAllowedHost checks if host matches the host entry of the allowlist.
+func matchAllowedHost(entry string, host string) bool {
+	if wildcardDomain := strings.TrimPrefix(entry, "*"); wildcardDomain != entry {
+		return strings.HasSuffix(strings.ToLower(host), strings.ToLower(wildcardDomain))
+	}
+	return strings.EqualFold(entry, host)
+}
+
+// hasDotSegments checks if the path of u has . or .. segments, which the
+// server would resolve, once percent-decoded and with '\' as a separator too.
+func hasDotSegments(u *url.URL) bool {
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool {
+		return r == '/' || r == '\\'
+	})
+	for _, segment := range segments {
+		if segment == "." || segment == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsURL checks if the build may fetch u.
+// URL prefixes only match at path boundaries, unless they end with '/'.
+// URLs with dot segments in the path don't match URL prefixes, as they
+// could resolve outside of the prefix.
+func (n *NetworkPolicy) AllowsURL(u *url.URL) bool {
+	dotSegments := hasDotSegments(u)
+	for _, entry := range n.Allow {
+		if !strings.Contains(entry, "://") {
+			if matchAllowedHost(entry, u.Hostname()) {
+				return true
+			}
+			continue
+		}
+		prefix, err := url.Parse(entry)
+		if err != nil || dotSegments || prefix.Scheme != u.Scheme ||
+			!strings.EqualFold(prefix.Hostname(), u.Hostname()) ||
+			defaultPort(prefix) != defaultPort(u) {
+			continue
+		}
+		prefixPath := prefix.EscapedPath()
+		urlPath := u.EscapedPath()
+		if prefixPath == "" || strings.HasSuffix(prefixPath, "/") {
+			if strings.HasPrefix(urlPath, prefixPath) {
+				return true
+			}
+		} else if urlPath == prefixPath || strings.HasPrefix(urlPath, prefixPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsHTTPSHost checks if the build may fetch anything from https://host:port,
+// the proxy checks the URLs in the connection with AllowsURL.
+func (n *NetworkPolicy) AllowsHTTPSHost(host string, port string) bool {
+	for _, entry := range n.Allow {
+		if !strings.Contains(entry, "://") {
+			if matchAllowedHost(entry, host) {
+				return true
+			}
+			continue
+		}
+		prefix, err := url.Parse(entry)
+		if err == nil && prefix.Scheme == "https" &&
+			strings.EqualFold(prefix.Hostname(), host) && defaultPort(prefix) == port {
+			return true
+		}
+	}
+	return false
 }
 
 // DetachedSignature spec
 // Specify either full URL of detached signature or how to derive it from source URL
+// For git-upstream packages, AllowedSigners can be specified instead of PubKey
+// to verify SSH signed revisions. It is an ssh allowed_signers file under
+// the trusted signers dir in PkiPath, listing the trusted keys.
 type DetachedSignature struct {
 	FullURL        string `yaml:"full-url"`
 	PubKey         string `yaml:"public-key"`
 	OnUncompressed bool   `yaml:"on-uncompressed"`
+	AllowedSigners string `yaml:"allowed-signers,omitempty"`
 }
 
 // Signature spec
@@ -118,25 +295,173 @@ type SourceBundle struct {
 	SrcRepoParamsOverride srcconfig.SrcRepoParamsOverride `yaml:"override"`
 }
 
+// ChecksumFile spec
+// Checksum file(Eg: SHA256SUMS) published by upstream, listing the expected
+// hashes of the upstream sources. The checksum file is verified with
+// its detached signature, unless the signature check is skipped.
+// Algorithm is one of sha256/sha512/blake2b. If not specified,
+// it is derived from the checksum file name.
+type ChecksumFile struct {
+	FullURL   string    `yaml:"full-url"`
+	Algorithm string    `yaml:"algorithm"`
+	Signature Signature `yaml:"signature"`
+}
+
+// GitBundle spec
+// Git repo and revision of a git-upstream source, from which a tarball is generated.
+// Archive is the file name of the tarball, Source<N>.tar.gz for the Nth source
+// by default. Prefix is the directory the files are put under in the tarball,
+// <NAME>-<VERSION> from the spec file by default.
+// Submodules includes the submodules, recursively, at the commits pinned
+// in the revision.
 type GitBundle struct {
-	Url      string `yaml:"url"`
-	Revision string `yaml:"revision"`
+	Url        string `yaml:"url"`
+	Revision   string `yaml:"revision"`
+	Archive    string `yaml:"archive,omitempty"`
+	Prefix     string `yaml:"prefix,omitempty"`
+	Submodules bool   `yaml:"submodules,omitempty"`
+}
+
+// Archive formats supported for tarballs generated by eext,
+// from git sources or vendored dependencies
+var generatedArchiveSuffixes = []string{".tar.gz", ".tgz", ".tar"}
+
+// GetArchive returns the file name of the tarball generated from the git
+// source, which is the index-th upstream source of the package.
+func (g *GitBundle) GetArchive(index int) string {
+	if g.Archive != "" {
+		return g.Archive
+	}
+	return fmt.Sprintf("Source%d.tar.gz", index)
+}
+
+// checkGeneratedArchive checks the file name of a tarball generated by eext
+func checkGeneratedArchive(archive string) error {
+	if strings.Contains(archive, "/") || archive == "." || archive == ".." {
+		return fmt.Errorf("archive '%s' must be a file name", archive)
+	}
+	for _, suffix := range generatedArchiveSuffixes {
+		if strings.HasSuffix(archive, suffix) && archive != suffix {
+			return nil
+		}
+	}
+	return fmt.Errorf("archive '%s' must end with one of %v",
+		archive, generatedArchiveSuffixes)
+}
+
+func (g *GitBundle) check() error {
+	if g.Archive != "" {
+		if err := checkGeneratedArchive(g.Archive); err != nil {
+			return err
+		}
+	}
+	if g.Prefix != "" {
+		cleanPrefix := path.Clean(g.Prefix)
+		if path.IsAbs(cleanPrefix) || cleanPrefix == "." || cleanPrefix == ".." ||
+			strings.HasPrefix(cleanPrefix, "../") {
+			return fmt.Errorf("prefix '%s' must be a relative path in the archive", g.Prefix)
+		}
+	}
+	return nil
 }
 
 // UpstreamSrc spec
 // Lists each source bundle(tarball/srpm) and
 // detached signature file for tarball.
+// Sha256, Sha512 and Blake2b specify expected hashes of the source,
+// which for git sources is the reproducible tarball generated from the revision.
+// ChecksumFile specifies a checksum file to look up the expected hash in.
 type UpstreamSrc struct {
 	SourceBundle SourceBundle `yaml:"source-bundle"`
 	FullURL      string       `yaml:"full-url"`
 	GitBundle    GitBundle    `yaml:"git"`
 	Signature    Signature    `yaml:"signature"`
 	Sha256       string       `yaml:"sha256"`
+	Sha512       string       `yaml:"sha512"`
+	Blake2b      string       `yaml:"blake2b"`
+	ChecksumFile ChecksumFile `yaml:"checksum-file"`
+}
+
+// Vendor spec
+// Vendors the dependencies of an upstream source with its language package
+// manager into an extra source tarball, so that the package builds without
+// network access.
+// Tool is one of go(go mod vendor), cargo(cargo vendor) or
+// pip(pip download, of the wheels of the pinned requirements in
+// requirements.txt, sdists aren't supported).
+// Source is the index of the upstream source in upstream-sources.
+// Dir is the directory with go.mod, Cargo.toml or requirements.txt in the
+// upstream source tree, the top dir by default.
+// Archive is the file name of the vendored tarball, which has Dir/vendor.
+// The spec file lists it as a source and unpacks it in the source tree,
+// Eg: with %setup -a.
+// CheckedIn specifies that the vendored tarball is checked in to the
+// package's sources dir in the repo, it's only generated if it's missing there.
+// Reproducible specifies that the vendored tarball is generated on each
+// create-srpm and must match Sha256.
+type Vendor struct {
+	Tool         string `yaml:"tool"`
+	Source       int    `yaml:"source"`
+	Dir          string `yaml:"dir"`
+	Archive      string `yaml:"archive"`
+	CheckedIn    bool   `yaml:"checked-in"`
+	Reproducible bool   `yaml:"reproducible"`
+	Sha256       string `yaml:"sha256"`
+}
+
+// VendorTools are the supported tools to vendor dependencies with
+var VendorTools = []string{"go", "cargo", "pip"}
+
+func (v *Vendor) check(numSources int) error {
+	if !slices.Contains(VendorTools, v.Tool) {
+		return fmt.Errorf("unsupported tool '%s', must be one of %v", v.Tool, VendorTools)
+	}
+	if v.Source < 0 || v.Source >= numSources {
+		return fmt.Errorf("no upstream source %d", v.Source)
+	}
+	if v.Dir != "" {
+		cleanDir := path.Clean(v.Dir)
+		if path.IsAbs(cleanDir) || cleanDir == ".." || strings.HasPrefix(cleanDir, "../") {
+			return fmt.Errorf("dir '%s' must be a relative path in the source tree", v.Dir)
+		}
+	}
+	if v.Archive == "" {
+		return fmt.Errorf("archive not specified")
+	}
+	if err := checkGeneratedArchive(v.Archive); err != nil {
+		return err
+	}
+	if v.CheckedIn && v.Reproducible {
+		return fmt.Errorf("specify either checked-in or reproducible")
+	}
+	if v.Reproducible && v.Sha256 == "" {
+		return fmt.Errorf("sha256 of reproducible archive %s not specified", v.Archive)
+	}
+	return nil
+}
+
+// Variant spec
+// A build variant of a package, specified in the package's build matrix.
+// Name identifies the variant and is used to keep the variant results separate.
+// Distro and RepoBundle replace the ones in the package's Build spec if specified.
+// Macros are merged with the ones in the package's Build spec.
+// Arches restricts the target archs the variant is built for, all archs by default.
+// With and Without specify rpmbuild --with/--without conditionals.
+type Variant struct {
+	Name       string            `yaml:"name"`
+	Distro     string            `yaml:"distro"`
+	RepoBundle []RepoBundle      `yaml:"repo-bundle"`
+	Macros     map[string]string `yaml:"macros"`
+	Arches     []string          `yaml:"arches"`
+	With       []string          `yaml:"with"`
+	Without    []string          `yaml:"without"`
 }
 
 // Package spec
 // In the general case, there will only be one package.
 // But we can have a bundle repo with multiple pakcages too.
+// Matrix expands the package into several build variants.
+// Vendor lists the dependencies of upstream sources to vendor.
 type Package struct {
 	Name            string        `yaml:"name"`
 	Subdir          bool          `yaml:"subdir"`
@@ -144,6 +469,41 @@ type Package struct {
 	UpstreamSrc     []UpstreamSrc `yaml:"upstream-sources"`
 	Type            string        `yaml:"type"`
 	Build           Build         `yaml:"build"`
+	Matrix          []Variant     `yaml:"matrix"`
+	Vendor          []Vendor      `yaml:"vendor"`
+}
+
+// GetVariant returns the variant named variantName in the package's
+// build matrix, or nil if there is no such variant.
+func (p *Package) GetVariant(variantName string) *Variant {
+	for i := range p.Matrix {
+		if p.Matrix[i].Name == variantName {
+			return &p.Matrix[i]
+		}
+	}
+	return nil
+}
+
+// GetVariantBuild returns the package's Build spec with the
+// overrides of the variant applied.
+func (p *Package) GetVariantBuild(variant *Variant) *Build {
+	build := p.Build
+	if variant.Distro != "" {
+		build.Distro = variant.Distro
+	}
+	if variant.RepoBundle != nil {
+		build.RepoBundle = variant.RepoBundle
+	}
+	if variant.Macros != nil {
+		build.Macros = make(map[string]string)
+		for macro, value := range p.Build.Macros {
+			build.Macros[macro] = value
+		}
+		for macro, value := range variant.Macros {
+			build.Macros[macro] = value
+		}
+	}
+	return &build
 }
 
 // Manifest spec
@@ -153,6 +513,65 @@ type Manifest struct {
 	Package []Package `yaml:"package"`
 }
 
+func (c ChecksumFile) check() error {
+	if c == (ChecksumFile{}) {
+		return nil
+	}
+	if c.FullURL == "" {
+		return fmt.Errorf("full-url not specified")
+	}
+	if c.Algorithm != "" && !slices.Contains(util.HashAlgorithms, c.Algorithm) {
+		return fmt.Errorf("unsupported algorithm %s, must be one of %v",
+			c.Algorithm, util.HashAlgorithms)
+	}
+	detachedSig := c.Signature.DetachedSignature
+	if detachedSig.OnUncompressed || detachedSig.AllowedSigners != "" {
+		return fmt.Errorf("on-uncompressed/allowed-signers aren't applicable")
+	}
+	if !c.Signature.SkipCheck && (detachedSig.FullURL == "" || detachedSig.PubKey == "") {
+		return fmt.Errorf("provide detached-sig full-url and public-key, or skip signature check")
+	}
+	return nil
+}
+
+var variantNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.+-]+$`)
+
+func (p *Package) checkMatrix() error {
+	allowedArchs := []string{"i686", "x86_64", "aarch64"}
+	variantNames := make(map[string]bool)
+	for _, variant := range p.Matrix {
+		if !variantNameRegex.MatchString(variant.Name) {
+			return fmt.Errorf("Bad variant name '%s' in matrix of package %s",
+				variant.Name, p.Name)
+		}
+		if variantNames[variant.Name] {
+			return fmt.Errorf("Duplicate variant %s in matrix of package %s",
+				variant.Name, p.Name)
+		}
+		variantNames[variant.Name] = true
+
+		for _, arch := range variant.Arches {
+			if !slices.Contains(allowedArchs, arch) {
+				return fmt.Errorf("'%v' is not a valid/supported arch for variant %s of package %s, use one of %v",
+					arch, variant.Name, p.Name, allowedArchs)
+			}
+		}
+		for _, cond := range variant.With {
+			if slices.Contains(variant.Without, cond) {
+				return fmt.Errorf("Conditional %s is both in with and without for variant %s of package %s",
+					cond, variant.Name, p.Name)
+			}
+		}
+		for _, cond := range append(slices.Clone(variant.With), variant.Without...) {
+			if !variantNameRegex.MatchString(cond) {
+				return fmt.Errorf("Bad conditional '%s' for variant %s of package %s",
+					cond, variant.Name, p.Name)
+			}
+		}
+	}
+	return nil
+}
+
 func (m Manifest) sanityCheck() error {
 	allowedPkgTypes := []string{"srpm", "unmodified-srpm", "tarball", "standalone", "git-upstream"}
 
@@ -190,8 +609,37 @@ func (m Manifest) sanityCheck() error {
 			}
 		}
 
-		for _, upStreamSrc := range pkgSpec.UpstreamSrc {
+		if err := pkgSpec.checkMatrix(); err != nil {
+			return err
+		}
+
+		if pkgSpec.Build.Network != nil {
+			if pkgSpec.Build.EnableNetwork {
+				return fmt.Errorf("Conflicting network access for Build in package %s, "+
+					"provide either enable-network or network", pkgSpec.Name)
+			}
+			if pkgSpec.Build.RpmbuildNetworking {
+				return fmt.Errorf("Conflicting network access for Build in package %s, "+
+					"provide either rpmbuild-networking or network", pkgSpec.Name)
+			}
+			if err := pkgSpec.Build.Network.check(); err != nil {
+				return fmt.Errorf("Bad network for package %s: %s", pkgSpec.Name, err)
+			}
+		}
+
+		generatedArchives := make(map[string]bool)
+		for i, upStreamSrc := range pkgSpec.UpstreamSrc {
 			if pkgSpec.Type == "git-upstream" {
+				if err := upStreamSrc.GitBundle.check(); err != nil {
+					return fmt.Errorf("Bad git source for package %s: %s", pkgSpec.Name, err)
+				}
+				archive := upStreamSrc.GitBundle.GetArchive(i)
+				if generatedArchives[archive] {
+					return fmt.Errorf("Duplicate archive %s for git sources of package %s",
+						archive, pkgSpec.Name)
+				}
+				generatedArchives[archive] = true
+
 				specifiedUrl := (upStreamSrc.GitBundle.Url != "")
 				specifiedRevision := (upStreamSrc.GitBundle.Revision != "")
 				if !specifiedUrl {
@@ -205,9 +653,15 @@ func (m Manifest) sanityCheck() error {
 				if specifiedSignature {
 					skipSigCheck := (upStreamSrc.Signature.SkipCheck)
 					specifiedPubKey := (upStreamSrc.Signature.DetachedSignature.PubKey != "")
-					if !skipSigCheck && !specifiedPubKey {
+					specifiedAllowedSigners := (upStreamSrc.Signature.DetachedSignature.AllowedSigners != "")
+					if specifiedPubKey && specifiedAllowedSigners {
 						return fmt.Errorf(
-							"please provide the public key to verify git repo for package %s, or skip signature check",
+							"Conflicting signature keys for package %s, provide either public-key or allowed-signers",
+							pkgSpec.Name)
+					}
+					if !skipSigCheck && !specifiedPubKey && !specifiedAllowedSigners {
+						return fmt.Errorf(
+							"please provide the public key or allowed-signers to verify git repo for package %s, or skip signature check",
 							pkgSpec.Name)
 					}
 				} else {
@@ -229,12 +683,37 @@ func (m Manifest) sanityCheck() error {
 						pkgSpec.Name)
 				}
 
+				if upStreamSrc.Signature.DetachedSignature.AllowedSigners != "" {
+					return fmt.Errorf("allowed-signers is only supported for git-upstream packages, package %s",
+						pkgSpec.Name)
+				}
+
 				specifiedFullSigURL := upStreamSrc.Signature.DetachedSignature.FullURL != ""
 				if specifiedFullSigURL && specifiedSrcBundle {
 					return fmt.Errorf("Conflicting signatures for Build in package %s, provide full-url or source-bundle",
 						pkgSpec.Name)
 				}
+
+				if err := upStreamSrc.ChecksumFile.check(); err != nil {
+					return fmt.Errorf("Bad checksum-file for package %s: %s",
+						pkgSpec.Name, err)
+				}
+			}
+		}
+
+		if pkgSpec.Vendor != nil && pkgSpec.Type != "tarball" && pkgSpec.Type != "git-upstream" {
+			return fmt.Errorf("vendor is only supported for tarball and git-upstream packages, package %s",
+				pkgSpec.Name)
+		}
+		for _, vendor := range pkgSpec.Vendor {
+			if err := vendor.check(len(pkgSpec.UpstreamSrc)); err != nil {
+				return fmt.Errorf("Bad vendor for package %s: %s", pkgSpec.Name, err)
+			}
+			if generatedArchives[vendor.Archive] {
+				return fmt.Errorf("Duplicate archive %s for vendor of package %s",
+					vendor.Archive, pkgSpec.Name)
 			}
+			generatedArchives[vendor.Archive] = true
 		}
 	}
 	return nil
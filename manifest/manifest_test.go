@@ -4,6 +4,7 @@
 package manifest
 
 import (
+	"net/url"
 	"os"
 	"testing"
 
@@ -31,7 +32,8 @@ func TestManifest(t *testing.T) {
 	viper.Set("SrcDir", dir)
 	defer viper.Reset()
 
-	testFiles := []string{"sampleManifest1.yaml", "sampleManifest4.yaml"}
+	testFiles := []string{"sampleManifest1.yaml", "sampleManifest4.yaml", "sampleManifest6.yaml",
+		"sampleManifest10.yaml", "sampleManifest12.yaml", "sampleManifest14.yaml"}
 	for _, testFile := range testFiles {
 		t.Logf("Copy sample manifest %s to test directory", testFile)
 		testutil.SetupManifest(t, dir, "pkg1", testFile)
@@ -75,6 +77,41 @@ func TestManifestNegative(t *testing.T) {
 			ManifestFile: "sampleManifest5.yaml",
 			ExpectedErr:  "signature fields not specified for package libpcap, provide public key or skip signature check",
 		},
+		"testDuplicateVariant": {
+			TestPkg:      "pkg7",
+			ManifestFile: "sampleManifest7.yaml",
+			ExpectedErr:  "Duplicate variant debug in matrix of package libpcap",
+		},
+		"testChecksumFileWithoutSignature": {
+			TestPkg:      "pkg8",
+			ManifestFile: "sampleManifest8.yaml",
+			ExpectedErr:  "Bad checksum-file for package mrtparse: provide detached-sig full-url and public-key",
+		},
+		"testGitUpstreamConflictingSignatureKeys": {
+			TestPkg:      "pkg9",
+			ManifestFile: "sampleManifest9.yaml",
+			ExpectedErr:  "Conflicting signature keys for package libpcap, provide either public-key or allowed-signers",
+		},
+		"testGitUpstreamDuplicateArchive": {
+			TestPkg:      "pkg11",
+			ManifestFile: "sampleManifest11.yaml",
+			ExpectedErr:  "Duplicate archive Source0.tar.gz for git sources of package frr",
+		},
+		"testReproducibleVendorWithoutSha256": {
+			TestPkg:      "pkg13",
+			ManifestFile: "sampleManifest13.yaml",
+			ExpectedErr:  "Bad vendor for package containerd: sha256 of reproducible archive containerd-vendor.tar.gz not specified",
+		},
+		"testEnableNetworkWithNetworkPolicy": {
+			TestPkg:      "pkg15",
+			ManifestFile: "sampleManifest15.yaml",
+			ExpectedErr:  "Conflicting network access for Build in package containerd, provide either enable-network or network",
+		},
+		"testRpmbuildNetworkingWithNetworkPolicy": {
+			TestPkg:      "pkg16",
+			ManifestFile: "sampleManifest16.yaml",
+			ExpectedErr:  "Conflicting network access for Build in package containerd, provide either rpmbuild-networking or network",
+		},
 	}
 	for testName, variant := range testCases {
 		t.Logf("%s: Copy sample manifest to test directory", testName)
@@ -86,3 +123,156 @@ func TestManifestNegative(t *testing.T) {
 		t.Logf("%s: Load test passed", testName)
 	}
 }
+
+func TestManifestMatrix(t *testing.T) {
+	dir := t.TempDir()
+	viper.Set("SrcDir", dir)
+	defer viper.Reset()
+
+	testutil.SetupManifest(t, dir, "pkg6", "sampleManifest6.yaml")
+	manifest, err := LoadManifest("pkg6")
+	require.NoError(t, err)
+
+	pkgSpec := &manifest.Package[0]
+	require.Nil(t, pkgSpec.GetVariant("release"))
+
+	debug := pkgSpec.GetVariant("debug")
+	require.NotNil(t, debug)
+	debugBuild := pkgSpec.GetVariantBuild(debug)
+	require.Equal(t, "", debugBuild.Distro)
+	require.Equal(t, pkgSpec.Build.RepoBundle, debugBuild.RepoBundle)
+	require.Equal(t, map[string]string{"foo": "1", "bar": "3"}, debugBuild.Macros)
+	require.Equal(t, []string{"debug"}, debug.With)
+	// The package's build spec is untouched
+	require.Equal(t, map[string]string{"foo": "1", "bar": "2"}, pkgSpec.Build.Macros)
+
+	fc40 := pkgSpec.GetVariant("fc40")
+	require.NotNil(t, fc40)
+	fc40Build := pkgSpec.GetVariantBuild(fc40)
+	require.Equal(t, "fc40", fc40Build.Distro)
+	require.Equal(t, []RepoBundle{{Name: "fc40-snapshot"}}, fc40Build.RepoBundle)
+	require.Equal(t, pkgSpec.Build.Macros, fc40Build.Macros)
+	require.Equal(t, []string{"x86_64"}, fc40.Arches)
+	require.Equal(t, []string{"docs"}, fc40.Without)
+}
+
+func TestGitBundleArchive(t *testing.T) {
+	dir := t.TempDir()
+	viper.Set("SrcDir", dir)
+	defer viper.Reset()
+
+	testutil.SetupManifest(t, dir, "pkg10", "sampleManifest10.yaml")
+	manifest, err := LoadManifest("pkg10")
+	require.NoError(t, err)
+
+	upstreamSrcs := manifest.Package[0].UpstreamSrc
+	require.Equal(t, "Source0.tar.gz", upstreamSrcs[0].GitBundle.GetArchive(0))
+	require.True(t, upstreamSrcs[0].GitBundle.Submodules)
+	require.Equal(t, "libyang-2.1.128.tar.gz", upstreamSrcs[1].GitBundle.GetArchive(1))
+	require.Equal(t, "libyang-2.1.128", upstreamSrcs[1].GitBundle.Prefix)
+
+	for _, bad := range []GitBundle{
+		{Archive: "../Source0.tar.gz"},
+		{Archive: "Source0.zip"},
+		{Archive: ".tar.gz"},
+		{Prefix: "/foo"},
+		{Prefix: "foo/../.."},
+	} {
+		require.Error(t, bad.check(), "%+v", bad)
+	}
+	require.NoError(t, (&GitBundle{Archive: "foo.tgz", Prefix: "foo-1.0/src"}).check())
+}
+
+func TestVendor(t *testing.T) {
+	dir := t.TempDir()
+	viper.Set("SrcDir", dir)
+	defer viper.Reset()
+
+	testutil.SetupManifest(t, dir, "pkg12", "sampleManifest12.yaml")
+	manifest, err := LoadManifest("pkg12")
+	require.NoError(t, err)
+	vendor := manifest.Package[0].Vendor
+	require.Len(t, vendor, 2)
+	require.Equal(t, 0, vendor[0].Source)
+	require.Equal(t, "", vendor[0].Dir)
+	require.True(t, vendor[0].Reproducible)
+	require.Equal(t, 1, vendor[1].Source)
+	require.True(t, vendor[1].CheckedIn)
+
+	for _, bad := range []Vendor{
+		{Tool: "npm", Archive: "vendor.tar.gz"},
+		{Tool: "go", Source: 2, Archive: "vendor.tar.gz"},
+		{Tool: "go", Dir: "../foo", Archive: "vendor.tar.gz"},
+		{Tool: "go"},
+		{Tool: "go", Archive: "vendor.zip"},
+		{Tool: "go", Archive: "vendor.tar.gz", CheckedIn: true, Reproducible: true, Sha256: "abc"},
+	} {
+		require.Error(t, bad.check(2), "%+v", bad)
+	}
+	require.NoError(t, (&Vendor{Tool: "pip", Source: 1, Dir: "python",
+		Archive: "vendor.tar"}).check(2))
+}
+
+func TestNetworkPolicy(t *testing.T) {
+	dir := t.TempDir()
+	viper.Set("SrcDir", dir)
+	defer viper.Reset()
+
+	testutil.SetupManifest(t, dir, "pkg14", "sampleManifest14.yaml")
+	manifest, err := LoadManifest("pkg14")
+	require.NoError(t, err)
+	policy := manifest.Package[0].Build.Network
+	require.NotNil(t, policy)
+
+	for rawURL, allowed := range map[string]bool{
+		"https://proxy.golang.org/github.com/foo/@v/list":      true,
+		"http://Proxy.Golang.org:8080/foo":                     true,
+		"https://static.crates.io/crates/foo/foo-1.0.0.crate":  true,
+		"https://crates.io/api/v1/crates":                      false,
+		"https://github.com/containerd/containerd/archive/foo": true,
+		"https://github.com/containerd":                        false,
+		"https://github.com/containerdx/foo":                   false,
+		"http://github.com/containerd/containerd":              false,
+		"https://github.com:8443/containerd/containerd":        false,
+		"https://objects.githubusercontent.com/foo":            false,
+	} {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		require.Equal(t, allowed, policy.AllowsURL(u), rawURL)
+	}
+	require.True(t, policy.AllowsHTTPSHost("github.com", "443"))
+	require.False(t, policy.AllowsHTTPSHost("github.com", "8443"))
+	require.True(t, policy.AllowsHTTPSHost("index.crates.io", "443"))
+	require.False(t, policy.AllowsHTTPSHost("pypi.org", "443"))
+
+	prefixPolicy := NetworkPolicy{Allow: []string{"https://example.org/pkgs"}}
+	for rawURL, allowed := range map[string]bool{
+		"https://example.org/pkgs":       true,
+		"https://example.org/pkgs/a.tgz": true,
+		"https://example.org/pkgs2":      false,
+		"https://example.org:443/pkgs/b": true,
+		// Dot segments could resolve outside of the prefix
+		"https://example.org/pkgs/../secret":       false,
+		"https://example.org/pkgs/%2e%2e/secret":   false,
+		"https://example.org/pkgs/%2E%2E%2fsecret": false,
+		"https://example.org/pkgs/..%5csecret":     false,
+		"https://example.org/pkgs/./a.tgz":         false,
+		"https://example.org/pkgs/..a.tgz":         true,
+	} {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		require.Equal(t, allowed, prefixPolicy.AllowsURL(u), rawURL)
+	}
+
+	for _, bad := range []NetworkPolicy{
+		{},
+		{Allow: []string{"foo bar"}},
+		{Allow: []string{"foo.*.org"}},
+		{Allow: []string{"ftp://example.org/"}},
+		{Allow: []string{"https:///foo"}},
+		{Allow: []string{"https://user@example.org/"}},
+		{Allow: []string{"https://example.org/?foo=1"}},
+	} {
+		require.Error(t, bad.check(), "%+v", bad)
+	}
+}
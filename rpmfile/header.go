@@ -0,0 +1,375 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package rpmfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Tag identifies an entry in a header
+type Tag int32
+
+// Main header tags
+const (
+	TagName            Tag = 1000
+	TagVersion         Tag = 1001
+	TagRelease         Tag = 1002
+	TagEpoch           Tag = 1003
+	TagSummary         Tag = 1004
+	TagOS              Tag = 1021
+	TagArch            Tag = 1022
+	TagOldFileNames    Tag = 1027
+	TagFileSizes       Tag = 1028
+	TagFileModes       Tag = 1030
+	TagFileDigests     Tag = 1035
+	TagFileFlags       Tag = 1037
+	TagSourceRPM       Tag = 1044
+	TagProvideName     Tag = 1047
+	TagRequireFlags    Tag = 1048
+	TagRequireName     Tag = 1049
+	TagRequireVersion  Tag = 1050
+	TagSourcePackage   Tag = 1106
+	TagProvideFlags    Tag = 1112
+	TagProvideVersion  Tag = 1113
+	TagDirIndexes      Tag = 1116
+	TagBaseNames       Tag = 1117
+	TagDirNames        Tag = 1118
+	TagPayloadFormat   Tag = 1124
+	TagPayloadCompress Tag = 1125
+	TagFileColors      Tag = 1140
+	TagLongFileSizes   Tag = 5008
+	TagFileDigestAlgo  Tag = 5011
+)
+
+// Signature header tags
+const (
+	SigTagDSA     Tag = 267
+	SigTagRSA     Tag = 268
+	SigTagSHA256  Tag = 273
+	SigTagOpenPGP Tag = 278
+	SigTagSize    Tag = 1000
+	SigTagPGP     Tag = 1002
+	SigTagMD5     Tag = 1004
+	SigTagGPG     Tag = 1005
+)
+
+// Type is the data type of a header entry
+type Type uint32
+
+// Header entry data types
+const (
+	TypeNull        Type = 0
+	TypeChar        Type = 1
+	TypeInt8        Type = 2
+	TypeInt16       Type = 3
+	TypeInt32       Type = 4
+	TypeInt64       Type = 5
+	TypeString      Type = 6
+	TypeBin         Type = 7
+	TypeStringArray Type = 8
+	TypeI18NString  Type = 9
+)
+
+// typeSize is the size of each element of the fixed size types,
+// which is also their alignment in the data store.
+var typeSize = map[Type]int{
+	TypeChar:  1,
+	TypeInt8:  1,
+	TypeInt16: 2,
+	TypeInt32: 4,
+	TypeInt64: 8,
+	TypeBin:   1,
+}
+
+var headerMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+
+// Limits enforced by rpm on the number of entries and the data size
+const (
+	maxHeaderEntries  = 0xffff
+	maxHeaderDataSize = 256 * 1024 * 1024
+)
+
+func appendUint16(buf []byte, value uint16) []byte {
+	return append(buf, byte(value>>8), byte(value))
+}
+
+func appendUint32(buf []byte, value uint32) []byte {
+	return appendUint16(appendUint16(buf, uint16(value>>16)), uint16(value))
+}
+
+func appendUint64(buf []byte, value uint64) []byte {
+	return appendUint32(appendUint32(buf, uint32(value>>32)), uint32(value))
+}
+
+type headerEntry struct {
+	tag   Tag
+	typ   Type
+	count uint32
+	data  []byte
+}
+
+// Header is an rpm header, a set of tagged entries.
+// Both the signature header and the main header use this format.
+type Header struct {
+	entries map[Tag]headerEntry
+}
+
+// NewHeader returns an empty header
+func NewHeader() *Header {
+	return &Header{entries: make(map[Tag]headerEntry)}
+}
+
+// entryDataLen returns the length of the data of an entry
+// starting at store[offset:].
+func entryDataLen(store []byte, offset int, typ Type, count uint32) (int, error) {
+	switch typ {
+	case TypeNull:
+		return 0, nil
+	case TypeString, TypeStringArray, TypeI18NString:
+		if typ == TypeString && count != 1 {
+			return 0, fmt.Errorf("string entry with count %d", count)
+		}
+		end := offset
+		for i := uint32(0); i < count; i++ {
+			nul := bytes.IndexByte(store[end:], 0)
+			if nul < 0 {
+				return 0, fmt.Errorf("unterminated string")
+			}
+			end += nul + 1
+		}
+		return end - offset, nil
+	}
+	size, ok := typeSize[typ]
+	if !ok {
+		return 0, fmt.Errorf("unknown type %d", typ)
+	}
+	length := uint64(size) * uint64(count)
+	if length > uint64(len(store)-offset) {
+		return 0, fmt.Errorf("data of %d bytes beyond end of store", length)
+	}
+	return int(length), nil
+}
+
+// readHeader reads a header from r
+func readHeader(r io.Reader) (*Header, int, error) {
+	var intro [16]byte
+	if _, err := io.ReadFull(r, intro[:]); err != nil {
+		return nil, 0, fmt.Errorf("error reading header: %s", err)
+	}
+	if !bytes.Equal(intro[:4], headerMagic) {
+		return nil, 0, fmt.Errorf("bad header magic %x", intro[:4])
+	}
+	numEntries := binary.BigEndian.Uint32(intro[8:12])
+	dataSize := binary.BigEndian.Uint32(intro[12:16])
+	if numEntries > maxHeaderEntries || dataSize > maxHeaderDataSize {
+		return nil, 0, fmt.Errorf("header too large, %d entries, %d bytes of data",
+			numEntries, dataSize)
+	}
+
+	index := make([]byte, 16*numEntries)
+	if _, err := io.ReadFull(r, index); err != nil {
+		return nil, 0, fmt.Errorf("error reading header index: %s", err)
+	}
+	store := make([]byte, dataSize)
+	if _, err := io.ReadFull(r, store); err != nil {
+		return nil, 0, fmt.Errorf("error reading header data: %s", err)
+	}
+
+	hdr := NewHeader()
+	for i := 0; i < int(numEntries); i++ {
+		entryBytes := index[16*i : 16*(i+1)]
+		entry := headerEntry{
+			tag:   Tag(binary.BigEndian.Uint32(entryBytes[0:4])),
+			typ:   Type(binary.BigEndian.Uint32(entryBytes[4:8])),
+			count: binary.BigEndian.Uint32(entryBytes[12:16]),
+		}
+		offset := int(int32(binary.BigEndian.Uint32(entryBytes[8:12])))
+		if offset < 0 || offset > len(store) {
+			return nil, 0, fmt.Errorf("bad offset %d for tag %d", offset, entry.tag)
+		}
+		length, err := entryDataLen(store, offset, entry.typ, entry.count)
+		if err != nil {
+			return nil, 0, fmt.Errorf("bad entry for tag %d: %s", entry.tag, err)
+		}
+		entry.data = store[offset : offset+length]
+		hdr.entries[entry.tag] = entry
+	}
+	return hdr, 16 + len(index) + len(store), nil
+}
+
+// MarshalBinary encodes the header, with the entries sorted by tag.
+func (h *Header) MarshalBinary() ([]byte, error) {
+	tags := h.Tags()
+	index := make([]byte, 0, 16*len(tags))
+	var store []byte
+	for _, tag := range tags {
+		entry := h.entries[tag]
+		if align := typeSize[entry.typ]; align > 1 {
+			for len(store)%align != 0 {
+				store = append(store, 0)
+			}
+		}
+		index = appendUint32(index, uint32(entry.tag))
+		index = appendUint32(index, uint32(entry.typ))
+		index = appendUint32(index, uint32(len(store)))
+		index = appendUint32(index, entry.count)
+		store = append(store, entry.data...)
+	}
+	if len(tags) > maxHeaderEntries || len(store) > maxHeaderDataSize {
+		return nil, fmt.Errorf("header too large, %d entries, %d bytes of data",
+			len(tags), len(store))
+	}
+
+	buf := append([]byte{}, headerMagic...)
+	buf = append(buf, 0, 0, 0, 0)
+	buf = appendUint32(buf, uint32(len(tags)))
+	buf = appendUint32(buf, uint32(len(store)))
+	buf = append(buf, index...)
+	return append(buf, store...), nil
+}
+
+// Tags returns the tags of the entries in the header in ascending order
+func (h *Header) Tags() []Tag {
+	tags := make([]Tag, 0, len(h.entries))
+	for tag := range h.entries {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+	return tags
+}
+
+// Has checks if the header has an entry for the tag
+func (h *Header) Has(tag Tag) bool {
+	_, found := h.entries[tag]
+	return found
+}
+
+// String returns the value of a string entry. For an i18n string
+// entry, the first(untranslated) string is returned.
+func (h *Header) String(tag Tag) string {
+	strs := h.StringArray(tag)
+	if len(strs) == 0 {
+		return ""
+	}
+	return strs[0]
+}
+
+// StringArray returns the value of a string or string array entry
+func (h *Header) StringArray(tag Tag) []string {
+	entry, found := h.entries[tag]
+	if !found {
+		return nil
+	}
+	switch entry.typ {
+	case TypeString, TypeStringArray, TypeI18NString:
+	default:
+		return nil
+	}
+	strs := make([]string, 0, entry.count)
+	data := entry.data
+	for i := uint32(0); i < entry.count; i++ {
+		nul := bytes.IndexByte(data, 0)
+		strs = append(strs, string(data[:nul]))
+		data = data[nul+1:]
+	}
+	return strs
+}
+
+// Uint64Array returns the value of an integer entry of any size
+func (h *Header) Uint64Array(tag Tag) []uint64 {
+	entry, found := h.entries[tag]
+	if !found {
+		return nil
+	}
+	values := make([]uint64, 0, entry.count)
+	for i := 0; i < int(entry.count); i++ {
+		switch entry.typ {
+		case TypeChar, TypeInt8:
+			values = append(values, uint64(entry.data[i]))
+		case TypeInt16:
+			values = append(values, uint64(binary.BigEndian.Uint16(entry.data[2*i:])))
+		case TypeInt32:
+			values = append(values, uint64(binary.BigEndian.Uint32(entry.data[4*i:])))
+		case TypeInt64:
+			values = append(values, uint64(binary.BigEndian.Uint64(entry.data[8*i:])))
+		default:
+			return nil
+		}
+	}
+	return values
+}
+
+// Uint64 returns the first value of an integer entry
+func (h *Header) Uint64(tag Tag) (uint64, bool) {
+	values := h.Uint64Array(tag)
+	if len(values) == 0 {
+		return 0, false
+	}
+	return values[0], true
+}
+
+// Bin returns the value of a binary entry
+func (h *Header) Bin(tag Tag) []byte {
+	entry, found := h.entries[tag]
+	if !found || entry.typ != TypeBin {
+		return nil
+	}
+	return entry.data
+}
+
+// SetString sets a string entry
+func (h *Header) SetString(tag Tag, value string) {
+	h.entries[tag] = headerEntry{tag: tag, typ: TypeString, count: 1,
+		data: append([]byte(value), 0)}
+}
+
+// SetStringArray sets a string array entry
+func (h *Header) SetStringArray(tag Tag, values []string) {
+	var data []byte
+	for _, value := range values {
+		data = append(append(data, value...), 0)
+	}
+	h.entries[tag] = headerEntry{tag: tag, typ: TypeStringArray,
+		count: uint32(len(values)), data: data}
+}
+
+// SetInt16Array sets an int16 array entry
+func (h *Header) SetInt16Array(tag Tag, values []uint16) {
+	var data []byte
+	for _, value := range values {
+		data = appendUint16(data, value)
+	}
+	h.entries[tag] = headerEntry{tag: tag, typ: TypeInt16,
+		count: uint32(len(values)), data: data}
+}
+
+// SetInt32Array sets an int32 array entry
+func (h *Header) SetInt32Array(tag Tag, values []uint32) {
+	var data []byte
+	for _, value := range values {
+		data = appendUint32(data, value)
+	}
+	h.entries[tag] = headerEntry{tag: tag, typ: TypeInt32,
+		count: uint32(len(values)), data: data}
+}
+
+// SetInt64Array sets an int64 array entry
+func (h *Header) SetInt64Array(tag Tag, values []uint64) {
+	var data []byte
+	for _, value := range values {
+		data = appendUint64(data, value)
+	}
+	h.entries[tag] = headerEntry{tag: tag, typ: TypeInt64,
+		count: uint32(len(values)), data: data}
+}
+
+// SetBin sets a binary entry
+func (h *Header) SetBin(tag Tag, value []byte) {
+	h.entries[tag] = headerEntry{tag: tag, typ: TypeBin,
+		count: uint32(len(value)), data: append([]byte{}, value...)}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package rpmfile
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+
+	"code.arista.io/eos/tools/eext/decompress"
+)
+
+// File flags
+const (
+	FileFlagSpecFile = 1 << 5
+)
+
+// File digest algorithms, from the OpenPGP hash algorithm IDs
+var fileDigestAlgos = map[uint64]func() hash.Hash{
+	1:  md5.New,
+	2:  sha1.New,
+	8:  sha256.New,
+	9:  sha512.New384,
+	10: sha512.New,
+	11: sha256.New224,
+}
+
+// NewFileDigest returns a hash for computing file digests
+// with the package's file digest algorithm.
+func (pkg *Package) NewFileDigest() (hash.Hash, error) {
+	algo, found := pkg.Header.Uint64(TagFileDigestAlgo)
+	if !found {
+		// MD5 is used by packages without the tag
+		algo = 1
+	}
+	newHash, supported := fileDigestAlgos[algo]
+	if !supported {
+		return nil, fmt.Errorf("unsupported file digest algorithm %d", algo)
+	}
+	return newHash(), nil
+}
+
+// cpio "new ASCII" format, which rpm uses for payloads
+const (
+	cpioNewcMagic   = "070701"
+	cpioHeaderSize  = 110
+	cpioTrailerName = "TRAILER!!!"
+	// PATH_MAX
+	cpioMaxNameSize = 4096
+)
+
+// PayloadFile is the header of a file in the payload
+type PayloadFile struct {
+	Name string
+	Mode uint32
+	Size int64
+}
+
+// PayloadReader reads the files in a package's cpio payload,
+// like archive/tar.Reader.
+type PayloadReader struct {
+	decompressor io.ReadCloser
+	r            io.Reader
+	// Remaining data and padding of the current file
+	remaining int64
+	padding   int64
+}
+
+// NewPayloadReader returns a reader for the payload in r, which must be
+// positioned at the start of the payload, like after Read.
+func (pkg *Package) NewPayloadReader(r io.Reader) (*PayloadReader, error) {
+	if payloadFormat := pkg.Header.String(TagPayloadFormat); payloadFormat != "" &&
+		payloadFormat != "cpio" {
+		return nil, fmt.Errorf("unsupported payload format '%s'", payloadFormat)
+	}
+	compressor := pkg.Header.String(TagPayloadCompress)
+	if compressor == "" {
+		// Default compressor of packages without the tag
+		compressor = decompress.Gzip
+	}
+	decompressor, err := decompress.NewReader(r, compressor)
+	if err != nil {
+		return nil, fmt.Errorf("payload: %s", err)
+	}
+	return &PayloadReader{decompressor: decompressor, r: decompressor}, nil
+}
+
+func cpioPadding(size int64) int64 {
+	return (4 - size%4) % 4
+}
+
+// Next advances to the next file in the payload, and returns io.EOF
+// at the end of the payload.
+func (pr *PayloadReader) Next() (*PayloadFile, error) {
+	// Skip the rest of the current file
+	if _, err := io.CopyN(io.Discard, pr.r, pr.remaining+pr.padding); err != nil {
+		return nil, fmt.Errorf("payload: %s", err)
+	}
+	pr.remaining, pr.padding = 0, 0
+
+	var header [cpioHeaderSize]byte
+	if _, err := io.ReadFull(pr.r, header[:]); err != nil {
+		return nil, fmt.Errorf("payload: error reading cpio header: %s", err)
+	}
+	if magic := string(header[:6]); magic != cpioNewcMagic {
+		return nil, fmt.Errorf("payload: unsupported cpio magic '%s'", magic)
+	}
+	// 13 8 character hex fields follow the magic
+	field := func(i int) (uint64, error) {
+		start := 6 + 8*i
+		return strconv.ParseUint(string(header[start:start+8]), 16, 32)
+	}
+	mode, modeErr := field(1)
+	size, sizeErr := field(6)
+	nameSize, nameSizeErr := field(11)
+	if modeErr != nil || sizeErr != nil || nameSizeErr != nil ||
+		nameSize == 0 || nameSize > cpioMaxNameSize {
+		return nil, fmt.Errorf("payload: bad cpio header")
+	}
+
+	name := make([]byte, nameSize)
+	if _, err := io.ReadFull(pr.r, name); err != nil {
+		return nil, fmt.Errorf("payload: error reading cpio file name: %s", err)
+	}
+	if name[nameSize-1] != 0 {
+		return nil, fmt.Errorf("payload: unterminated cpio file name")
+	}
+	if _, err := io.CopyN(io.Discard, pr.r,
+		cpioPadding(cpioHeaderSize+int64(nameSize))); err != nil {
+		return nil, fmt.Errorf("payload: %s", err)
+	}
+
+	file := &PayloadFile{
+		Name: string(name[:nameSize-1]),
+		Mode: uint32(mode),
+		Size: int64(size),
+	}
+	if file.Name == cpioTrailerName {
+		return nil, io.EOF
+	}
+	pr.remaining = file.Size
+	pr.padding = cpioPadding(file.Size)
+	return file, nil
+}
+
+// Read reads the data of the current file
+func (pr *PayloadReader) Read(b []byte) (int, error) {
+	if pr.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(b)) > pr.remaining {
+		b = b[:pr.remaining]
+	}
+	n, err := pr.r.Read(b)
+	pr.remaining -= int64(n)
+	if err == io.EOF && pr.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// Close releases the resources of the decompressor
+func (pr *PayloadReader) Close() error {
+	return pr.decompressor.Close()
+}
+
+// PayloadWriter writes a cpio payload, for crafting test fixtures.
+// The payload has to be compressed separately.
+type PayloadWriter struct {
+	w   io.Writer
+	ino int
+}
+
+// NewPayloadWriter returns a writer of a cpio payload to w
+func NewPayloadWriter(w io.Writer) *PayloadWriter {
+	return &PayloadWriter{w: w}
+}
+
+func (pw *PayloadWriter) writeEntry(file PayloadFile, data []byte) error {
+	pw.ino++
+	nlink := 1
+	if file.Name == cpioTrailerName {
+		nlink = 0
+	}
+	header := fmt.Sprintf("%s%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+		cpioNewcMagic, pw.ino, file.Mode, 0, 0, nlink, 0, len(data),
+		0, 0, 0, 0, len(file.Name)+1, 0)
+	buf := append([]byte(header), file.Name...)
+	buf = append(buf, 0)
+	buf = append(buf, make([]byte, cpioPadding(int64(len(buf))))...)
+	buf = append(buf, data...)
+	buf = append(buf, make([]byte, cpioPadding(int64(len(data))))...)
+	_, err := pw.w.Write(buf)
+	return err
+}
+
+// WriteFile writes a file with its data. file.Size is ignored.
+func (pw *PayloadWriter) WriteFile(file PayloadFile, data []byte) error {
+	if file.Name == cpioTrailerName {
+		return fmt.Errorf("reserved file name %s", file.Name)
+	}
+	return pw.writeEntry(file, data)
+}
+
+// Close writes the trailer
+func (pw *PayloadWriter) Close() error {
+	return pw.writeEntry(PayloadFile{Name: cpioTrailerName}, nil)
+}
@@ -0,0 +1,384 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+// Package rpmfile reads the lead, signature header and main header of
+// RPM and SRPM files, so that packages can be queried without rpm.
+// It can also write packages, for crafting test fixtures.
+package rpmfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var leadMagic = []byte{0xed, 0xab, 0xee, 0xdb}
+
+const (
+	leadSize = 96
+	// Lead types
+	leadTypeBinary = 0
+	leadTypeSource = 1
+	// The only signature type in use, signature header follows the lead
+	leadSignatureType = 5
+)
+
+// Lead is the legacy fixed size header at the start of an RPM file
+type Lead struct {
+	Major uint8
+	Minor uint8
+	Type  uint16
+	Arch  uint16
+	Name  string
+	OS    uint16
+}
+
+// Package is an RPM file's lead, signature header and main header
+type Package struct {
+	Lead      Lead
+	Signature *Header
+	Header    *Header
+}
+
+func readLead(r io.Reader) (Lead, error) {
+	var buf [leadSize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return Lead{}, fmt.Errorf("error reading lead: %s", err)
+	}
+	if !bytes.Equal(buf[:4], leadMagic) {
+		return Lead{}, fmt.Errorf("bad lead magic %x, not an rpm", buf[:4])
+	}
+	if sigType := binary.BigEndian.Uint16(buf[78:80]); sigType != leadSignatureType {
+		return Lead{}, fmt.Errorf("unsupported signature type %d", sigType)
+	}
+	name := buf[10:76]
+	if nul := bytes.IndexByte(name, 0); nul >= 0 {
+		name = name[:nul]
+	}
+	return Lead{
+		Major: buf[4],
+		Minor: buf[5],
+		Type:  binary.BigEndian.Uint16(buf[6:8]),
+		Arch:  binary.BigEndian.Uint16(buf[8:10]),
+		Name:  string(name),
+		OS:    binary.BigEndian.Uint16(buf[76:78]),
+	}, nil
+}
+
+func (lead *Lead) marshal() []byte {
+	buf := make([]byte, leadSize)
+	copy(buf, leadMagic)
+	buf[4] = lead.Major
+	buf[5] = lead.Minor
+	binary.BigEndian.PutUint16(buf[6:8], lead.Type)
+	binary.BigEndian.PutUint16(buf[8:10], lead.Arch)
+	// Name is NUL terminated
+	name := lead.Name
+	if len(name) > 65 {
+		name = name[:65]
+	}
+	copy(buf[10:75], name)
+	binary.BigEndian.PutUint16(buf[76:78], lead.OS)
+	binary.BigEndian.PutUint16(buf[78:80], leadSignatureType)
+	return buf
+}
+
+// Read reads the lead and headers of an RPM from r.
+// r is left positioned at the start of the payload.
+func Read(r io.Reader) (*Package, error) {
+	lead, err := readLead(r)
+	if err != nil {
+		return nil, err
+	}
+	sig, sigSize, err := readHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("signature header: %s", err)
+	}
+	// The signature header is padded to a multiple of 8 bytes
+	if pad := (8 - sigSize%8) % 8; pad != 0 {
+		if _, err := io.CopyN(io.Discard, r, int64(pad)); err != nil {
+			return nil, fmt.Errorf("signature header: error reading padding: %s", err)
+		}
+	}
+	hdr, _, err := readHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("header: %s", err)
+	}
+	return &Package{Lead: lead, Signature: sig, Header: hdr}, nil
+}
+
+// Open reads the lead and headers of the RPM file at path
+func Open(path string) (*Package, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	pkg, err := Read(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return pkg, nil
+}
+
+// NewPackage returns a package with the main header hdr, an empty
+// signature header and a lead derived from hdr.
+func NewPackage(hdr *Header) *Package {
+	pkg := &Package{
+		Signature: NewHeader(),
+		Header:    hdr,
+	}
+	pkg.Lead = Lead{
+		Major: 3,
+		Name:  pkg.NVR(),
+		OS:    1,
+	}
+	if pkg.IsSource() {
+		pkg.Lead.Type = leadTypeSource
+	} else {
+		pkg.Lead.Type = leadTypeBinary
+	}
+	return pkg
+}
+
+// Write writes the package to w, followed by the payload.
+func (pkg *Package) Write(w io.Writer, payload []byte) error {
+	sig, err := pkg.Signature.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("signature header: %s", err)
+	}
+	hdr, err := pkg.Header.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("header: %s", err)
+	}
+	buf := pkg.Lead.marshal()
+	buf = append(buf, sig...)
+	buf = append(buf, make([]byte, (8-len(sig)%8)%8)...)
+	buf = append(buf, hdr...)
+	buf = append(buf, payload...)
+	_, err = w.Write(buf)
+	return err
+}
+
+// Name returns the package name
+func (pkg *Package) Name() string {
+	return pkg.Header.String(TagName)
+}
+
+// Version returns the package version
+func (pkg *Package) Version() string {
+	return pkg.Header.String(TagVersion)
+}
+
+// Release returns the package release
+func (pkg *Package) Release() string {
+	return pkg.Header.String(TagRelease)
+}
+
+// Epoch returns the package epoch, and false if there's none
+func (pkg *Package) Epoch() (uint64, bool) {
+	return pkg.Header.Uint64(TagEpoch)
+}
+
+// Arch returns the package architecture, which is "src" for SRPMs
+func (pkg *Package) Arch() string {
+	if pkg.IsSource() {
+		return "src"
+	}
+	return pkg.Header.String(TagArch)
+}
+
+// IsSource checks if the package is an SRPM.
+// Like rpm, a package without a source rpm is an SRPM.
+func (pkg *Package) IsSource() bool {
+	return !pkg.Header.Has(TagSourceRPM)
+}
+
+// NVR returns name-version-release
+func (pkg *Package) NVR() string {
+	return fmt.Sprintf("%s-%s-%s", pkg.Name(), pkg.Version(), pkg.Release())
+}
+
+// NEVRA returns name-[epoch:]version-release.arch
+func (pkg *Package) NEVRA() string {
+	evr := pkg.Version() + "-" + pkg.Release()
+	if epoch, found := pkg.Epoch(); found {
+		evr = fmt.Sprintf("%d:%s", epoch, evr)
+	}
+	return fmt.Sprintf("%s-%s.%s", pkg.Name(), evr, pkg.Arch())
+}
+
+// Dependency flags
+const (
+	SenseLess    = 1 << 1
+	SenseGreater = 1 << 2
+	SenseEqual   = 1 << 3
+	// Dependencies on rpm features, like rpmlib(CompressedFileNames)
+	SenseRpmlib = 1 << 24
+)
+
+// Dependency is a Requires or Provides entry
+type Dependency struct {
+	Name    string
+	Flags   uint32
+	Version string
+}
+
+// String formats the dependency like rpm, Eg: foo >= 1.0
+func (dep Dependency) String() string {
+	if dep.Version == "" {
+		return dep.Name
+	}
+	var op string
+	if dep.Flags&SenseLess != 0 {
+		op += "<"
+	}
+	if dep.Flags&SenseGreater != 0 {
+		op += ">"
+	}
+	if dep.Flags&SenseEqual != 0 {
+		op += "="
+	}
+	return fmt.Sprintf("%s %s %s", dep.Name, op, dep.Version)
+}
+
+func (pkg *Package) dependencies(nameTag, flagsTag, versionTag Tag) []Dependency {
+	names := pkg.Header.StringArray(nameTag)
+	flags := pkg.Header.Uint64Array(flagsTag)
+	versions := pkg.Header.StringArray(versionTag)
+	deps := make([]Dependency, 0, len(names))
+	for i, name := range names {
+		dep := Dependency{Name: name}
+		if i < len(flags) {
+			dep.Flags = uint32(flags[i])
+		}
+		if i < len(versions) {
+			dep.Version = versions[i]
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+// Requires returns the Requires of the package, including the
+// rpmlib() dependencies.
+func (pkg *Package) Requires() []Dependency {
+	return pkg.dependencies(TagRequireName, TagRequireFlags, TagRequireVersion)
+}
+
+// Provides returns the Provides of the package
+func (pkg *Package) Provides() []Dependency {
+	return pkg.dependencies(TagProvideName, TagProvideFlags, TagProvideVersion)
+}
+
+// BuildRequires returns the BuildRequires of an SRPM, which are stored
+// as its Requires. nil is returned for binary RPMs.
+func (pkg *Package) BuildRequires() []Dependency {
+	if !pkg.IsSource() {
+		return nil
+	}
+	var buildRequires []Dependency
+	for _, dep := range pkg.Requires() {
+		if dep.Flags&SenseRpmlib == 0 {
+			buildRequires = append(buildRequires, dep)
+		}
+	}
+	return buildRequires
+}
+
+// File is an entry in the package's file list
+type File struct {
+	Path   string
+	Mode   uint32
+	Size   uint64
+	Digest string
+	Color  uint32
+}
+
+// Files returns the package's file list
+func (pkg *Package) Files() ([]File, error) {
+	hdr := pkg.Header
+	var paths []string
+	if hdr.Has(TagBaseNames) {
+		baseNames := hdr.StringArray(TagBaseNames)
+		dirNames := hdr.StringArray(TagDirNames)
+		dirIndexes := hdr.Uint64Array(TagDirIndexes)
+		if len(dirIndexes) != len(baseNames) {
+			return nil, fmt.Errorf("%d dir indexes for %d files",
+				len(dirIndexes), len(baseNames))
+		}
+		for i, baseName := range baseNames {
+			if dirIndexes[i] >= uint64(len(dirNames)) {
+				return nil, fmt.Errorf("bad dir index %d for %s", dirIndexes[i], baseName)
+			}
+			paths = append(paths, dirNames[dirIndexes[i]]+baseName)
+		}
+	} else {
+		paths = hdr.StringArray(TagOldFileNames)
+	}
+
+	sizes := hdr.Uint64Array(TagLongFileSizes)
+	if sizes == nil {
+		sizes = hdr.Uint64Array(TagFileSizes)
+	}
+	modes := hdr.Uint64Array(TagFileModes)
+	digests := hdr.StringArray(TagFileDigests)
+	colors := hdr.Uint64Array(TagFileColors)
+
+	files := make([]File, 0, len(paths))
+	for i, path := range paths {
+		file := File{Path: path}
+		if i < len(sizes) {
+			file.Size = sizes[i]
+		}
+		if i < len(modes) {
+			file.Mode = uint32(modes[i])
+		}
+		if i < len(digests) {
+			file.Digest = digests[i]
+		}
+		if i < len(colors) {
+			file.Color = uint32(colors[i])
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// SignatureKeyIDs returns the IDs of the keys which signed the package,
+// as upper case hex strings. nil is returned if the package isn't signed.
+func (pkg *Package) SignatureKeyIDs() ([]string, error) {
+	var sigs [][]byte
+	for _, tag := range []Tag{SigTagRSA, SigTagDSA, SigTagPGP, SigTagGPG} {
+		if sig := pkg.Signature.Bin(tag); sig != nil {
+			sigs = append(sigs, sig)
+		}
+	}
+	// OpenPGP signatures of rpm v6 packages are base64 encoded
+	for _, sig := range pkg.Signature.StringArray(SigTagOpenPGP) {
+		decoded, err := decodeBase64(sig)
+		if err != nil {
+			return nil, fmt.Errorf("bad OpenPGP signature: %s", err)
+		}
+		sigs = append(sigs, decoded)
+	}
+
+	var keyIDs []string
+	seen := make(map[string]bool)
+	for _, sig := range sigs {
+		keyID, err := signatureKeyID(sig)
+		if err != nil {
+			return nil, err
+		}
+		keyID = strings.ToUpper(keyID)
+		if !seen[keyID] {
+			seen[keyID] = true
+			keyIDs = append(keyIDs, keyID)
+		}
+	}
+	return keyIDs, nil
+}
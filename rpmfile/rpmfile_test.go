@@ -0,0 +1,236 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package rpmfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHeader(name string, srpm bool) *Header {
+	hdr := NewHeader()
+	hdr.SetString(TagName, name)
+	hdr.SetString(TagVersion, "1.2")
+	hdr.SetString(TagRelease, "3.el9")
+	hdr.SetString(TagOS, "linux")
+	if srpm {
+		hdr.SetString(TagArch, "x86_64")
+		hdr.SetInt32Array(TagSourcePackage, []uint32{1})
+		hdr.SetStringArray(TagRequireName, []string{"gcc", "zlib-devel", "rpmlib(CompressedFileNames)"})
+		hdr.SetInt32Array(TagRequireFlags, []uint32{0, SenseGreater | SenseEqual, SenseRpmlib | SenseLess | SenseEqual})
+		hdr.SetStringArray(TagRequireVersion, []string{"", "1.2.11", "3.0.4-1"})
+	} else {
+		hdr.SetString(TagArch, "x86_64")
+		hdr.SetString(TagSourceRPM, name+"-1.2-3.el9.src.rpm")
+		hdr.SetInt32Array(TagEpoch, []uint32{2})
+		hdr.SetStringArray(TagRequireName, []string{"libc.so.6()(64bit)"})
+		hdr.SetInt32Array(TagRequireFlags, []uint32{0})
+		hdr.SetStringArray(TagRequireVersion, []string{""})
+		hdr.SetStringArray(TagProvideName, []string{name, name + "(x86-64)"})
+		hdr.SetInt32Array(TagProvideFlags, []uint32{SenseEqual, SenseEqual})
+		hdr.SetStringArray(TagProvideVersion, []string{"2:1.2-3.el9", "2:1.2-3.el9"})
+		hdr.SetStringArray(TagDirNames, []string{"/usr/bin/", "/usr/share/doc/" + name + "/"})
+		hdr.SetStringArray(TagBaseNames, []string{name, "README"})
+		hdr.SetInt32Array(TagDirIndexes, []uint32{0, 1})
+		hdr.SetInt16Array(TagFileModes, []uint16{0100755, 0100644})
+		hdr.SetInt32Array(TagFileSizes, []uint32{4096, 12})
+		hdr.SetStringArray(TagFileDigests, []string{"aa", "bb"})
+		hdr.SetInt32Array(TagFileColors, []uint32{2, 0})
+	}
+	return hdr
+}
+
+func writeTestPackage(t *testing.T, pkg *Package, payload []byte) string {
+	path := filepath.Join(t.TempDir(), pkg.NEVRA()+".rpm")
+	var buf bytes.Buffer
+	require.NoError(t, pkg.Write(&buf, payload))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+	return path
+}
+
+func TestReadRpm(t *testing.T) {
+	rsaSig, err := os.ReadFile("testData/rsa.sig")
+	require.NoError(t, err)
+
+	t.Log("Testing binary RPM")
+	pkg := NewPackage(newTestHeader("foo", false))
+	pkg.Signature.SetBin(SigTagRSA, rsaSig)
+	pkg.Signature.SetInt32Array(SigTagSize, []uint32{1234})
+	path := writeTestPackage(t, pkg, []byte("payload"))
+
+	readPkg, err := Open(path)
+	require.NoError(t, err)
+	require.False(t, readPkg.IsSource())
+	require.Equal(t, "foo-2:1.2-3.el9.x86_64", readPkg.NEVRA())
+	require.Equal(t, "foo-1.2-3.el9", readPkg.Lead.Name)
+	require.Equal(t, uint16(leadTypeBinary), readPkg.Lead.Type)
+	require.Equal(t, []Dependency{{Name: "libc.so.6()(64bit)"}}, readPkg.Requires())
+	require.Nil(t, readPkg.BuildRequires())
+	require.Equal(t, "foo = 2:1.2-3.el9", readPkg.Provides()[0].String())
+
+	files, err := readPkg.Files()
+	require.NoError(t, err)
+	require.Equal(t, []File{
+		{Path: "/usr/bin/foo", Mode: 0100755, Size: 4096, Digest: "aa", Color: 2},
+		{Path: "/usr/share/doc/foo/README", Mode: 0100644, Size: 12, Digest: "bb"},
+	}, files)
+
+	keyIDs, err := readPkg.SignatureKeyIDs()
+	require.NoError(t, err)
+	require.Equal(t, []string{"CA4EA3DBFC519B64"}, keyIDs)
+
+	t.Log("Testing Read leaves the reader at the payload")
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	reader := bytes.NewReader(contents)
+	_, err = Read(reader)
+	require.NoError(t, err)
+	payload, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "payload", string(payload))
+
+	t.Log("Testing SRPM")
+	srpm := NewPackage(newTestHeader("bar", true))
+	path = writeTestPackage(t, srpm, nil)
+	readSrpm, err := Open(path)
+	require.NoError(t, err)
+	require.True(t, readSrpm.IsSource())
+	require.Equal(t, uint16(leadTypeSource), readSrpm.Lead.Type)
+	require.Equal(t, "bar-1.2-3.el9.src", readSrpm.NEVRA())
+	require.Len(t, readSrpm.Requires(), 3)
+	buildRequires := readSrpm.BuildRequires()
+	require.Len(t, buildRequires, 2)
+	require.Equal(t, "gcc", buildRequires[0].String())
+	require.Equal(t, "zlib-devel >= 1.2.11", buildRequires[1].String())
+	keyIDs, err = readSrpm.SignatureKeyIDs()
+	require.NoError(t, err)
+	require.Empty(t, keyIDs)
+
+	t.Log("Testing bad RPMs")
+	badPath := filepath.Join(t.TempDir(), "bad.rpm")
+	require.NoError(t, os.WriteFile(badPath, []byte("not an rpm"), 0644))
+	_, err = Open(badPath)
+	require.ErrorContains(t, err, "error reading lead")
+	require.NoError(t, os.WriteFile(badPath, contents[:len(contents)-20], 0644))
+	_, err = Open(badPath)
+	require.ErrorContains(t, err, "header")
+	corrupt := append([]byte{}, contents...)
+	corrupt[leadSize] = 0
+	require.NoError(t, os.WriteFile(badPath, corrupt, 0644))
+	_, err = Open(badPath)
+	require.ErrorContains(t, err, "bad header magic")
+}
+
+func TestSignatureKeyIDs(t *testing.T) {
+	rsaSig, err := os.ReadFile("testData/rsa.sig")
+	require.NoError(t, err)
+	ed25519Sig, err := os.ReadFile("testData/ed25519.sig")
+	require.NoError(t, err)
+	// v3 signature packet in old format with a 1 byte length
+	v3Sig := []byte{0x88, 0x10, 3, 5, 0, 0x60, 0, 0, 0,
+		0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 1, 8}
+
+	pkg := NewPackage(newTestHeader("foo", false))
+	pkg.Signature.SetBin(SigTagPGP, v3Sig)
+	pkg.Signature.SetStringArray(SigTagOpenPGP, []string{
+		base64.StdEncoding.EncodeToString(ed25519Sig),
+		base64.StdEncoding.EncodeToString(rsaSig),
+	})
+	keyIDs, err := pkg.SignatureKeyIDs()
+	require.NoError(t, err)
+	require.Equal(t, []string{"0123456789ABCDEF", "39774110D9B38FCE", "CA4EA3DBFC519B64"}, keyIDs)
+
+	t.Log("Testing bad signatures")
+	for _, badSig := range [][]byte{
+		nil,
+		rsaSig[:len(rsaSig)-1],
+		// Not a signature packet
+		{0xc6, 0x01, 0x00},
+		v3Sig[:10],
+	} {
+		pkg.Signature.SetBin(SigTagPGP, badSig)
+		_, err := pkg.SignatureKeyIDs()
+		require.Error(t, err)
+	}
+}
+
+func TestPayloadReader(t *testing.T) {
+	var cpio bytes.Buffer
+	payloadWriter := NewPayloadWriter(&cpio)
+	require.NoError(t, payloadWriter.WriteFile(PayloadFile{Name: "foo.spec", Mode: 0100644},
+		[]byte("Name: foo\n")))
+	require.NoError(t, payloadWriter.WriteFile(PayloadFile{Name: "a", Mode: 0100600},
+		[]byte("12345")))
+	require.NoError(t, payloadWriter.WriteFile(PayloadFile{Name: "./usr/bin/foo", Mode: 0100755},
+		[]byte("binary")))
+	require.NoError(t, payloadWriter.Close())
+
+	var payload bytes.Buffer
+	gzipWriter := gzip.NewWriter(&payload)
+	_, err := gzipWriter.Write(cpio.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+
+	hdr := newTestHeader("foo", true)
+	pkg := NewPackage(hdr)
+	var buf bytes.Buffer
+	require.NoError(t, pkg.Write(&buf, payload.Bytes()))
+
+	reader := bytes.NewReader(buf.Bytes())
+	readPkg, err := Read(reader)
+	require.NoError(t, err)
+	payloadReader, err := readPkg.NewPayloadReader(reader)
+	require.NoError(t, err)
+	defer payloadReader.Close()
+
+	file, err := payloadReader.Next()
+	require.NoError(t, err)
+	require.Equal(t, PayloadFile{Name: "foo.spec", Mode: 0100644, Size: 10}, *file)
+	data, err := io.ReadAll(payloadReader)
+	require.NoError(t, err)
+	require.Equal(t, "Name: foo\n", string(data))
+
+	t.Log("Testing partially read file is skipped")
+	file, err = payloadReader.Next()
+	require.NoError(t, err)
+	require.Equal(t, "a", file.Name)
+	partial := make([]byte, 2)
+	_, err = io.ReadFull(payloadReader, partial)
+	require.NoError(t, err)
+	file, err = payloadReader.Next()
+	require.NoError(t, err)
+	require.Equal(t, PayloadFile{Name: "./usr/bin/foo", Mode: 0100755, Size: 6}, *file)
+	data, err = io.ReadAll(payloadReader)
+	require.NoError(t, err)
+	require.Equal(t, "binary", string(data))
+
+	_, err = payloadReader.Next()
+	require.Equal(t, io.EOF, err)
+
+	t.Log("Testing bad payloads")
+	hdr.SetString(TagPayloadCompress, "7z")
+	_, err = pkg.NewPayloadReader(bytes.NewReader(payload.Bytes()))
+	require.ErrorContains(t, err, "unsupported compression format")
+	hdr.SetString(TagPayloadCompress, "gzip")
+	hdr.SetString(TagPayloadFormat, "drpm")
+	_, err = pkg.NewPayloadReader(bytes.NewReader(payload.Bytes()))
+	require.ErrorContains(t, err, "unsupported payload format")
+	hdr.SetString(TagPayloadFormat, "cpio")
+	var notCpio bytes.Buffer
+	gzipWriter = gzip.NewWriter(&notCpio)
+	_, err = gzipWriter.Write(bytes.Repeat([]byte("x"), 200))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	payloadReader, err = pkg.NewPayloadReader(&notCpio)
+	require.NoError(t, err)
+	_, err = payloadReader.Next()
+	require.ErrorContains(t, err, "unsupported cpio magic")
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package rpmfile
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// OpenPGP(RFC 4880, RFC 9580) packet and subpacket types
+const (
+	pgpTagSignature        = 2
+	pgpSubpacketIssuer     = 16
+	pgpSubpacketIssuerFpr  = 33
+	pgpSubpacketTypeMask   = 0x7f
+	pgpIssuerFprV4Len      = 20
+	pgpIssuerFprV6Len      = 32
+	pgpSigV3HashedLen      = 5
+	pgpSigV3KeyIDOffset    = 7
+	pgpSigV4SubpacketStart = 4
+)
+
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+}
+
+// pgpPacketBody returns the body of the single OpenPGP packet in data
+// and its tag. Both the old and new packet formats are supported,
+// except for partial body lengths.
+func pgpPacketBody(data []byte) (byte, []byte, error) {
+	if len(data) < 2 || data[0]&0x80 == 0 {
+		return 0, nil, fmt.Errorf("bad OpenPGP packet header")
+	}
+	var tag byte
+	var length, headerLen int
+	if data[0]&0x40 != 0 {
+		// New format
+		tag = data[0] & 0x3f
+		switch first := int(data[1]); {
+		case first < 192:
+			length, headerLen = first, 2
+		case first < 224:
+			if len(data) < 3 {
+				return 0, nil, fmt.Errorf("truncated OpenPGP packet header")
+			}
+			length, headerLen = (first-192)<<8+int(data[2])+192, 3
+		case first == 255:
+			if len(data) < 6 {
+				return 0, nil, fmt.Errorf("truncated OpenPGP packet header")
+			}
+			length, headerLen = int(binary.BigEndian.Uint32(data[2:6])), 6
+		default:
+			return 0, nil, fmt.Errorf("unsupported OpenPGP partial body length")
+		}
+	} else {
+		// Old format
+		tag = (data[0] >> 2) & 0xf
+		switch data[0] & 0x3 {
+		case 0:
+			length, headerLen = int(data[1]), 2
+		case 1:
+			if len(data) < 3 {
+				return 0, nil, fmt.Errorf("truncated OpenPGP packet header")
+			}
+			length, headerLen = int(binary.BigEndian.Uint16(data[1:3])), 3
+		case 2:
+			if len(data) < 5 {
+				return 0, nil, fmt.Errorf("truncated OpenPGP packet header")
+			}
+			length, headerLen = int(binary.BigEndian.Uint32(data[1:5])), 5
+		default:
+			// Indeterminate length, the packet extends to the end
+			length, headerLen = len(data)-1, 1
+		}
+	}
+	if length < 0 || headerLen+length > len(data) {
+		return 0, nil, fmt.Errorf("truncated OpenPGP packet")
+	}
+	return tag, data[headerLen : headerLen+length], nil
+}
+
+// subpacketsIssuer returns the issuer key ID in the signature subpackets
+func subpacketsIssuer(subpackets []byte) (string, error) {
+	var fprKeyID string
+	for len(subpackets) > 0 {
+		var length, headerLen int
+		switch first := int(subpackets[0]); {
+		case first < 192:
+			length, headerLen = first, 1
+		case first < 255:
+			if len(subpackets) < 2 {
+				return "", fmt.Errorf("truncated signature subpacket")
+			}
+			length, headerLen = (first-192)<<8+int(subpackets[1])+192, 2
+		default:
+			if len(subpackets) < 5 {
+				return "", fmt.Errorf("truncated signature subpacket")
+			}
+			length, headerLen = int(binary.BigEndian.Uint32(subpackets[1:5])), 5
+		}
+		if length < 1 || headerLen+length > len(subpackets) {
+			return "", fmt.Errorf("truncated signature subpacket")
+		}
+		subpacketType := subpackets[headerLen] & pgpSubpacketTypeMask
+		body := subpackets[headerLen+1 : headerLen+length]
+		subpackets = subpackets[headerLen+length:]
+
+		switch subpacketType {
+		case pgpSubpacketIssuer:
+			if len(body) == 8 {
+				return hex.EncodeToString(body), nil
+			}
+		case pgpSubpacketIssuerFpr:
+			// Key version followed by the fingerprint. The key ID is the
+			// last 8 bytes of a v4 fingerprint and the first 8 of a v6 one.
+			if len(body) == 1+pgpIssuerFprV4Len {
+				fprKeyID = hex.EncodeToString(body[1+pgpIssuerFprV4Len-8:])
+			} else if len(body) == 1+pgpIssuerFprV6Len {
+				fprKeyID = hex.EncodeToString(body[1:9])
+			}
+		}
+	}
+	if fprKeyID == "" {
+		return "", fmt.Errorf("no issuer in OpenPGP signature")
+	}
+	return fprKeyID, nil
+}
+
+// signatureKeyID returns the ID of the key which made the
+// OpenPGP signature packet.
+func signatureKeyID(packet []byte) (string, error) {
+	tag, body, err := pgpPacketBody(packet)
+	if err != nil {
+		return "", err
+	}
+	if tag != pgpTagSignature {
+		return "", fmt.Errorf("unexpected OpenPGP packet type %d, expected signature", tag)
+	}
+	if len(body) < 1 {
+		return "", fmt.Errorf("empty OpenPGP signature")
+	}
+
+	switch version := body[0]; version {
+	case 3:
+		if len(body) < pgpSigV3KeyIDOffset+8 || body[1] != pgpSigV3HashedLen {
+			return "", fmt.Errorf("bad v3 OpenPGP signature")
+		}
+		return hex.EncodeToString(body[pgpSigV3KeyIDOffset : pgpSigV3KeyIDOffset+8]), nil
+	case 4, 5, 6:
+		if len(body) < pgpSigV4SubpacketStart {
+			return "", fmt.Errorf("truncated v%d OpenPGP signature", version)
+		}
+		// v4 subpacket areas have 2 byte lengths, v5 and v6 have 4 byte ones
+		lenSize := 2
+		if version != 4 {
+			lenSize = 4
+		}
+		readLen := func(data []byte) int {
+			if lenSize == 2 {
+				return int(binary.BigEndian.Uint16(data))
+			}
+			return int(binary.BigEndian.Uint32(data))
+		}
+		rest := body[pgpSigV4SubpacketStart:]
+		var subpackets []byte
+		// Hashed, then unhashed subpackets
+		for i := 0; i < 2; i++ {
+			if len(rest) < lenSize {
+				return "", fmt.Errorf("truncated v%d OpenPGP signature", version)
+			}
+			length := readLen(rest)
+			if length < 0 || lenSize+length > len(rest) {
+				return "", fmt.Errorf("truncated v%d OpenPGP signature", version)
+			}
+			subpackets = append(subpackets, rest[lenSize:lenSize+length]...)
+			rest = rest[lenSize+length:]
+		}
+		return subpacketsIssuer(subpackets)
+	default:
+		return "", fmt.Errorf("unsupported OpenPGP signature version %d", version)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package rpmfile
+
+import (
+	"strconv"
+	"strings"
+)
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// VerCmp compares the versions a and b like rpmvercmp, and returns
+// -1, 0 or 1 if a is older than, the same as or newer than b.
+// Versions are compared by their numeric and alphabetic segments,
+// numeric segments are newer than alphabetic ones. '~' sorts before
+// anything, even the end of the version, and '^' after the end of the
+// version but before anything else.
+func VerCmp(a string, b string) int {
+	if a == b {
+		return 0
+	}
+	for len(a) > 0 || len(b) > 0 {
+		for len(a) > 0 && !isDigit(a[0]) && !isAlpha(a[0]) && a[0] != '~' && a[0] != '^' {
+			a = a[1:]
+		}
+		for len(b) > 0 && !isDigit(b[0]) && !isAlpha(b[0]) && b[0] != '~' && b[0] != '^' {
+			b = b[1:]
+		}
+
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			if len(a) == 0 {
+				return -1
+			}
+			if len(b) == 0 {
+				return 1
+			}
+			if !strings.HasPrefix(a, "^") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "^") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if len(a) == 0 || len(b) == 0 {
+			break
+		}
+
+		isNum := isDigit(a[0])
+		inSegment := isAlpha
+		if isNum {
+			inSegment = isDigit
+		}
+		aEnd, bEnd := 0, 0
+		for aEnd < len(a) && inSegment(a[aEnd]) {
+			aEnd++
+		}
+		for bEnd < len(b) && inSegment(b[bEnd]) {
+			bEnd++
+		}
+		aSegment, bSegment := a[:aEnd], b[:bEnd]
+		a, b = a[aEnd:], b[bEnd:]
+		// Segments of different types, numeric ones are newer
+		if len(bSegment) == 0 {
+			if isNum {
+				return 1
+			}
+			return -1
+		}
+		if isNum {
+			aSegment = strings.TrimLeft(aSegment, "0")
+			bSegment = strings.TrimLeft(bSegment, "0")
+			if len(aSegment) != len(bSegment) {
+				if len(aSegment) > len(bSegment) {
+					return 1
+				}
+				return -1
+			}
+		}
+		if cmp := strings.Compare(aSegment, bSegment); cmp != 0 {
+			return cmp
+		}
+	}
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	if len(a) == 0 {
+		return -1
+	}
+	return 1
+}
+
+// CompareEVR compares the epoch, version and release of two packages
+// like rpm, an empty epoch is 0.
+func CompareEVR(epochA string, versionA string, releaseA string,
+	epochB string, versionB string, releaseB string) int {
+	parseEpoch := func(epoch string) uint64 {
+		value, _ := strconv.ParseUint(epoch, 10, 64)
+		return value
+	}
+	if a, b := parseEpoch(epochA), parseEpoch(epochB); a != b {
+		if a > b {
+			return 1
+		}
+		return -1
+	}
+	if cmp := VerCmp(versionA, versionB); cmp != 0 {
+		return cmp
+	}
+	return VerCmp(releaseA, releaseB)
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package rpmfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerCmp(t *testing.T) {
+	// From the rpmvercmp tests of rpm
+	for _, testCase := range []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "2.0", -1},
+		{"2.0.1", "2.0", 1},
+		{"2.0.1a", "2.0.1", 1},
+		{"5.5p1", "5.5p10", -1},
+		{"10xyz", "10.1xyz", -1},
+		{"xyz10", "xyz10.1", -1},
+		{"xyz.4", "8", -1},
+		{"1b", "1a", 1},
+		{"010", "10", 0},
+		{"1.0010", "1.9", 1},
+		{"6.0.rc1", "6.0", 1},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~rc1", "1.0~rc2", -1},
+		{"1.0~rc1~git123", "1.0~rc1", -1},
+		{"1.0^", "1.0", 1},
+		{"1.0^git1", "1.0", 1},
+		{"1.0^git1", "1.01", -1},
+		{"1.0^git1~pre", "1.0^git1", -1},
+		{"1.0^git1", "1.0~rc1", 1},
+		{"1.0.0", "1.0^", 1},
+		{"a+", "a_", 0},
+		{"+", "_", 0},
+	} {
+		require.Equal(t, testCase.expected, VerCmp(testCase.a, testCase.b),
+			"%s vs %s", testCase.a, testCase.b)
+		require.Equal(t, -testCase.expected, VerCmp(testCase.b, testCase.a),
+			"%s vs %s", testCase.b, testCase.a)
+	}
+}
+
+func TestCompareEVR(t *testing.T) {
+	require.Equal(t, 0, CompareEVR("", "1.0", "1.el9", "0", "1.0", "1.el9"))
+	require.Equal(t, 1, CompareEVR("1", "1.0", "1", "", "2.0", "1"))
+	require.Equal(t, -1, CompareEVR("", "1.0", "1.el9", "", "1.0", "2.el9"))
+	require.Equal(t, 1, CompareEVR("", "1.10", "1", "", "1.9", "5"))
+}
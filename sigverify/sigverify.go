@@ -0,0 +1,389 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+// Package sigverify verifies OpenPGP detached signatures in-process,
+// without a gpg keyring.
+//
+// RSA, DSA, ECDSA and EdDSA (Ed25519, Ed448) keys are supported, through
+// github.com/ProtonMail/go-crypto/openpgp. Keys which it can't parse are
+// skipped when loading keys. Binary and text signatures are supported,
+// version 3 signatures aren't.
+package sigverify
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+
+	// Register the hashes used by OpenPGP signatures
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// OpenPGP packet tags, RFC 4880 section 4.3
+const (
+	tagSignature    = 2
+	tagPublicKey    = 6
+	tagUserID       = 13
+	tagPublicSubkey = 14
+)
+
+var armorStart = []byte("-----BEGIN PGP ")
+
+// key is a trusted primary key or subkey
+type key struct {
+	publicKey *packet.PublicKey
+	// The primary key of a subkey, or the key itself
+	primary *key
+	// Latest valid self-signature of a primary key
+	// or binding signature of a subkey
+	selfSig *packet.Signature
+	revoked bool
+}
+
+func (k *key) canSign() bool {
+	return k.selfSig == nil || !k.selfSig.FlagsValid || k.selfSig.FlagSign
+}
+
+// expired checks if the key had expired at time t
+func (k *key) expired(t time.Time) bool {
+	if k.selfSig == nil || k.selfSig.KeyLifetimeSecs == nil ||
+		*k.selfSig.KeyLifetimeSecs == 0 {
+		return false
+	}
+	expiry := k.publicKey.CreationTime.Add(
+		time.Duration(*k.selfSig.KeyLifetimeSecs) * time.Second)
+	return t.After(expiry)
+}
+
+func (k *key) updateSelfSig(sig *packet.Signature) {
+	if k.selfSig == nil || sig.CreationTime.After(k.selfSig.CreationTime) {
+		k.selfSig = sig
+	}
+}
+
+// Keyring holds the public keys trusted to make signatures
+type Keyring struct {
+	keys []*key
+}
+
+// Signer describes the key which made a good signature
+type Signer struct {
+	// Fingerprint of the signing key, which may be a subkey
+	Fingerprint string
+	// Fingerprint of the primary key of the signing key
+	PrimaryFingerprint string
+	// Creation time of the signature
+	Created time.Time
+}
+
+func fingerprint(pk *packet.PublicKey) string {
+	return fmt.Sprintf("%X", pk.Fingerprint)
+}
+
+// dearmor returns the binary data of the blocks of blockType in data,
+// which can be ASCII armored or binary. Text around armored blocks
+// is ignored, like gpg does.
+func dearmor(data []byte, blockType string) ([][]byte, error) {
+	if !bytes.Contains(data, armorStart) {
+		return [][]byte{data}, nil
+	}
+	var blocks [][]byte
+	reader := bufio.NewReader(bytes.NewReader(data))
+	for {
+		block, err := armor.Decode(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if block.Type != blockType {
+			return nil, fmt.Errorf("expected '%s', found '%s'", blockType, block.Type)
+		}
+		body, err := io.ReadAll(block.Body)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, body)
+	}
+	return blocks, nil
+}
+
+// addSignature applies a self-signature, binding signature or revocation
+// to the key it follows. Signatures which don't verify, like third party
+// certifications, are ignored.
+func addSignature(k *key, userID *packet.UserId, sig *packet.Signature) bool {
+	isPrimary := k.primary == k
+	primaryKey := k.primary.publicKey
+	switch sig.SigType {
+	case packet.SigTypeKeyRevocation:
+		if isPrimary && primaryKey.VerifyRevocationSignature(sig) == nil {
+			k.revoked = true
+		}
+	case packet.SigTypeSubkeyRevocation:
+		if !isPrimary && primaryKey.VerifySubkeyRevocationSignature(sig, k.publicKey) == nil {
+			k.revoked = true
+		}
+	case packet.SigTypeGenericCert, packet.SigTypePersonaCert,
+		packet.SigTypeCasualCert, packet.SigTypePositiveCert:
+		if isPrimary && userID != nil &&
+			primaryKey.VerifyUserIdSignature(userID.Id, primaryKey, sig) == nil {
+			k.updateSelfSig(sig)
+		}
+	case packet.SigTypeSubkeyBinding:
+		// Also verifies the cross-signature of signing subkeys
+		if !isPrimary && primaryKey.VerifyKeySignature(k.publicKey, sig) == nil {
+			k.updateSelfSig(sig)
+			return true
+		}
+	}
+	return false
+}
+
+// readKeyPackets adds the keys in the binary key data.
+// Subkeys are only trusted with a valid binding signature,
+// and keys which can't be parsed are skipped.
+func (kr *Keyring) readKeyPackets(data []byte) (int, error) {
+	var keys []*key
+	var primary, current *key
+	var userID *packet.UserId
+	currentBound := false
+
+	packets := packet.NewOpaqueReader(bytes.NewReader(data))
+	for {
+		opaque, err := packets.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		p, parseErr := opaque.Parse()
+		switch opaque.Tag {
+		case tagPublicKey:
+			primary, current, userID = nil, nil, nil
+			if publicKey, ok := p.(*packet.PublicKey); parseErr == nil && ok {
+				primary = &key{publicKey: publicKey}
+				primary.primary = primary
+				current = primary
+				keys = append(keys, primary)
+			}
+		case tagPublicSubkey:
+			current, userID, currentBound = nil, nil, false
+			if publicKey, ok := p.(*packet.PublicKey); parseErr == nil && ok && primary != nil {
+				current = &key{publicKey: publicKey, primary: primary}
+			}
+		case tagUserID:
+			userID, _ = p.(*packet.UserId)
+		case tagSignature:
+			if sig, ok := p.(*packet.Signature); parseErr == nil && ok && current != nil {
+				if addSignature(current, userID, sig) && !currentBound {
+					currentBound = true
+					keys = append(keys, current)
+				}
+			}
+		}
+	}
+	kr.keys = append(kr.keys, keys...)
+	return len(keys), nil
+}
+
+// ReadKeys adds the armored or binary public keys in r to the keyring,
+// and returns the number of primary keys and subkeys added.
+func (kr *Keyring) ReadKeys(r io.Reader) (int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	blocks, err := dearmor(data, openpgp.PublicKeyType)
+	if err != nil {
+		return 0, err
+	}
+	numKeys := 0
+	for _, block := range blocks {
+		n, err := kr.readKeyPackets(block)
+		if err != nil {
+			return 0, err
+		}
+		numKeys += n
+	}
+	return numKeys, nil
+}
+
+// LoadKeyring returns a keyring with the public keys in the files.
+// Each file must have at least one supported key.
+func LoadKeyring(paths ...string) (*Keyring, error) {
+	kr := &Keyring{}
+	for _, path := range paths {
+		keyFile, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		numKeys, err := kr.ReadKeys(keyFile)
+		keyFile.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading keys in %s: %s", path, err)
+		}
+		if numKeys == 0 {
+			return nil, fmt.Errorf("no supported keys in %s", path)
+		}
+	}
+	return kr, nil
+}
+
+// signingKeys returns the keys matching the issuer of sig
+// which can make signatures
+func (kr *Keyring) signingKeys(sig *packet.Signature) []*key {
+	var keys []*key
+	for _, k := range kr.keys {
+		if sig.CheckKeyIdOrFingerprint(k.publicKey) && k.canSign() {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// sigCheck is a signature to check with a candidate key
+type sigCheck struct {
+	sig  *packet.Signature
+	key  *key
+	hash hash.Hash
+}
+
+// verify checks the signature, and that neither the signing key nor
+// its primary key was revoked, or had expired when the signature was made.
+func (check *sigCheck) verify() (*Signer, error) {
+	created := check.sig.CreationTime
+	if err := check.key.publicKey.VerifySignature(check.hash, check.sig); err != nil {
+		return nil, err
+	}
+	for _, k := range []*key{check.key, check.key.primary} {
+		if k.revoked {
+			return nil, fmt.Errorf("key %s is revoked", fingerprint(k.publicKey))
+		}
+		if k.expired(created) {
+			return nil, fmt.Errorf("signature made after key %s expired",
+				fingerprint(k.publicKey))
+		}
+	}
+	return &Signer{
+		Fingerprint:        fingerprint(check.key.publicKey),
+		PrimaryFingerprint: fingerprint(check.key.primary.publicKey),
+		Created:            created,
+	}, nil
+}
+
+// readSignatures returns the signatures in the armored or binary
+// signature data.
+func readSignatures(signature io.Reader) ([]*packet.Signature, error) {
+	data, err := io.ReadAll(signature)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := dearmor(data, openpgp.SignatureType)
+	if err != nil {
+		return nil, err
+	}
+	var sigs []*packet.Signature
+	for _, block := range blocks {
+		packets := packet.NewReader(bytes.NewReader(block))
+		for {
+			p, err := packets.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			sig, ok := p.(*packet.Signature)
+			if !ok {
+				return nil, fmt.Errorf("unexpected non signature packet")
+			}
+			sigs = append(sigs, sig)
+		}
+	}
+	if len(sigs) == 0 {
+		return nil, fmt.Errorf("no signatures found")
+	}
+	return sigs, nil
+}
+
+// VerifyDetached verifies the detached signature of the signed data with
+// the keys in the keyring. signature can be ASCII armored or binary.
+// When there are multiple signatures, it's enough for one of them
+// to be good. Signatures by revoked keys, or made after the signing key
+// expired, aren't good. This also applies to the primary key of a signing
+// subkey.
+func (kr *Keyring) VerifyDetached(signed io.Reader, signature io.Reader) (*Signer, error) {
+	sigs, err := readSignatures(signature)
+	if err != nil {
+		if _, unsupported := err.(pgperrors.UnsupportedError); unsupported {
+			return nil, fmt.Errorf("unsupported signature: %s", err)
+		}
+		return nil, fmt.Errorf("invalid signature: %s", err)
+	}
+
+	var checks []*sigCheck
+	var hashWriters []io.Writer
+	var issuers []string
+	for _, sig := range sigs {
+		switch {
+		case sig.IssuerFingerprint != nil:
+			issuers = append(issuers, fmt.Sprintf("%X", sig.IssuerFingerprint))
+		case sig.IssuerKeyId != nil:
+			issuers = append(issuers, fmt.Sprintf("%016X", *sig.IssuerKeyId))
+		default:
+			return nil, fmt.Errorf("invalid signature: no issuer")
+		}
+		if sig.SigType != packet.SigTypeBinary && sig.SigType != packet.SigTypeText {
+			return nil, fmt.Errorf("unsupported signature type %d", sig.SigType)
+		}
+		if !sig.Hash.Available() {
+			return nil, fmt.Errorf("unsupported signature hash %s", sig.Hash)
+		}
+		for _, k := range kr.signingKeys(sig) {
+			// Also hashes the salt of v6 signatures
+			sigHash, err := sig.PrepareVerify()
+			if err != nil {
+				return nil, fmt.Errorf("invalid signature: %s", err)
+			}
+			check := &sigCheck{sig: sig, key: k, hash: sigHash}
+			checks = append(checks, check)
+			if sig.SigType == packet.SigTypeText {
+				// Text signatures are of the data with CRLF line endings
+				hashWriters = append(hashWriters, openpgp.NewCanonicalTextHash(check.hash))
+			} else {
+				hashWriters = append(hashWriters, check.hash)
+			}
+		}
+	}
+	if len(checks) == 0 {
+		return nil, fmt.Errorf("no trusted key found for signature issuers %v", issuers)
+	}
+
+	if _, err := io.Copy(io.MultiWriter(hashWriters...), signed); err != nil {
+		return nil, fmt.Errorf("error reading signed data: %s", err)
+	}
+	var verifyErr error
+	for _, check := range checks {
+		signer, err := check.verify()
+		if err == nil {
+			return signer, nil
+		}
+		if verifyErr == nil {
+			verifyErr = err
+		}
+	}
+	return nil, fmt.Errorf("bad signature: %s", verifyErr)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package sigverify
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	signerPrimaryFpr = "0BEBC75C7C49267869FD4EAC1129C5AC00C745C3"
+	signerSubkeyFpr  = "D3358AD6842259F5AF500AB6F2138FB9B6C12D7B"
+	ed25519Fpr       = "33B8937C799E30C64A6FBD4D9194E8446A9C8377"
+	textmodeFpr      = "8F40DF221A79C6DF1E12B367384A64D3BB230B1A"
+)
+
+func verifyFile(t *testing.T, keyring *Keyring, dataPath string, sigPath string) (
+	*Signer, error) {
+	data, err := os.Open(dataPath)
+	require.NoError(t, err)
+	defer data.Close()
+	sig, err := os.Open(sigPath)
+	require.NoError(t, err)
+	defer sig.Close()
+	return keyring.VerifyDetached(data, sig)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	// Armored keys with text before the armor, and binary keys
+	for _, keyPath := range []string{"testData/signer.pem", "testData/signer.gpg"} {
+		t.Logf("Loading %s", keyPath)
+		keyring, err := LoadKeyring(keyPath)
+		require.NoError(t, err)
+		require.Len(t, keyring.keys, 2)
+		require.Equal(t, signerPrimaryFpr, fingerprint(keyring.keys[0].publicKey))
+		// The primary key is certify-only
+		require.False(t, keyring.keys[0].canSign())
+		require.Equal(t, signerSubkeyFpr, fingerprint(keyring.keys[1].publicKey))
+		require.True(t, keyring.keys[1].canSign())
+	}
+
+	t.Log("Loading multiple armored blocks")
+	var keys bytes.Buffer
+	for _, keyPath := range []string{"testData/signer.pem", "testData/other.pem"} {
+		contents, err := os.ReadFile(keyPath)
+		require.NoError(t, err)
+		keys.Write(contents)
+	}
+	keyring := &Keyring{}
+	numKeys, err := keyring.ReadKeys(&keys)
+	require.NoError(t, err)
+	require.Equal(t, 3, numKeys)
+
+	t.Log("Loading an Ed25519 key with a Curve25519 encryption subkey")
+	keyring, err = LoadKeyring("testData/ed25519.pem")
+	require.NoError(t, err)
+	require.Len(t, keyring.keys, 2)
+	require.Equal(t, ed25519Fpr, fingerprint(keyring.keys[0].publicKey))
+	require.True(t, keyring.keys[0].canSign())
+	require.False(t, keyring.keys[1].canSign())
+
+	_, err = LoadKeyring("testData/data.txt.sig")
+	require.ErrorContains(t, err, "no supported keys")
+	_, err = LoadKeyring("testData/data.txt")
+	require.Error(t, err)
+	_, err = LoadKeyring("testData/data.txt.asc")
+	require.ErrorContains(t, err, "expected 'PGP PUBLIC KEY BLOCK'")
+	_, err = LoadKeyring("testData/missing.pem")
+	require.Error(t, err)
+}
+
+func TestVerifyDetached(t *testing.T) {
+	keyring, err := LoadKeyring("testData/signer.pem", "testData/revoked.pem",
+		"testData/expired.pem", "testData/ed25519.pem", "testData/revoked-primary.pem",
+		"testData/expired-primary.pem", "testData/textmode.pem")
+	require.NoError(t, err)
+
+	for _, sigPath := range []string{"testData/data.txt.asc", "testData/data.txt.sig"} {
+		t.Logf("Verifying %s", sigPath)
+		signer, err := verifyFile(t, keyring, "testData/data.txt", sigPath)
+		require.NoError(t, err)
+		require.Equal(t, signerSubkeyFpr, signer.Fingerprint)
+		require.Equal(t, signerPrimaryFpr, signer.PrimaryFingerprint)
+		require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), signer.Created.UTC())
+	}
+
+	t.Log("Verifying Ed25519 signature")
+	signer, err := verifyFile(t, keyring, "testData/data.txt", "testData/ed25519.asc")
+	require.NoError(t, err)
+	require.Equal(t, ed25519Fpr, signer.Fingerprint)
+	require.Equal(t, ed25519Fpr, signer.PrimaryFingerprint)
+
+	t.Log("Verifying text signature")
+	signer, err = verifyFile(t, keyring, "testData/data.txt", "testData/textmode.asc")
+	require.NoError(t, err)
+	require.Equal(t, textmodeFpr, signer.Fingerprint)
+	// Text signatures don't depend on the line endings
+	for data, good := range map[string]bool{
+		"signed data\r\n": true,
+		"signed data \n":  false,
+	} {
+		sig, err := os.Open("testData/textmode.asc")
+		require.NoError(t, err)
+		_, err = keyring.VerifyDetached(strings.NewReader(data), sig)
+		sig.Close()
+		if good {
+			require.NoError(t, err, data)
+		} else {
+			require.ErrorContains(t, err, "bad signature", data)
+		}
+	}
+
+	t.Log("Verifying tampered data")
+	sig, err := os.Open("testData/data.txt.asc")
+	require.NoError(t, err)
+	defer sig.Close()
+	_, err = keyring.VerifyDetached(strings.NewReader("tampered data\n"), sig)
+	require.ErrorContains(t, err, "bad signature")
+
+	t.Log("Verifying with untrusted key")
+	_, err = verifyFile(t, keyring, "testData/data.txt", "testData/other.asc")
+	require.ErrorContains(t, err, "no trusted key found")
+
+	t.Log("Verifying with revoked key")
+	_, err = verifyFile(t, keyring, "testData/data.txt", "testData/revoked.asc")
+	require.ErrorContains(t, err, "revoked")
+
+	t.Log("Verifying signature made after key expired")
+	_, err = verifyFile(t, keyring, "testData/data.txt", "testData/expired.asc")
+	require.ErrorContains(t, err, "expired")
+
+	// The signing subkeys themselves are neither revoked nor expired
+	t.Log("Verifying signing subkey signature with revoked primary key")
+	_, err = verifyFile(t, keyring, "testData/data.txt", "testData/revoked-primary.asc")
+	require.ErrorContains(t, err,
+		"key 783CB2D9F0FB2E4CFD40B436E7E1F8C73435C918 is revoked")
+
+	t.Log("Verifying signing subkey signature made after primary key expired")
+	_, err = verifyFile(t, keyring, "testData/data.txt", "testData/expired-primary.asc")
+	require.ErrorContains(t, err,
+		"signature made after key 5494343FA51BFDFB0C69AE4DC774B1F8212EEA99 expired")
+
+	t.Log("Verifying invalid signature")
+	_, err = verifyFile(t, keyring, "testData/data.txt", "testData/data.txt")
+	require.ErrorContains(t, err, "invalid signature")
+}
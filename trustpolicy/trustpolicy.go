@@ -0,0 +1,140 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package trustpolicy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// TrustPolicyName is the name of the trust policy file in PkiPath
+const TrustPolicyName = "trust-policy.yaml"
+
+// Key IDs can be specified as long(16) key IDs or fingerprints(40).
+// Short(8) key IDs aren't accepted, keys colliding with them are easy to generate.
+var keyIDRegex = regexp.MustCompile(`^([0-9a-fA-F]{16}|[0-9a-fA-F]{40})$`)
+var shortKeyIDRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}$`)
+
+// TrustPolicy spec
+// Specifies the keys allowed to sign upstream SRPMs.
+// Package maps package names, SourceBundle maps source-bundle names
+// to the IDs of the keys allowed to sign them.
+// The package entry takes precedence over the source-bundle entry,
+// which takes precedence over Default.
+// If none of them apply, any key in the rpmkeys dir is allowed.
+type TrustPolicy struct {
+	Default      []string            `yaml:"default"`
+	Package      map[string][]string `yaml:"package"`
+	SourceBundle map[string][]string `yaml:"source-bundle"`
+}
+
+// AllowedKeys returns the IDs of the keys allowed to sign the package
+// from the source-bundle. nil is returned if any key is allowed.
+func (p *TrustPolicy) AllowedKeys(pkg string, sourceBundle string) []string {
+	if keys, found := p.Package[pkg]; found {
+		return keys
+	}
+	if keys, found := p.SourceBundle[sourceBundle]; found && sourceBundle != "" {
+		return keys
+	}
+	return p.Default
+}
+
+// KeyAllowed checks if the key with the fingerprint is one of allowedKeys,
+// which can be long key IDs or fingerprints.
+func KeyAllowed(allowedKeys []string, fingerprint string) bool {
+	for _, keyID := range allowedKeys {
+		if !keyIDRegex.MatchString(keyID) {
+			continue
+		}
+		if strings.HasSuffix(strings.ToLower(fingerprint), strings.ToLower(keyID)) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyIDs returns all the key IDs referred to in the policy
+func (p *TrustPolicy) KeyIDs() []string {
+	keyIDs := append([]string{}, p.Default...)
+	for _, keys := range p.Package {
+		keyIDs = append(keyIDs, keys...)
+	}
+	for _, keys := range p.SourceBundle {
+		keyIDs = append(keyIDs, keys...)
+	}
+	return keyIDs
+}
+
+func (p *TrustPolicy) sanityCheck() error {
+	checkKeys := func(what string, keys []string) error {
+		if keys != nil && len(keys) == 0 {
+			return fmt.Errorf("empty key list for %s", what)
+		}
+		for _, keyID := range keys {
+			if shortKeyIDRegex.MatchString(keyID) {
+				return fmt.Errorf("short key ID '%s' for %s isn't allowed, "+
+					"keys with the same short key ID are easy to generate, "+
+					"use the fingerprint", keyID, what)
+			}
+			if !keyIDRegex.MatchString(keyID) {
+				return fmt.Errorf("bad key ID '%s' for %s, "+
+					"expected a fingerprint or a long key ID", keyID, what)
+			}
+		}
+		return nil
+	}
+
+	if err := checkKeys("default", p.Default); err != nil {
+		return err
+	}
+	for pkg, keys := range p.Package {
+		if err := checkKeys("package "+pkg, keys); err != nil {
+			return err
+		}
+	}
+	for bundle, keys := range p.SourceBundle {
+		if err := checkKeys("source-bundle "+bundle, keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTrustPolicyPath returns the path of the trust policy file in PkiPath
+func GetTrustPolicyPath() string {
+	return filepath.Join(viper.GetString("PkiPath"), TrustPolicyName)
+}
+
+// LoadTrustPolicy loads the trust policy from PkiPath to memory and
+// returns the data structure.
+// If there's no trust policy file, nil is returned.
+func LoadTrustPolicy() (*TrustPolicy, error) {
+	policyPath := GetTrustPolicyPath()
+	yamlContents, readErr := os.ReadFile(policyPath)
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("trustpolicy.LoadTrustPolicy: os.ReadFile on %s returned %s",
+			policyPath, readErr)
+	}
+
+	var policy TrustPolicy
+	if parseErr := yaml.UnmarshalStrict(yamlContents, &policy); parseErr != nil {
+		return nil, fmt.Errorf("trustpolicy.LoadTrustPolicy: Error parsing yaml file %s: %s",
+			policyPath, parseErr)
+	}
+	if sanityErr := policy.sanityCheck(); sanityErr != nil {
+		return nil, fmt.Errorf("trustpolicy.LoadTrustPolicy: %s: %s",
+			policyPath, sanityErr)
+	}
+	return &policy, nil
+}
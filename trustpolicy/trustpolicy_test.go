@@ -0,0 +1,58 @@
+// Copyright (c) 2026 Arista Networks, Inc.  All rights reserved.
+// Arista Networks, Inc. Confidential and Proprietary.
+
+package trustpolicy
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTrustPolicy(t *testing.T) {
+	defer viper.Reset()
+
+	t.Log("Testing valid trust policy")
+	viper.Set("PkiPath", "testData/valid")
+	policy, err := LoadTrustPolicy()
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+	require.Equal(t, []string{"702d426d350d275d"}, policy.AllowedKeys("libfoo", "fedora"))
+	require.Equal(t, []string{"F55AD3FB5323552A", "809A8D7CEB10B464"},
+		policy.AllowedKeys("libbar", "fedora"))
+	require.Equal(t, []string{"21CB256AE16FC54C6E652949702D426D350D275D"},
+		policy.AllowedKeys("libbar", ""))
+	require.ElementsMatch(t, []string{
+		"21CB256AE16FC54C6E652949702D426D350D275D", "F55AD3FB5323552A",
+		"809A8D7CEB10B464", "702d426d350d275d"}, policy.KeyIDs())
+
+	t.Log("Testing no trust policy")
+	viper.Set("PkiPath", "testData")
+	policy, err = LoadTrustPolicy()
+	require.NoError(t, err)
+	require.Nil(t, policy)
+
+	for _, dir := range []string{"badKeyID", "emptyKeys"} {
+		t.Logf("Testing bad trust policy %s", dir)
+		viper.Set("PkiPath", "testData/"+dir)
+		_, err = LoadTrustPolicy()
+		require.ErrorContains(t, err, GetTrustPolicyPath())
+	}
+
+	t.Log("Testing trust policy with a short key ID")
+	viper.Set("PkiPath", "testData/shortKeyID")
+	_, err = LoadTrustPolicy()
+	require.ErrorContains(t, err, "short key ID '5323552A' for source-bundle fedora isn't allowed")
+}
+
+func TestKeyAllowed(t *testing.T) {
+	fingerprint := "ACB5EE4E831C74BB7C168D27F55AD3FB5323552A"
+	require.True(t, KeyAllowed([]string{"f55ad3fb5323552a"}, fingerprint))
+	require.True(t, KeyAllowed([]string{"DEADBEEFDEADBEEF", "F55AD3FB5323552A"}, fingerprint))
+	require.True(t, KeyAllowed([]string{fingerprint}, fingerprint))
+	require.False(t, KeyAllowed([]string{"7C168D27F55AD3FB"}, fingerprint))
+	// Short key IDs never match
+	require.False(t, KeyAllowed([]string{"5323552A"}, fingerprint))
+	require.False(t, KeyAllowed(nil, fingerprint))
+}
@@ -5,7 +5,9 @@ package util
 
 import (
 	"crypto/sha256"
+	"crypto/sha512"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"os/exec"
@@ -14,6 +16,7 @@ import (
 
 	"code.arista.io/eos/tools/eext/executor"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/blake2b"
 )
 
 // Globals type struct exported for global flags
@@ -131,17 +134,37 @@ func GetRepoDir(repo string) string {
 	return repoDir
 }
 
-// Generate SHA256 hash of file
-func GenerateSha256Hash(filePath string) (string, error) {
+// HashAlgorithms are the hash algorithms supported by GenerateHash
+var HashAlgorithms = []string{"sha256", "sha512", "blake2b"}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	case "blake2b":
+		// BLAKE2b-512, as generated by b2sum
+		return blake2b.New512(nil)
+	}
+	return nil, fmt.Errorf("Unsupported hash algorithm %s, must be one of %v",
+		algorithm, HashAlgorithms)
+}
+
+// GenerateHash generates the hash of the file with the algorithm,
+// which is one of HashAlgorithms.
+func GenerateHash(filePath string, algorithm string) (string, error) {
+	hashComputer, hashErr := newHash(algorithm)
+	if hashErr != nil {
+		return "", fmt.Errorf("GenerateHash: %s", hashErr)
+	}
 	file, err := os.Open(filePath)
 	if err != nil {
-		return "", fmt.Errorf("GenerateSha256Hash: %s", err)
+		return "", fmt.Errorf("GenerateHash: %s", err)
 	}
 	defer file.Close()
-	hashComputer := sha256.New()
 	if _, err := io.Copy(hashComputer, file); err != nil {
-		return "", fmt.Errorf("GenerateSha256Hash: %s", err)
+		return "", fmt.Errorf("GenerateHash: %s", err)
 	}
-	sha256Hash := fmt.Sprintf("%x", hashComputer.Sum(nil))
-	return sha256Hash, nil
+	return fmt.Sprintf("%x", hashComputer.Sum(nil)), nil
 }